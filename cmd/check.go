@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/check"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/usage"
+	"github.com/denysvitali/llm-usage/pkg/llmusage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkProvider          string
+	checkAccount           string
+	checkMax               []string
+	checkWarn              []string
+	checkRequireConfigured bool
+	checkAllWindows        bool
+	checkSelection         usage.Selection
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check usage against thresholds with Nagios/Icinga exit codes",
+	Long: `Check every usage window against warning and critical thresholds.
+
+Thresholds are provider[/account][:window]=percent; "*" matches any provider
+or window and the most specific threshold wins. With --warn or --max, only the
+windows they match are checked, falling back to the config file's thresholds
+(75% and 90% by default) for the other limit; --all-windows checks the rest
+against the config file's thresholds too. Without either flag every window is
+checked against the config file's thresholds.
+
+Prints a Nagios/Icinga plugin status line with performance data and exits
+0 (OK), 1 (WARNING), 2 (CRITICAL) or 3 (UNKNOWN, e.g. a failed fetch or,
+with --require-configured, a provider named by a threshold that isn't set up).`,
+	Example: `  # Fail a CI job when Claude's 5-hour window or any Kimi window is nearly exhausted
+  llm-usage check --max claude:5-Hour=90 --max kimi:*=95 --require-configured
+
+  # Icinga/Nagios service check
+  llm-usage check --warn '*=80' --max '*=95'`,
+	Args: cobra.NoArgs,
+	RunE: runCheck,
+
+	Annotations: map[string]string{remoteAnnotation: "true"},
+}
+
+func init() {
+	addProviderFlag(checkCmd, &checkProvider, "all")
+	addAccountFlag(checkCmd, &checkAccount, "all accounts")
+	checkCmd.Flags().StringArrayVar(&checkMax, "max", nil, "Critical threshold, e.g. claude:5-Hour=90 (repeatable)")
+	checkCmd.Flags().StringArrayVar(&checkWarn, "warn", nil, "Warning threshold, e.g. kimi:*=80 (repeatable)")
+	checkCmd.Flags().BoolVar(&checkAllWindows, "all-windows", false, "Also check windows not matched by --warn or --max against the config file's thresholds")
+	addSelectionFlags(checkCmd, &checkSelection)
+	checkCmd.Flags().BoolVar(&checkRequireConfigured, "require-configured", false, "Report UNKNOWN for providers named by thresholds that have no working account")
+	rootCmd.AddCommand(checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, _ []string) error {
+	// The status line on stdout and the exit code are the whole interface
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	applyConfigDefaults(cmd, &checkProvider, &checkAccount, nil)
+
+	opts := check.Options{RequireConfigured: checkRequireConfigured, AllWindows: checkAllWindows}
+	var err error
+	if opts.Warn, err = parseThresholds(checkWarn); err != nil {
+		return checkUnknown(err)
+	}
+	if opts.Max, err = parseThresholds(checkMax); err != nil {
+		return checkUnknown(err)
+	}
+
+	clientOpts := []llmusage.Option{llmusage.WithAccount(checkAccount)}
+	if checkAccount == "" {
+		clientOpts = append(clientOpts, llmusage.WithAllAccounts())
+	}
+	switch {
+	case remoteURL != "":
+		token, err := secretFlag(remoteToken, "LLM_USAGE_REMOTE_TOKEN")
+		if err != nil {
+			return checkUnknown(err)
+		}
+		clientOpts = append(clientOpts, llmusage.WithRemote(remoteURL, token))
+	case credentialsFile != "":
+		clientOpts = append(clientOpts, llmusage.WithCredentialsFile(credentialsFile))
+	}
+	if checkProvider != "all" && checkProvider != "" {
+		clientOpts = append(clientOpts, llmusage.WithProviders(strings.Split(checkProvider, ",")...))
+	}
+	clientOpts = append(clientOpts, withSelection(checkSelection))
+
+	client, err := newUsageClient(clientOpts...)
+	if err != nil {
+		return checkUnknown(err)
+	}
+	stats, err := client.Fetch(cmd.Context())
+	if errors.Is(err, llmusage.ErrNoProviders) {
+		stats, err = &provider.UsageStats{}, nil
+	}
+	if err != nil {
+		return checkUnknown(err)
+	}
+
+	result := check.Evaluate(stats, opts)
+	fmt.Print(result.Output())
+	if result.State == check.OK {
+		return nil
+	}
+	return &exitError{code: int(result.State), err: errors.New(result.State.String())}
+}
+
+// parseThresholds parses --warn or --max values
+func parseThresholds(specs []string) ([]check.Threshold, error) {
+	thresholds := make([]check.Threshold, 0, len(specs))
+	for _, s := range specs {
+		t, err := check.ParseThreshold(s)
+		if err != nil {
+			return nil, err
+		}
+		thresholds = append(thresholds, t)
+	}
+	return thresholds, nil
+}
+
+// checkUnknown reports an error as an UNKNOWN plugin result
+func checkUnknown(err error) error {
+	fmt.Printf("LLM USAGE %s - %v\n", check.Unknown, err)
+	return &exitError{code: int(check.Unknown), err: err}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/denysvitali/llm-usage/internal/engine"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/usage"
+	"github.com/denysvitali/llm-usage/pkg/llmusage"
+)
+
+// usageClient is the public llmusage client, returning results in the
+// provider types the CLI renders. The CLI fetches through it, so it behaves
+// like the library.
+type usageClient struct {
+	*llmusage.Client
+}
+
+// newUsageClient creates a llmusage client
+func newUsageClient(opts ...llmusage.Option) (*usageClient, error) {
+	client, err := llmusage.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &usageClient{Client: client}, nil
+}
+
+// Fetch queries the client's accounts
+func (c *usageClient) Fetch(ctx context.Context) (*provider.UsageStats, error) {
+	stats, err := c.Client.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return engine.Unwrap(stats), nil
+}
+
+// withSelection restricts a client to the accounts matching the selection flags
+func withSelection(sel usage.Selection) llmusage.Option {
+	return func(c *llmusage.Client) {
+		llmusage.WithTags(sel.Tags...)(c)
+		llmusage.WithGroups(sel.Groups...)(c)
+		llmusage.WithExcludeTags(sel.ExcludeTags...)(c)
+	}
+}
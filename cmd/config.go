@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/config"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+// cfg is the loaded config file, empty when there is none
+var cfg = &config.Config{}
+
+var configPath string
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the config file",
+	Long: `Inspect the config file at $XDG_CONFIG_HOME/llm-usage/config.yaml
+(or $LLM_USAGE_CONFIG, or --config).
+
+It sets defaults for --provider, --account, --all-accounts and the output
+flags, warning and critical thresholds per provider, account and window,
+the display order, hidden windows, account aliases and window label renames.
+Flags always win over the config file; LLM_USAGE_* environment variables
+override it.`,
+	// Don't load the config before validating it
+	PersistentPreRun: func(*cobra.Command, []string) {},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective config, including environment overrides",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigShow,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Check a config file for errors",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runConfigValidate,
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Config file (default: $LLM_USAGE_CONFIG or $XDG_CONFIG_HOME/llm-usage/config.yaml)")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
+		if err := loadConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring config file: %v\n", err)
+		}
+		return checkRemote(cmd)
+	}
+
+	configCmd.AddCommand(configShowCmd, configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// resolvedConfigPath returns --config or the default config path
+func resolvedConfigPath() string {
+	if configPath != "" {
+		return configPath
+	}
+	return config.Path()
+}
+
+// readConfig loads the config file and applies the environment overrides
+func readConfig(path string) (*config.Config, error) {
+	c, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ApplyEnv(os.Getenv); err != nil {
+		return nil, err
+	}
+	if c.Output.Format != "" {
+		if _, err := resolveOutput(c.Output.Format, false, false); err != nil {
+			return nil, fmt.Errorf("output.format: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// loadConfig loads the config file into cfg and installs its thresholds
+func loadConfig() error {
+	c, err := readConfig(resolvedConfigPath())
+	if err != nil {
+		return err
+	}
+	cfg = c
+	provider.SetThresholds(cfg.ThresholdsFor)
+	provider.SetLowBalance(cfg.LowBalanceFor)
+	return nil
+}
+
+func runConfigShow(cmd *cobra.Command, _ []string) error {
+	cmd.SilenceUsage = true
+	path := resolvedConfigPath()
+	c, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	data, err := c.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	fmt.Printf("# %s\n", path)
+	if _, err := os.Stat(path); err != nil {
+		fmt.Println("# (not found, showing defaults and environment overrides)")
+	}
+	if s := string(data); s != "{}\n" {
+		fmt.Print(s)
+	}
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	path := resolvedConfigPath()
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("config file not found at %s", path)
+	}
+	if _, err := readConfig(path); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	fmt.Printf("%s is valid\n", path)
+	return nil
+}
+
+// applyConfigDefaults fills provider and account flags the user didn't set
+// from the config file, and resolves account aliases
+func applyConfigDefaults(cmd *cobra.Command, providers, account *string, allAccounts *bool) {
+	flags := cmd.Flags()
+	if providers != nil && !flags.Changed("provider") && len(cfg.Defaults.Providers) > 0 {
+		*providers = strings.Join(cfg.Defaults.Providers, ",")
+	}
+	// Tag and group selectors span accounts, so they skip the default account
+	selecting := flags.Changed("tag") || flags.Changed("group") || flags.Changed("exclude-tag")
+	if account != nil {
+		if !flags.Changed("account") && !selecting && cfg.Defaults.Account != "" {
+			*account = cfg.Defaults.Account
+		}
+		*account = cfg.ResolveAccount(*account)
+	}
+	// An explicit --account wins over the all_accounts default
+	if allAccounts != nil && !flags.Changed("all-accounts") && !flags.Changed("account") && cfg.Defaults.AllAccounts {
+		*allAccounts = true
+	}
+}
+
+// applyOutputDefaults fills the root command's output flags the user didn't
+// set from the config file
+func applyOutputDefaults(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	outputChosen := flags.Changed("output") || jsonOutput || waybarOutput || formatFlag != "" || formatFile != ""
+	if !outputChosen {
+		outputFlag = cfg.Output.Format
+		formatFlag = cfg.Output.Template
+	}
+	if !flags.Changed("interval") && cfg.Output.Interval > 0 {
+		followInterval = cfg.Output.Interval
+	}
+	if !flags.Changed("bar") && cfg.Output.Bar != "" {
+		barName = cfg.Output.Bar
+	}
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package cmd
+
+import "syscall"
+
+// Process creation flags for a detached background process
+const (
+	detachedProcess       = 0x00000008
+	createNewProcessGroup = 0x00000200
+)
+
+// detachedProcAttr starts a background process without a console, so it
+// outlives the terminal or statusline that spawned it
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: detachedProcess | createNewProcessGroup}
+}
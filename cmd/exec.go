@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/launch"
+	"github.com/denysvitali/llm-usage/internal/quota"
+	"github.com/denysvitali/llm-usage/internal/usage"
+	"github.com/denysvitali/llm-usage/pkg/llmusage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	execProvider  string
+	execAccounts  []string
+	execWindow    string
+	execMax       float64
+	execForce     bool
+	execDryRun    bool
+	execSelection usage.Selection
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec [flags] -- command [args...]",
+	Short: "Run a command under the account with the most headroom",
+	Long: `Run a command under the least utilized account of a provider.
+
+Without --account every account is considered and the one with the lowest
+utilization wins. With --account (repeatable or comma-separated) the accounts
+form a fallback chain: the first one below --max is used.
+
+The command gets LLM_USAGE_PROVIDER and LLM_USAGE_ACCOUNT, plus the
+provider's credentials: CLAUDE_CONFIG_DIR for Claude accounts with a
+configDir (CLAUDE_CODE_OAUTH_TOKEN otherwise), KIMI_API_KEY and
+MOONSHOT_API_KEY for Kimi, ZAI_API_KEY for Z.AI, MINIMAX_API_KEY and
+MINIMAX_GROUP_ID for MiniMax accounts with an apiKey. Other providers are
+not supported.
+
+Every choice is appended to $XDG_STATE_HOME/llm-usage/exec.jsonl. When all
+accounts are at or above --max, exec refuses to run unless --force is given.`,
+	Example: `  llm-usage exec -- claude
+  llm-usage exec --account work,personal --max 80 -- claude -p "fix the tests"
+  llm-usage exec --provider kimi -- ./agent.sh`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runExec,
+}
+
+func init() {
+	addProviderFlag(execCmd, &execProvider, "claude")
+	execCmd.Flags().StringSliceVarP(&execAccounts, "account", "a", nil, "Accounts to try in order (default: all, least utilized first)")
+	execCmd.Flags().StringVarP(&execWindow, "window", "w", "", "Window label to compare, e.g. 5-Hour (default: all windows)")
+	execCmd.Flags().Float64Var(&execMax, "max", 90, "Refuse accounts at or above this utilization percentage")
+	addSelectionFlags(execCmd, &execSelection)
+	execCmd.Flags().BoolVar(&execForce, "force", false, "Run under the least utilized account even when all are above --max")
+	execCmd.Flags().BoolVar(&execDryRun, "dry-run", false, "Print the chosen account instead of running the command")
+	// Flags after the command name belong to the command
+	execCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(execCmd)
+}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to find %s: %w", args[0], err)
+	}
+	cmd.SilenceUsage = true
+	for i, account := range execAccounts {
+		execAccounts[i] = cfg.ResolveAccount(account)
+	}
+
+	credsMgr := credentialsManager()
+	opts := []llmusage.Option{llmusage.WithProviders(execProvider), llmusage.WithAllAccounts()}
+	opts = append(opts, withSelection(execSelection))
+	if credentialsFile != "" {
+		opts = append(opts, llmusage.WithCredentialsFile(credentialsFile))
+	}
+	client, err := newUsageClient(opts...)
+	if err != nil {
+		return err
+	}
+	stats, err := client.Fetch(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s usage: %w", execProvider, err)
+	}
+
+	choice, ok, err := quota.Pick(stats.Providers, quota.Condition{Window: execWindow, Below: execMax}, execAccounts)
+	if err != nil {
+		return err
+	}
+	account := fmt.Sprintf("%s/%s (%s at %.0f%%)", execProvider, choice.Account, choice.Status.Window, choice.Status.Utilization)
+	if !ok && !execForce {
+		return fmt.Errorf("every %s account is at or above %.0f%%; least utilized is %s. Use --force to run anyway", execProvider, execMax, account)
+	}
+
+	env, err := launch.Env(credsMgr, execProvider, choice.Account)
+	if err != nil {
+		return fmt.Errorf("failed to prepare environment for %s: %w", account, err)
+	}
+
+	if execDryRun {
+		fmt.Printf("Would run %s under %s\n", args[0], account)
+		return nil
+	}
+
+	if err := launch.NewLog().Record(launch.Choice{
+		Time:        time.Now(),
+		Provider:    execProvider,
+		Account:     choice.Account,
+		Window:      choice.Status.Window,
+		Utilization: choice.Status.Utilization,
+		Forced:      !ok,
+		Command:     args[0],
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	fmt.Fprintf(os.Stderr, "llm-usage: running under %s\n", account)
+
+	return execCommand(path, args, launch.Merge(os.Environ(), env))
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// execCommand replaces llm-usage with the command, so signals and the exit
+// status reach the caller unchanged
+func execCommand(path string, args, env []string) error {
+	if err := syscall.Exec(path, args, env); err != nil { //nolint:gosec
+		return fmt.Errorf("failed to exec %s: %w", path, err)
+	}
+	return nil
+}
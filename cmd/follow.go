@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/barstate"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// followRenderInterval is how often --follow re-renders reset countdowns without refetching
+const followRenderInterval = time.Minute
+
+// fetchFunc fetches usage stats
+type fetchFunc func(ctx context.Context) (*provider.UsageStats, error)
+
+// checkFollow validates that an output can be streamed by --follow
+func checkFollow(out outputSpec, interval time.Duration) error {
+	switch out.kind {
+	case outputWaybar, usage.StatusI3bar, usage.StatusPolybar:
+	default:
+		return fmt.Errorf("--follow supports the waybar, i3bar and polybar outputs")
+	}
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	return nil
+}
+
+// runFollow streams an update per line until interrupted. It refetches every
+// interval and on the refresh signal (SIGUSR1), and re-renders the last
+// stats every minute so reset countdowns stay current, and on the redraw
+// signal (SIGUSR2) sent by the waybar subcommands after changing the bar state.
+func runFollow(ctx context.Context, fetch fetchFunc, out outputSpec, interval time.Duration) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	refresh := make(chan os.Signal, 1)
+	if len(refreshSignals) > 0 {
+		signal.Notify(refresh, refreshSignals...)
+		defer signal.Stop(refresh)
+	}
+	redraw := make(chan os.Signal, 1)
+	if redrawSignal != nil {
+		signal.Notify(redraw, redrawSignal)
+		defer signal.Stop(redraw)
+	}
+
+	if out.bar != "" {
+		removePID, err := barstate.NewStore().WritePID(out.bar)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			defer removePID()
+		}
+	}
+
+	stream := &followStream{w: os.Stdout, kind: out.kind, bar: out.bar}
+	fetchTicker := time.NewTicker(interval)
+	defer fetchTicker.Stop()
+	renderTicker := time.NewTicker(followRenderInterval)
+	defer renderTicker.Stop()
+
+	var last *provider.UsageStats
+	update := func() error {
+		stats, err := fetch(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			last = nil
+			return stream.writeError(err.Error())
+		}
+		last = stats
+		return stream.write(stats)
+	}
+
+	if err := update(); err != nil {
+		return err
+	}
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-fetchTicker.C:
+			err = update()
+		case <-refresh:
+			fetchTicker.Reset(interval)
+			err = update()
+		case <-renderTicker.C:
+			if last != nil {
+				err = stream.write(last)
+			}
+		case <-redraw:
+			if last != nil {
+				err = stream.write(last)
+			}
+		}
+		// A write error means the bar went away
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// followStream writes successive updates in a streaming status bar format
+type followStream struct {
+	w       io.Writer
+	kind    string
+	bar     string
+	started bool
+}
+
+// write writes an update
+func (s *followStream) write(stats *provider.UsageStats) error {
+	summary := barSummary(s.bar, stats)
+	switch s.kind {
+	case usage.StatusI3bar:
+		return s.writeI3bar(usage.I3barBlockFor(summary))
+	case outputWaybar:
+		return usage.WriteWaybar(s.w, summary)
+	default:
+		return usage.WriteStatusBar(s.w, s.kind, summary)
+	}
+}
+
+// writeError writes a fetch error as an update
+func (s *followStream) writeError(msg string) error {
+	switch s.kind {
+	case usage.StatusI3bar:
+		return s.writeI3bar(usage.I3barErrorBlock())
+	case outputWaybar:
+		return usage.WriteWaybarError(s.w, msg)
+	default:
+		return usage.WriteStatusBarError(s.w, s.kind, msg)
+	}
+}
+
+// writeI3bar writes a status line of the i3bar protocol, preceded by the
+// protocol header and the opening of the endless array on the first call
+func (s *followStream) writeI3bar(block usage.I3barBlock) error {
+	line, err := json.Marshal([]usage.I3barBlock{block})
+	if err != nil {
+		return err
+	}
+
+	prefix := ","
+	if !s.started {
+		prefix = usage.I3barHeader
+		s.started = true
+	}
+	_, err = fmt.Fprintf(s.w, "%s%s\n", prefix, line)
+	return err
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/mqtt"
+	"github.com/denysvitali/llm-usage/internal/usage"
+	"github.com/denysvitali/llm-usage/pkg/llmusage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mqttBroker          string
+	mqttUsername        string
+	mqttPassword        string
+	mqttClientID        string
+	mqttTopicPrefix     string
+	mqttDiscoveryPrefix string
+	mqttInterval        time.Duration
+)
+
+var mqttCmd = &cobra.Command{
+	Use:   "mqtt",
+	Short: "Publish usage to an MQTT broker with Home Assistant discovery",
+	Long: `Publish the utilization, remaining quota and reset time of every provider
+account and window as retained MQTT topics, together with Home Assistant
+discovery messages so the sensors appear automatically.
+
+Topics are <prefix>/<provider>/<account>/<window>/{utilization,remaining,resets_at}.
+An account is marked offline on <prefix>/<provider>/<account>/availability while
+its provider returns errors, and <prefix>/status goes offline when llm-usage
+disconnects.`,
+	Example: `  llm-usage mqtt --broker tcp://homeassistant.local:1883 --username llm --interval 5m`,
+	RunE:    runMQTT,
+}
+
+func init() {
+	hostname, _ := os.Hostname()
+
+	mqttCmd.Flags().StringVar(&mqttBroker, "broker", "tcp://localhost:1883", "Broker URL (tcp://, mqtt://, ssl:// or mqtts://)")
+	mqttCmd.Flags().StringVar(&mqttUsername, "username", "", "Broker username")
+	mqttCmd.Flags().StringVar(&mqttPassword, "password", "", "Broker password, or @file (default: $LLM_USAGE_MQTT_PASSWORD)")
+	mqttCmd.Flags().StringVar(&mqttClientID, "client-id", "llm-usage-"+hostname, "MQTT client ID")
+	mqttCmd.Flags().StringVar(&mqttTopicPrefix, "topic-prefix", "llm-usage", "Prefix for state topics")
+	mqttCmd.Flags().StringVar(&mqttDiscoveryPrefix, "discovery-prefix", "homeassistant", "Home Assistant discovery prefix (empty to disable discovery)")
+	mqttCmd.Flags().DurationVar(&mqttInterval, "interval", 5*time.Minute, "How often to publish (0 publishes once and exits)")
+
+	rootCmd.AddCommand(mqttCmd)
+}
+
+func runMQTT(_ *cobra.Command, _ []string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	password, err := secretFlag(mqttPassword, "LLM_USAGE_MQTT_PASSWORD")
+	if err != nil {
+		return err
+	}
+
+	opts := []llmusage.Option{llmusage.WithAllAccounts()}
+	if credentialsFile != "" {
+		opts = append(opts, llmusage.WithCredentialsFile(credentialsFile))
+	}
+	client, err := newUsageClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	var conn *mqtt.Client
+	var publisher *mqtt.Publisher
+	defer func() {
+		if conn != nil {
+			// A clean disconnect doesn't trigger the will, so go offline explicitly
+			_ = conn.Publish(mqtt.Message{Topic: mqtt.StatusTopic(mqttTopicPrefix), Payload: []byte(mqtt.Offline), Retain: true})
+			_ = conn.Close()
+		}
+	}()
+
+	for {
+		if conn == nil || conn.Err() != nil {
+			if conn != nil {
+				log.Printf("MQTT connection lost, reconnecting: %v", conn.Err())
+				_ = conn.Close()
+			}
+			conn, err = mqtt.Dial(ctx, mqtt.Options{
+				Broker:   mqttBroker,
+				ClientID: mqttClientID,
+				Username: mqttUsername,
+				Password: password,
+				Will: &mqtt.Message{
+					Topic:   mqtt.StatusTopic(mqttTopicPrefix),
+					Payload: []byte(mqtt.Offline),
+					Retain:  true,
+				},
+			})
+			if err != nil {
+				conn = nil
+				if mqttInterval == 0 {
+					return err
+				}
+				log.Printf("Failed to connect to MQTT broker: %v", err)
+			} else {
+				publisher = mqtt.NewPublisher(conn, mqttTopicPrefix, mqttDiscoveryPrefix)
+				publisher.NameOf = usage.ProviderName
+			}
+		}
+
+		if conn != nil {
+			stats, err := client.Fetch(ctx)
+			switch {
+			case ctx.Err() != nil:
+				return nil
+			case err != nil && mqttInterval == 0:
+				return err
+			case err != nil:
+				log.Printf("Failed to fetch usage: %v", err)
+			default:
+				if err := publisher.Publish(stats); err != nil {
+					if mqttInterval == 0 {
+						return fmt.Errorf("failed to publish usage: %w", err)
+					}
+					log.Printf("Failed to publish usage: %v", err)
+				}
+			}
+		}
+
+		if mqttInterval == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(mqttInterval):
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/denysvitali/llm-usage/internal/notify"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// newNotifier builds a notifier from --webhook and --webhook-template flags.
+// It returns nil when no webhooks are configured.
+func newNotifier(specs []string, templatePath string) (*notify.Notifier, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	webhooks := make([]*notify.Webhook, 0, len(specs))
+	for _, spec := range specs {
+		w, err := notify.ParseWebhook(spec)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	if templatePath != "" {
+		tmpl, err := notify.LoadTemplate(templatePath)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range webhooks {
+			if w.Kind == notify.KindGeneric {
+				w.Template = tmpl
+			}
+		}
+	}
+
+	return notify.New(webhooks, notify.WithProviderNames(usage.ProviderName)), nil
+}
+
+// registerNotifier sends webhook notifications for every usage fetch
+func registerNotifier(ctx context.Context, n *notify.Notifier, async bool) {
+	usage.AddFetchHook(func(stats *provider.UsageStats) {
+		if async {
+			go n.Process(ctx, stats)
+			return
+		}
+		n.Process(ctx, stats)
+	})
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/barstate"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// Output formats accepted by --output
+const (
+	outputPretty       = "pretty"
+	outputJSON         = "json"
+	outputWaybar       = "waybar"
+	outputPromTextfile = "prom-textfile"
+	outputInflux       = "influx"
+	outputTemplate     = "template" // --format and --format-file
+)
+
+// outputSpec is a parsed --output value such as "prom-textfile=/path"
+type outputSpec struct {
+	kind string
+	arg  string
+	tmpl *template.Template
+	bar  string // status bar instance whose display state is used
+}
+
+// resolveOutput combines --output with the --json and --waybar shorthands
+func resolveOutput(output string, json, waybar bool) (outputSpec, error) {
+	if output != "" && (json || waybar) {
+		return outputSpec{}, fmt.Errorf("--output cannot be combined with --json or --waybar")
+	}
+
+	switch {
+	case waybar:
+		return outputSpec{kind: outputWaybar}, nil
+	case json:
+		return outputSpec{kind: outputJSON}, nil
+	case output == "":
+		return outputSpec{kind: outputPretty}, nil
+	}
+
+	kind, arg, _ := strings.Cut(output, "=")
+	switch kind {
+	case outputPretty, outputJSON, outputWaybar:
+		if arg != "" {
+			return outputSpec{}, fmt.Errorf("output %q takes no argument", kind)
+		}
+	case outputPromTextfile:
+		if arg == "" {
+			return outputSpec{}, fmt.Errorf("output prom-textfile requires a path, e.g. prom-textfile=/var/lib/node_exporter/llm_usage.prom")
+		}
+	case outputInflux:
+	default:
+		format, ok := usage.StatusBarFormat(kind)
+		if !ok {
+			return outputSpec{}, fmt.Errorf("unknown output %q (supported: pretty, json, waybar, i3bar, i3blocks, polybar, tmux, xbar, conky, prom-textfile=PATH, influx[=URL])", kind)
+		}
+		if arg != "" {
+			return outputSpec{}, fmt.Errorf("output %q takes no argument", kind)
+		}
+		kind = format
+	}
+
+	return outputSpec{kind: kind, arg: arg}, nil
+}
+
+// resolveFormat parses --format or --format-file, which replace the --output format
+func resolveFormat(out outputSpec, format, formatFile string, outputSet bool) (outputSpec, error) {
+	if format == "" && formatFile == "" {
+		return out, nil
+	}
+	if format != "" && formatFile != "" {
+		return outputSpec{}, fmt.Errorf("--format cannot be combined with --format-file")
+	}
+	if outputSet {
+		return outputSpec{}, fmt.Errorf("--format cannot be combined with --output, --json or --waybar")
+	}
+
+	var (
+		tmpl *template.Template
+		err  error
+	)
+	if formatFile != "" {
+		tmpl, err = usage.ParseFormatFile(formatFile)
+	} else {
+		tmpl, err = usage.ParseFormat(format)
+	}
+	if err != nil {
+		return outputSpec{}, err
+	}
+	return outputSpec{kind: outputTemplate, tmpl: tmpl}, nil
+}
+
+// writeOutput renders usage stats in the requested format
+func writeOutput(ctx context.Context, out outputSpec, stats *provider.UsageStats) error {
+	switch out.kind {
+	case outputWaybar:
+		return usage.WriteWaybar(os.Stdout, barSummary(out.bar, stats))
+	case outputJSON:
+		usage.OutputJSON(stats)
+	case outputPromTextfile:
+		return usage.WritePromTextfile(out.arg, stats)
+	case outputInflux:
+		if out.arg != "" {
+			return usage.PostInflux(ctx, out.arg, stats)
+		}
+		return usage.WriteInflux(os.Stdout, stats, time.Now())
+	case outputTemplate:
+		return usage.OutputTemplate(os.Stdout, out.tmpl, stats)
+	default:
+		if _, ok := usage.StatusBarFormat(out.kind); ok {
+			return usage.WriteStatusBar(os.Stdout, out.kind, barSummary(out.bar, stats))
+		}
+		usage.OutputPretty(stats)
+	}
+	return nil
+}
+
+// isStatusBar reports whether an output is a status bar format
+func isStatusBar(kind string) bool {
+	_, ok := usage.StatusBarFormat(kind)
+	return ok || kind == outputWaybar
+}
+
+// barSummary summarizes usage stats with a bar's persisted display state
+func barSummary(bar string, stats *provider.UsageStats) usage.StatusSummary {
+	var view usage.BarView
+	if bar != "" {
+		state, err := barstate.NewStore().Load(bar)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		view = usage.BarView{Window: state.Window, Account: state.Account, Detail: state.Detail}
+	}
+	return usage.Summarize(stats, view)
+}
+
+// writeOutputError renders a fetch error for status bar outputs, which must
+// always print something the bar can display. It reports whether it did.
+func writeOutputError(out outputSpec, msg string) bool {
+	if out.kind == outputWaybar {
+		usage.OutputWaybarError(msg)
+		return true
+	}
+	if _, ok := usage.StatusBarFormat(out.kind); ok {
+		if err := usage.WriteStatusBarError(os.Stdout, out.kind, msg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		}
+		return true
+	}
+	return false
+}
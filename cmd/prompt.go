@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/prompt"
+	"github.com/denysvitali/llm-usage/internal/snapshot"
+	"github.com/denysvitali/llm-usage/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	promptProvider   string
+	promptAccount    string
+	promptMaxAge     time.Duration
+	promptShell      string
+	promptFormat     string
+	promptFormatFile string
+	promptSelection  usage.Selection
+)
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Print usage for a shell prompt from the cached snapshot",
+	Long: `Print compact usage (e.g. "C:42% K:10%") for shell prompts.
+
+It never touches the network: usage comes from the snapshot cached by every
+llm-usage run, so it returns in a few milliseconds. When the snapshot is older
+than --max-age, a detached background refresh updates it for the next prompt.
+
+Run 'llm-usage prompt init <shell>' for a bash, zsh, fish or starship snippet.`,
+	Args: cobra.NoArgs,
+	RunE: runPrompt,
+}
+
+var promptInitCmd = &cobra.Command{
+	Use:       "init <shell>",
+	Short:     "Print the prompt snippet for bash, zsh, fish or starship",
+	Example:   `  llm-usage prompt init zsh >> ~/.zshrc`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: prompt.Shells,
+	RunE:      runPromptInit,
+}
+
+func init() {
+	addProviderFlag(promptCmd, &promptProvider, "all")
+	addAccountFlag(promptCmd, &promptAccount, "all accounts")
+	addSelectionFlags(promptCmd, &promptSelection)
+	promptCmd.Flags().DurationVar(&promptMaxAge, "max-age", 10*time.Minute, "Refresh the snapshot in the background when older than this")
+	promptCmd.Flags().StringVar(&promptShell, "shell", "", "Color the output for this shell's prompt: bash, zsh or fish (default: no colors)")
+	promptCmd.Flags().StringVar(&promptFormat, "format", "", "Render with a Go template instead (see llm-usage --help)")
+	promptCmd.Flags().StringVar(&promptFormatFile, "format-file", "", "Render with a Go template read from a file")
+
+	promptCmd.AddCommand(promptInitCmd)
+	rootCmd.AddCommand(promptCmd)
+}
+
+func runPrompt(cmd *cobra.Command, _ []string) error {
+	applyConfigDefaults(cmd, &promptProvider, &promptAccount, nil)
+
+	var tmpl *template.Template
+	var err error
+	switch {
+	case promptFormat != "" && promptFormatFile != "":
+		return fmt.Errorf("--format cannot be combined with --format-file")
+	case promptFormat != "":
+		tmpl, err = usage.ParseFormat(promptFormat)
+	case promptFormatFile != "":
+		tmpl, err = usage.ParseFormatFile(promptFormatFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	filter := prompt.Filter{Account: promptAccount}
+	if promptProvider != "all" && promptProvider != "" {
+		filter.Providers = strings.Split(promptProvider, ",")
+	}
+
+	snap, err := snapshot.NewStore().Load()
+	if err != nil {
+		snap = &snapshot.Snapshot{}
+	}
+
+	// Refresh when any matching account is stale, or none was fetched yet
+	stale := true
+	now := time.Now()
+	for _, e := range snap.Entries {
+		if filter.Match(e.Usage) {
+			stale = e.Age(now) > promptMaxAge
+			if stale {
+				break
+			}
+		}
+	}
+	if stale {
+		var args []string
+		if len(filter.Providers) > 0 {
+			args = append(args, "--provider", strings.Join(filter.Providers, ","))
+		}
+		_ = spawnRefresh(args...)
+	}
+
+	stats := cfg.Apply(promptSelection.Filter(filter.Apply(snap.Stats())))
+	if tmpl != nil {
+		return usage.OutputTemplate(os.Stdout, tmpl, stats)
+	}
+	if text := prompt.Render(stats, promptShell); text != "" {
+		fmt.Println(text)
+	}
+	return nil
+}
+
+func runPromptInit(_ *cobra.Command, args []string) error {
+	// Prefer the name on $PATH so the snippet survives upgrades
+	exe := "llm-usage"
+	if _, err := exec.LookPath(exe); err != nil {
+		if path, err := os.Executable(); err == nil {
+			exe = path
+		}
+	}
+
+	snippet, err := prompt.Snippet(args[0], exe)
+	if err != nil {
+		return err
+	}
+	fmt.Print(snippet)
+	return nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/snapshot"
+	"github.com/denysvitali/llm-usage/internal/usage"
+	"github.com/denysvitali/llm-usage/pkg/llmusage"
+	"github.com/spf13/cobra"
+)
+
+// refreshLockTTL bounds how long a background refresh may hold the snapshot lock
+const refreshLockTTL = 2 * time.Minute
+
+var refreshProvider string
+
+var refreshCmd = &cobra.Command{
+	Use:    "refresh",
+	Short:  "Fetch usage of all accounts and update the cached snapshot",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runRefresh,
+}
+
+func init() {
+	addProviderFlag(refreshCmd, &refreshProvider, "all")
+	rootCmd.AddCommand(refreshCmd)
+
+	// Every local fetch updates the snapshot used by statusline and prompt
+	usage.AddFetchHook(saveSnapshot)
+}
+
+// saveSnapshot records fetched usage in the cached snapshot
+func saveSnapshot(stats *provider.UsageStats) {
+	if err := snapshot.NewStore().Merge(stats, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+func runRefresh(cmd *cobra.Command, _ []string) error {
+	unlock, ok := snapshot.NewStore().TryLock(refreshLockTTL)
+	if !ok {
+		// Another refresh is already running
+		return nil
+	}
+	defer unlock()
+
+	opts := []llmusage.Option{llmusage.WithAllAccounts()}
+	if credentialsFile != "" {
+		opts = append(opts, llmusage.WithCredentialsFile(credentialsFile))
+	}
+	if refreshProvider != "all" && refreshProvider != "" {
+		opts = append(opts, llmusage.WithProviders(strings.Split(refreshProvider, ",")...))
+	}
+
+	client, err := newUsageClient(opts...)
+	if err != nil {
+		return err
+	}
+	// The fetch hook writes the snapshot
+	_, err = client.Fetch(cmd.Context())
+	return err
+}
+
+// spawnRefresh starts a detached background refresh, unless one is already
+// running. It uses the same --credentials-file.
+func spawnRefresh(args ...string) error {
+	if snapshot.NewStore().Refreshing(refreshLockTTL) {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find executable: %w", err)
+	}
+	if credentialsFile != "" {
+		args = append(args, "--credentials-file", credentialsFile)
+	}
+	c := exec.Command(exe, append([]string{"refresh"}, args...)...) //nolint:gosec
+	c.SysProcAttr = detachedProcAttr()
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("failed to start background refresh: %w", err)
+	}
+	return c.Process.Release()
+}
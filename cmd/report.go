@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/report"
+	"github.com/denysvitali/llm-usage/pkg/llmusage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportTo       string
+	reportReporter string
+	reportKey      string
+	reportProvider string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Push a signed usage report to a llm-usage server",
+	Long: `Fetch usage from all local accounts and push it to a llm-usage server
+started with --reporter-key. The report is signed with this reporter's key,
+so the server never needs provider credentials.`,
+	Example: `  llm-usage report --to https://hub.example.com --reporter alice --key @~/.config/llm-usage/report.key`,
+	RunE:    runReport,
+}
+
+func init() {
+	hostname, _ := os.Hostname()
+
+	reportCmd.Flags().StringVar(&reportTo, "to", "", "Server URL to push the report to (required)")
+	reportCmd.Flags().StringVar(&reportReporter, "reporter", hostname, "Reporter name known to the server")
+	reportCmd.Flags().StringVar(&reportKey, "key", "", "Reporter key used to sign the report, or @file (default: $LLM_USAGE_REPORT_KEY)")
+	addProviderFlag(reportCmd, &reportProvider, "all")
+	_ = reportCmd.MarkFlagRequired("to")
+
+	rootCmd.AddCommand(reportCmd)
+}
+
+func runReport(cmd *cobra.Command, _ []string) error {
+	key, err := secretFlag(reportKey, "LLM_USAGE_REPORT_KEY")
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return fmt.Errorf("a reporter key is required (--key or $LLM_USAGE_REPORT_KEY)")
+	}
+	if reportReporter == "" {
+		return fmt.Errorf("a reporter name is required (--reporter)")
+	}
+
+	opts := []llmusage.Option{llmusage.WithAllAccounts()}
+	if credentialsFile != "" {
+		opts = append(opts, llmusage.WithCredentialsFile(credentialsFile))
+	}
+	if reportProvider != "all" && reportProvider != "" {
+		opts = append(opts, llmusage.WithProviders(strings.Split(reportProvider, ",")...))
+	}
+
+	client, err := newUsageClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	stats, err := client.Fetch(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if err := report.Push(cmd.Context(), reportTo, reportReporter, key, stats); err != nil {
+		return fmt.Errorf("failed to push report: %w", err)
+	}
+
+	fmt.Printf("Reported %d accounts to %s\n", len(stats.Providers), reportTo)
+	return nil
+}
@@ -2,12 +2,19 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/denysvitali/llm-usage/internal/barstate"
 	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/provider"
 	"github.com/denysvitali/llm-usage/internal/usage"
 	"github.com/denysvitali/llm-usage/internal/version"
+	"github.com/denysvitali/llm-usage/pkg/llmusage"
 	"github.com/spf13/cobra"
 )
 
@@ -15,9 +22,21 @@ var (
 	providerFlag    string
 	accountFlag     string
 	allAccountsFlag bool
+	outputFlag      string
 	jsonOutput      bool
 	waybarOutput    bool
+	formatFlag      string
+	formatFile      string
 	credentialsFile string
+	remoteURL       string
+	remoteToken     string
+	webhooks        []string
+	webhookTemplate string
+	otlpEnabled     bool
+	followFlag      bool
+	followInterval  time.Duration
+	barName         string
+	selection       usage.Selection
 )
 
 var rootCmd = &cobra.Command{
@@ -26,53 +45,203 @@ var rootCmd = &cobra.Command{
 	Long:    `llm-usage displays API usage statistics across multiple LLM providers including Claude, Kimi, Z.AI, and MiniMax.`,
 	Version: version.Version,
 	RunE:    runUsage,
+
+	Annotations: map[string]string{remoteAnnotation: "true"},
+}
+
+// exitError makes Execute exit with a specific status code
+type exitError struct {
+	code int
+	err  error
 }
 
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
 // Execute runs the root command
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
 
 func init() {
-	rootCmd.Flags().StringVarP(&providerFlag, "provider", "p", "all", "Provider: claude, kimi, zai, minimax, or all")
-	rootCmd.Flags().StringVarP(&accountFlag, "account", "a", "", "Account to use")
+	rootCmd.PersistentFlags().StringVar(&remoteURL, "remote", "", "Fetch usage from a llm-usage server (e.g. http://host:8080) instead of querying providers")
+	rootCmd.PersistentFlags().StringVar(&remoteToken, "remote-token", "", "Bearer token for --remote, or @file (default: $LLM_USAGE_REMOTE_TOKEN)")
+	rootCmd.PersistentFlags().StringVar(&credentialsFile, "credentials-file", "", "Path to a combined credentials file (values may use $VAR or ${VAR} env references)")
+	addProviderFlag(rootCmd, &providerFlag, "all")
+	addAccountFlag(rootCmd, &accountFlag, "the default account")
 	rootCmd.Flags().BoolVar(&allAccountsFlag, "all-accounts", false, "Aggregate usage across all accounts")
-	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
-	rootCmd.Flags().BoolVar(&waybarOutput, "waybar", false, "Output in waybar JSON format")
-	rootCmd.Flags().StringVar(&credentialsFile, "credentials-file", "", "Path to a combined credentials file (values may use $VAR or ${VAR} env references)")
+	rootCmd.Flags().StringVarP(&outputFlag, "output", "o", "", "Output format: pretty, json, waybar, i3bar, i3blocks, polybar, tmux, xbar (swiftbar, argos), conky, prom-textfile=PATH, influx or influx=URL")
+	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format (same as --output json)")
+	rootCmd.Flags().BoolVar(&waybarOutput, "waybar", false, "Output in waybar JSON format (same as --output waybar)")
+	rootCmd.Flags().StringVar(&formatFlag, "format", "", "Render output with a Go template (e.g. '{{range .Providers}}{{shortName .Provider}} {{percent (maxUtil .)}} {{end}}')")
+	rootCmd.Flags().StringVar(&formatFile, "format-file", "", "Render output with a Go template read from a file")
+	rootCmd.Flags().StringArrayVar(&webhooks, "webhook", nil, "Send threshold and reset notifications to a webhook, as kind=url (slack, discord, ntfy, gotify, generic; repeatable)")
+	rootCmd.Flags().StringVar(&webhookTemplate, "webhook-template", "", "Go text/template file for generic webhook payloads")
+	addSelectionFlags(rootCmd, &selection)
+	rootCmd.Flags().BoolVarP(&followFlag, "follow", "f", false, "Keep running and print an update per line (waybar, i3bar and polybar outputs; SIGUSR1 refreshes)")
+	rootCmd.Flags().DurationVar(&followInterval, "interval", 5*time.Minute, "Refresh interval for --follow")
+	rootCmd.Flags().StringVar(&barName, "bar", barstate.DefaultBar, "Status bar instance whose display state to use (see 'llm-usage waybar')")
+	rootCmd.Flags().BoolVar(&otlpEnabled, "otlp", false, "Export metrics via OTLP (also enabled by OTEL_EXPORTER_OTLP_ENDPOINT; configured by OTEL_EXPORTER_OTLP_* env vars)")
 }
 
-func runUsage(_ *cobra.Command, _ []string) error {
-	var credsMgr *credentials.Manager
-	if credentialsFile != "" {
-		credsMgr = credentials.NewManagerFromFile(credentialsFile)
-	} else {
-		credsMgr = credentials.NewManager()
+func runUsage(cmd *cobra.Command, _ []string) error {
+	applyConfigDefaults(cmd, &providerFlag, &accountFlag, &allAccountsFlag)
+	applyOutputDefaults(cmd)
+
+	out, err := resolveOutput(outputFlag, jsonOutput, waybarOutput)
+	if err != nil {
+		return err
+	}
+	out, err = resolveFormat(out, formatFlag, formatFile, cmd.Flags().Changed("output") || jsonOutput || waybarOutput)
+	if err != nil {
+		return err
+	}
+	if isStatusBar(out.kind) {
+		out.bar = barName
+	}
+	if followFlag {
+		if err := checkFollow(out, followInterval); err != nil {
+			return err
+		}
+	}
+
+	if remoteToken, err = secretFlag(remoteToken, "LLM_USAGE_REMOTE_TOKEN"); err != nil {
+		return err
+	}
+
+	opts := []llmusage.Option{llmusage.WithAccount(accountFlag)}
+	switch {
+	case remoteURL != "":
+		opts = append(opts, llmusage.WithRemote(remoteURL, remoteToken))
+	case credentialsFile != "":
+		opts = append(opts, llmusage.WithCredentialsFile(credentialsFile))
+	}
+	if providerFlag != "all" && providerFlag != "" {
+		opts = append(opts, llmusage.WithProviders(strings.Split(providerFlag, ",")...))
+	}
+	if allAccountsFlag {
+		opts = append(opts, llmusage.WithAllAccounts())
+	}
+	opts = append(opts, withSelection(selection))
+	if followFlag {
+		opts = append(opts, llmusage.WithPersistentProviders())
+	}
+
+	client, err := newUsageClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	notifier, err := newNotifier(webhooks, webhookTemplate)
+	if err != nil {
+		return err
+	}
+	if notifier != nil && remoteURL == "" {
+		registerNotifier(cmd.Context(), notifier, false)
+	}
+
+	exp, err := setupTelemetry(cmd.Context(), otlpEnabled)
+	if err != nil {
+		return err
+	}
+	defer shutdownTelemetry(exp)
+
+	// Fetch usage from all providers concurrently
+	fetch := func(ctx context.Context) (*provider.UsageStats, error) {
+		stats, err := client.Fetch(ctx)
+		if errors.Is(err, llmusage.ErrNoProviders) {
+			return nil, fmt.Errorf("%w. Run 'llm-usage setup' to configure providers", err)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Remote results don't pass through the local fetch hooks
+		if remoteURL != "" {
+			if notifier != nil {
+				notifier.Process(ctx, stats)
+			}
+			recordRemoteUsage(exp, stats)
+			saveSnapshot(stats)
+		}
+		return cfg.Apply(stats), nil
+	}
+
+	if followFlag {
+		return runFollow(cmd.Context(), fetch, out, followInterval)
 	}
 
-	// Determine which providers to query
-	providers := usage.GetProviders(providerFlag, accountFlag, allAccountsFlag, credsMgr)
-	if len(providers) == 0 {
-		if waybarOutput {
-			usage.OutputWaybarError("No providers configured")
+	stats, err := fetch(cmd.Context())
+	if errors.Is(err, llmusage.ErrNoProviders) {
+		if writeOutputError(out, "No providers configured") {
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		if writeOutputError(out, err.Error()) {
 			return nil
 		}
-		return fmt.Errorf("no providers configured. Run 'llm-usage setup' to configure providers")
+		return err
 	}
 
-	// Fetch usage from all providers concurrently
-	stats := usage.FetchAllUsage(providers)
+	return writeOutput(cmd.Context(), out, stats)
+}
 
-	switch {
-	case waybarOutput:
-		usage.OutputWaybar(stats)
-	case jsonOutput:
-		usage.OutputJSON(stats)
-	default:
-		usage.OutputPretty(stats)
+// addProviderFlag registers --provider. Commands querying several providers
+// default to "all", the others to a single provider or "".
+func addProviderFlag(cmd *cobra.Command, p *string, value string) {
+	usage := "Provider: claude, kimi, zai, minimax, a custom provider ID, or all"
+	if value != "all" {
+		usage = "Provider: claude, kimi, zai, minimax or a custom provider ID"
 	}
+	cmd.Flags().StringVarP(p, "provider", "p", value, usage)
+}
+
+// addAccountFlag registers --account, describing what is used without it
+func addAccountFlag(cmd *cobra.Command, account *string, fallback string) {
+	cmd.Flags().StringVarP(account, "account", "a", "", "Only use this account (default: "+fallback+")")
+}
+
+// remoteAnnotation marks the commands that can fetch usage from --remote
+const remoteAnnotation = "remote"
 
+// checkRemote rejects --remote on commands that always query providers directly
+func checkRemote(cmd *cobra.Command) error {
+	if cmd.Flags().Changed("remote") && cmd.Annotations[remoteAnnotation] == "" {
+		return fmt.Errorf("%s does not support --remote", cmd.CommandPath())
+	}
 	return nil
 }
+
+// secretFlag resolves a secret flag, falling back to the environment variable
+// env. Secrets are not flag defaults, so --help never prints them.
+// "@path" reads the secret from a file.
+func secretFlag(value, env string) (string, error) {
+	if value == "" {
+		value = os.Getenv(env)
+	}
+	return credentials.ReadSecret(value)
+}
+
+// credentialsManager returns the credentials manager for --credentials-file
+func credentialsManager() *credentials.Manager {
+	if credentialsFile != "" {
+		return credentials.NewManagerFromFile(credentialsFile)
+	}
+	return credentials.NewManager()
+}
+
+// addSelectionFlags registers the --tag, --group and --exclude-tag account selectors
+func addSelectionFlags(cmd *cobra.Command, sel *usage.Selection) {
+	cmd.Flags().StringSliceVar(&sel.Tags, "tag", nil, "Only accounts with any of these tags (repeatable or comma-separated)")
+	cmd.Flags().StringSliceVar(&sel.Groups, "group", nil, "Only accounts in any of these groups")
+	cmd.Flags().StringSliceVar(&sel.ExcludeTags, "exclude-tag", nil, "Skip accounts with any of these tags")
+}
@@ -6,7 +6,9 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/denysvitali/llm-usage/internal/report"
 	"github.com/denysvitali/llm-usage/internal/serve"
 	"github.com/spf13/cobra"
 )
@@ -15,6 +17,19 @@ var (
 	serveHost   string
 	servePort   int
 	serveWebDir string
+	serveToken  string
+
+	servePeers        []string
+	servePeerTokens   []string
+	servePeerInterval time.Duration
+
+	serveReporterKeys []string
+
+	serveWebhooks        []string
+	serveWebhookTemplate string
+	servePollInterval    time.Duration
+
+	serveOTLP bool
 )
 
 var serveCmd = &cobra.Command{
@@ -28,6 +43,15 @@ func init() {
 	serveCmd.Flags().StringVar(&serveHost, "host", "localhost", "Host to bind to")
 	serveCmd.Flags().IntVar(&servePort, "port", 8080, "Port to listen on")
 	serveCmd.Flags().StringVar(&serveWebDir, "web-dir", "", "Path to web directory (default: auto-detect)")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Require this bearer token on API requests, or @file (default: $LLM_USAGE_TOKEN)")
+	serveCmd.Flags().StringArrayVar(&servePeers, "peer", nil, "Peer server to aggregate in hub mode, as name=url (repeatable)")
+	serveCmd.Flags().StringArrayVar(&servePeerTokens, "peer-token", nil, "Bearer token for a peer, as name=token or name=@file (repeatable; default: $LLM_USAGE_PEER_TOKEN_<NAME>)")
+	serveCmd.Flags().DurationVar(&servePeerInterval, "peer-interval", time.Minute, "How often to poll peers in hub mode")
+	serveCmd.Flags().StringArrayVar(&serveReporterKeys, "reporter-key", nil, "Accept pushed reports from a reporter, as name=key, name=@file, or name to read $LLM_USAGE_REPORTER_KEY_<NAME> (repeatable)")
+	serveCmd.Flags().StringArrayVar(&serveWebhooks, "webhook", nil, "Send threshold and reset notifications to a webhook, as kind=url (slack, discord, ntfy, gotify, generic; repeatable)")
+	serveCmd.Flags().StringVar(&serveWebhookTemplate, "webhook-template", "", "Go text/template file for generic webhook payloads")
+	serveCmd.Flags().DurationVar(&servePollInterval, "poll-interval", 5*time.Minute, "How often to fetch usage for webhook notifications and OTLP metrics")
+	serveCmd.Flags().BoolVar(&serveOTLP, "otlp", false, "Export metrics via OTLP (also enabled by OTEL_EXPORTER_OTLP_ENDPOINT; configured by OTEL_EXPORTER_OTLP_* env vars)")
 
 	rootCmd.AddCommand(serveCmd)
 }
@@ -44,10 +68,48 @@ func runServe(_ *cobra.Command, _ []string) error {
 		cancel()
 	}()
 
+	token, err := secretFlag(serveToken, "LLM_USAGE_TOKEN")
+	if err != nil {
+		return err
+	}
+
+	peers, err := serve.ParsePeers(servePeers, servePeerTokens)
+	if err != nil {
+		return err
+	}
+
+	reporterKeys, err := report.ParseKeys(serveReporterKeys)
+	if err != nil {
+		return err
+	}
+
+	notifier, err := newNotifier(serveWebhooks, serveWebhookTemplate)
+	if err != nil {
+		return err
+	}
+	if notifier != nil {
+		registerNotifier(ctx, notifier, true)
+	}
+
+	exp, err := setupTelemetry(ctx, serveOTLP)
+	if err != nil {
+		return err
+	}
+	defer shutdownTelemetry(exp)
+
 	cfg := &serve.Config{
 		Host:   serveHost,
 		Port:   servePort,
 		WebDir: serveWebDir,
+		Token:  token,
+
+		CredentialsFile: credentialsFile,
+
+		Peers:        peers,
+		PeerInterval: servePeerInterval,
+		ReporterKeys: reporterKeys,
+
+		PollInterval: pollInterval(notifier != nil || exp != nil),
 	}
 
 	// Auto-detect web directory if not specified
@@ -62,3 +124,11 @@ func runServe(_ *cobra.Command, _ []string) error {
 
 	return nil
 }
+
+// pollInterval returns how often the server polls usage for fetch hooks, or 0 to disable
+func pollInterval(needed bool) time.Duration {
+	if !needed {
+		return 0
+	}
+	return servePollInterval
+}
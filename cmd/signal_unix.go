@@ -0,0 +1,14 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// refreshSignals trigger an immediate refetch in --follow mode
+var refreshSignals = []os.Signal{syscall.SIGUSR1}
+
+// redrawSignal makes a --follow instance re-render with the current bar state
+var redrawSignal os.Signal = syscall.SIGUSR2
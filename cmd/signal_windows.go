@@ -0,0 +1,11 @@
+//go:build windows
+
+package cmd
+
+import "os"
+
+// refreshSignals trigger an immediate refetch in --follow mode; Windows has no SIGUSR1
+var refreshSignals []os.Signal
+
+// redrawSignal makes a --follow instance re-render; Windows has no SIGUSR2
+var redrawSignal os.Signal
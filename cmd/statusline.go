@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/snapshot"
+	"github.com/denysvitali/llm-usage/internal/statusline"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statuslineAccount string
+	statuslineMaxAge  time.Duration
+	statuslineNoColor bool
+)
+
+var statuslineCmd = &cobra.Command{
+	Use:   "statusline",
+	Short: "Print a Claude Code statusline with the session's account usage",
+	Long: `Print a one-line usage summary for Claude Code's statusLine setting.
+
+It reads the session JSON Claude Code passes on stdin, detects which configured
+Claude account the session uses ($CLAUDE_CONFIG_DIR, matched against the
+account's "configDir" or its OAuth tokens), and shows 5-hour and 7-day
+utilization, the 5-hour reset countdown and the session's tokens.
+
+Usage comes from the cached snapshot written by every llm-usage run, so the
+statusline never waits for the network. When the snapshot is older than
+--max-age, a background refresh updates it for the next render.`,
+	Example: `  # ~/.claude/settings.json
+  "statusLine": {"type": "command", "command": "llm-usage statusline"}`,
+	Args: cobra.NoArgs,
+	RunE: runStatusline,
+}
+
+func init() {
+	addAccountFlag(statuslineCmd, &statuslineAccount, "detected from the session")
+	statuslineCmd.Flags().DurationVar(&statuslineMaxAge, "max-age", 5*time.Minute, "Refresh the snapshot in the background when older than this")
+	statuslineCmd.Flags().BoolVar(&statuslineNoColor, "no-color", os.Getenv("NO_COLOR") != "", "Disable colors (default: $NO_COLOR)")
+	rootCmd.AddCommand(statuslineCmd)
+}
+
+func runStatusline(_ *cobra.Command, _ []string) error {
+	// A broken statusline is worse than a partial one, so input errors are not fatal
+	in, err := statusline.ParseInput(os.Stdin)
+	if err != nil {
+		in = &statusline.Input{}
+	}
+
+	credsMgr := credentialsManager()
+	account := cfg.ResolveAccount(statuslineAccount)
+	if account == "" {
+		creds, _ := credsMgr.LoadClaude()
+		account = statusline.DetectAccount(creds, statusline.ConfigDir())
+	}
+
+	now := time.Now()
+	snap, err := snapshot.NewStore().Load()
+	if err != nil {
+		snap = &snapshot.Snapshot{}
+	}
+	// An empty account would match any Claude entry, i.e. someone else's usage
+	var entry *snapshot.Entry
+	if account != "" {
+		entry = snap.Find("claude", account)
+	}
+	if account != "" && (entry == nil || entry.Age(now) > statuslineMaxAge) {
+		_ = spawnRefresh("--provider", "claude")
+	}
+
+	line := statusline.Line{
+		Model:      in.Model.DisplayName,
+		Account:    account,
+		Undetected: account == "",
+		Color:      !statuslineNoColor,
+		Now:        now,
+	}
+	if entry != nil {
+		line.Usage = &entry.Usage
+	}
+	line.Tokens, _ = statusline.CachedSessionTokens(in.TranscriptPath, statusline.TokenCachePath(in.SessionID))
+
+	fmt.Println(statusline.Render(line))
+	return nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/telemetry"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// setupTelemetry starts OTLP metrics export when --otlp is set or an OTLP
+// endpoint is configured in the environment. It returns nil when disabled.
+func setupTelemetry(ctx context.Context, enabled bool) (*telemetry.Exporter, error) {
+	if !enabled && !telemetry.Enabled() {
+		return nil, nil
+	}
+
+	exp, err := telemetry.New(ctx, telemetry.Protocol())
+	if err != nil {
+		return nil, err
+	}
+
+	usage.AddFetchHook(exp.RecordUsage)
+	usage.AddProviderHook(func(f usage.ProviderFetch) {
+		exp.RecordFetch(ctx, f.ProviderID, f.Account, f.Duration, f.Err)
+	})
+
+	return exp, nil
+}
+
+// shutdownTelemetry flushes pending metrics, reporting failures on stderr
+// so they don't disturb machine-readable output
+func shutdownTelemetry(exp *telemetry.Exporter) {
+	if exp == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := exp.Shutdown(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// recordRemoteUsage feeds usage fetched from a remote server, which bypasses
+// the local fetch hooks, to the exporter
+func recordRemoteUsage(exp *telemetry.Exporter, stats *provider.UsageStats) {
+	if exp != nil {
+		exp.RecordUsage(stats)
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/denysvitali/llm-usage/internal/top"
+	"github.com/denysvitali/llm-usage/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	topInterval  time.Duration
+	topProvider  string
+	topSelection usage.Selection
+)
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Live full-screen usage dashboard",
+	Long: `Show the usage of all configured accounts in a full-screen dashboard that
+refreshes periodically, with live reset countdowns.
+
+Keys: ↑/↓ select, enter details, r refresh account, R refresh all,
+s sort (utilization/reset/name), f filter provider, q quit.`,
+	RunE: runTop,
+}
+
+func init() {
+	topCmd.Flags().DurationVarP(&topInterval, "interval", "n", time.Minute, "Refresh interval (0 disables automatic refresh)")
+	addProviderFlag(topCmd, &topProvider, "all")
+	addSelectionFlags(topCmd, &topSelection)
+
+	rootCmd.AddCommand(topCmd)
+}
+
+func runTop(cmd *cobra.Command, _ []string) error {
+	applyConfigDefaults(cmd, &topProvider, nil, nil)
+	instances := usage.GetProviders(topProvider, "", true, credentialsManager(), topSelection)
+	if len(instances) == 0 {
+		return fmt.Errorf("no providers configured. Run 'llm-usage setup' to configure providers")
+	}
+	slices.SortStableFunc(instances, func(a, b usage.ProviderInstance) int {
+		return cmp.Compare(cfg.Rank(a.ID(), a.AccountName), cfg.Rank(b.ID(), b.AccountName))
+	})
+
+	p := tea.NewProgram(top.NewModel(instances, topInterval, cfg.Apply), tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("TUI error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/quota"
+	"github.com/denysvitali/llm-usage/internal/usage"
+	"github.com/denysvitali/llm-usage/pkg/llmusage"
+	"github.com/spf13/cobra"
+)
+
+// waitTimeoutCode is the exit status when the wait times out
+const waitTimeoutCode = 2
+
+var (
+	waitProvider    string
+	waitAccount     string
+	waitWindow      string
+	waitBelow       float64
+	waitTimeout     time.Duration
+	waitMinInterval time.Duration
+	waitMaxInterval time.Duration
+	waitQuiet       bool
+)
+
+var waitCmd = &cobra.Command{
+	Use:   "wait",
+	Short: "Block until an account's utilization drops below a threshold",
+	Long: `Block until an account's utilization drops below --below percent.
+
+Usage is polled with exponential backoff between --min-interval and
+--max-interval; when the blocking windows report a reset time, wait sleeps
+until then instead. Without --window every window must be below the threshold.
+
+Exits 0 once the condition holds, 2 when --timeout expires and 1 on errors
+(e.g. an unknown window).`,
+	Example: `  # Pause a batch job until the work account has 5-hour quota left
+  llm-usage wait --provider claude --account work --below 80 --window 5-Hour --timeout 6h && ./run-agents.sh`,
+	Args: cobra.NoArgs,
+	RunE: runWait,
+}
+
+func init() {
+	addProviderFlag(waitCmd, &waitProvider, "")
+	addAccountFlag(waitCmd, &waitAccount, "the provider's default account")
+	waitCmd.Flags().StringVarP(&waitWindow, "window", "w", "", "Window label to check, e.g. 5-Hour (default: all windows)")
+	waitCmd.Flags().Float64Var(&waitBelow, "below", 80, "Utilization percentage to wait for")
+	waitCmd.Flags().DurationVar(&waitTimeout, "timeout", 0, "Give up after this long (default: wait forever)")
+	waitCmd.Flags().DurationVar(&waitMinInterval, "min-interval", time.Minute, "Initial delay between polls")
+	waitCmd.Flags().DurationVar(&waitMaxInterval, "max-interval", 15*time.Minute, "Maximum delay between polls")
+	waitCmd.Flags().BoolVarP(&waitQuiet, "quiet", "q", false, "Don't print progress")
+	_ = waitCmd.MarkFlagRequired("provider")
+	rootCmd.AddCommand(waitCmd)
+}
+
+func runWait(cmd *cobra.Command, _ []string) error {
+	waitAccount = cfg.ResolveAccount(waitAccount)
+	if waitBelow <= 0 || waitBelow > 100 {
+		return fmt.Errorf("--below must be between 0 and 100")
+	}
+	if waitMinInterval <= 0 || waitMaxInterval < waitMinInterval {
+		return fmt.Errorf("--min-interval must be positive and not above --max-interval")
+	}
+
+	opts := []llmusage.Option{
+		llmusage.WithProviders(waitProvider),
+		llmusage.WithAccount(waitAccount),
+		llmusage.WithPersistentProviders(),
+	}
+	if credentialsFile != "" {
+		opts = append(opts, llmusage.WithCredentialsFile(credentialsFile))
+	}
+	client, err := newUsageClient(opts...)
+	if err != nil {
+		return err
+	}
+	// Flags are valid from here on; errors are about the wait itself
+	cmd.SilenceUsage = true
+
+	// Fail fast when nothing is configured instead of polling until the timeout
+	if len(client.Accounts()) == 0 {
+		return fmt.Errorf("%w for %s. Run 'llm-usage setup' to configure providers", llmusage.ErrNoProviders, waitTarget())
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if waitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, waitTimeout)
+		defer cancel()
+	}
+
+	fetch := func(ctx context.Context) (provider.Usage, error) {
+		stats, err := client.Fetch(ctx)
+		if err != nil {
+			return provider.Usage{}, err
+		}
+		for _, u := range stats.Providers {
+			if account, _ := u.Extra["account"].(string); waitAccount == "" || account == waitAccount {
+				return u, nil
+			}
+		}
+		return provider.Usage{}, fmt.Errorf("no usage for %s", waitTarget())
+	}
+
+	cond := quota.Condition{Window: waitWindow, Below: waitBelow}
+	backoff := quota.Backoff{Min: waitMinInterval, Max: waitMaxInterval}
+	status, err := quota.Wait(ctx, fetch, cond, backoff, func(s quota.Status, err error, next time.Duration) {
+		if waitQuiet {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v; retrying in %s\n", waitTarget(), err, waitDelay(next))
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s at %.0f%%, waiting for %s; next check in %s\n",
+			waitTarget(), s.Window, s.Utilization, cond, waitDelay(next))
+	})
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		msg := fmt.Sprintf("timed out after %s waiting for %s %s", waitTimeout, waitTarget(), cond)
+		if status.Window != "" {
+			msg += fmt.Sprintf(" (last: %s at %.0f%%)", status.Window, status.Utilization)
+		}
+		return &exitError{code: waitTimeoutCode, err: errors.New(msg)}
+	case err != nil:
+		return err
+	}
+	if !waitQuiet {
+		fmt.Fprintf(os.Stderr, "%s: %s at %.0f%%, below %.0f%%\n", waitTarget(), status.Window, status.Utilization, waitBelow)
+	}
+	return nil
+}
+
+// waitTarget names the provider account being waited on, e.g. "claude/work"
+func waitTarget() string {
+	if waitAccount == "" {
+		return waitProvider
+	}
+	return waitProvider + "/" + waitAccount
+}
+
+// waitDelay formats a poll delay, keeping seconds for short ones
+func waitDelay(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	return usage.FormatDuration(d)
+}
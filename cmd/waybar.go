@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/denysvitali/llm-usage/internal/barstate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	waybarBar    string
+	waybarSignal int
+)
+
+var waybarCmd = &cobra.Command{
+	Use:   "waybar",
+	Short: "Change what the status bar module displays",
+	Long: `Change what the compact status bar text shows, for use in Waybar's on-click and
+on-scroll hooks (or any other bar's click handlers).
+
+The display state is kept per bar (--bar) in $XDG_STATE_HOME/llm-usage/bars.
+A running 'llm-usage --follow' instance for the bar re-renders immediately.
+For interval-based modules, --signal N also sends SIGRTMIN+N to Waybar so it
+re-runs a module configured with "signal": N.`,
+}
+
+// waybarAction is a display state change
+type waybarAction struct {
+	use, short string
+	apply      func(*barstate.State)
+}
+
+var waybarActions = []waybarAction{
+	{"next-window", "Show the next usage window of each account", func(s *barstate.State) { s.Window++ }},
+	{"prev-window", "Show the previous usage window of each account", func(s *barstate.State) { s.Window-- }},
+	{"next-account", "Focus the next account (cycles back to all accounts)", func(s *barstate.State) { s.Account++ }},
+	{"prev-account", "Focus the previous account (cycles back to all accounts)", func(s *barstate.State) { s.Account-- }},
+	{"toggle-detail", "Toggle window labels and reset countdowns", func(s *barstate.State) { s.Detail = !s.Detail }},
+	{"reset", "Show the first window of all accounts", func(s *barstate.State) { *s = barstate.State{} }},
+}
+
+func init() {
+	waybarCmd.PersistentFlags().StringVar(&waybarBar, "bar", barstate.DefaultBar, "Status bar instance to change")
+	waybarCmd.PersistentFlags().IntVar(&waybarSignal, "signal", 0, "Also send SIGRTMIN+N to Waybar to re-run an interval-based module")
+
+	for _, action := range waybarActions {
+		waybarCmd.AddCommand(&cobra.Command{
+			Use:   action.use,
+			Short: action.short,
+			Args:  cobra.NoArgs,
+			RunE: func(_ *cobra.Command, _ []string) error {
+				return runWaybarAction(action.apply)
+			},
+		})
+	}
+	rootCmd.AddCommand(waybarCmd)
+}
+
+func runWaybarAction(apply func(*barstate.State)) error {
+	store := barstate.NewStore()
+	if _, err := store.Update(waybarBar, apply); err != nil {
+		return err
+	}
+
+	if err := redrawFollower(store, waybarBar); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if waybarSignal > 0 {
+		// pkill exits with 1 when no Waybar is running, which is fine
+		_ = exec.Command("pkill", fmt.Sprintf("-RTMIN+%d", waybarSignal), "-x", "waybar").Run() //nolint:gosec
+	}
+	return nil
+}
+
+// redrawFollower signals the bar's running --follow instance to re-render
+func redrawFollower(store *barstate.Store, bar string) error {
+	if redrawSignal == nil {
+		return nil
+	}
+
+	// Only a live instance holds its pid file's lock, so a reused PID is never signaled
+	pid, err := store.ReadPID(bar)
+	if err != nil || pid == 0 {
+		return err
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("failed to find --follow instance: %w", err)
+	}
+	if err := proc.Signal(redrawSignal); err != nil {
+		return fmt.Errorf("failed to signal --follow instance: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Package barstate persists the display state of interactive status bar modules.
+package barstate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/adrg/xdg"
+)
+
+// DefaultBar is the bar name used when none is given
+const DefaultBar = "default"
+
+// validName restricts bar names to safe file names
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// State is what a bar module currently displays
+type State struct {
+	Window  int  `json:"window"`  // window offset within each account
+	Account int  `json:"account"` // 0 for all accounts, otherwise the focused account
+	Detail  bool `json:"detail"`  // show window labels and reset countdowns
+}
+
+// Store keeps bar states and the PIDs of running --follow instances
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store in $XDG_STATE_HOME/llm-usage/bars
+func NewStore() *Store {
+	return &Store{dir: filepath.Join(xdg.StateHome, "llm-usage", "bars")}
+}
+
+// NewStoreFromDir creates a store in dir
+func NewStoreFromDir(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// path returns the file of a bar with the given extension
+func (s *Store) path(bar, ext string) (string, error) {
+	if !validName.MatchString(bar) {
+		return "", fmt.Errorf("invalid bar name %q (use letters, digits, - and _)", bar)
+	}
+	return filepath.Join(s.dir, bar+ext), nil
+}
+
+// Load returns a bar's state, or the zero state if none was saved
+func (s *Store) Load(bar string) (State, error) {
+	var state State
+	path, err := s.path(bar, ".json")
+	if err != nil {
+		return state, err
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read bar state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("failed to parse bar state: %w", err)
+	}
+	return state, nil
+}
+
+// Save stores a bar's state
+func (s *Store) Save(bar string, state State) error {
+	path, err := s.path(bar, ".json")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bar state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write bar state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write bar state: %w", err)
+	}
+	return nil
+}
+
+// Update applies fn to a bar's state and saves it
+func (s *Store) Update(bar string, fn func(*State)) (State, error) {
+	state, err := s.Load(bar)
+	if err != nil {
+		return state, err
+	}
+	fn(&state)
+	return state, s.Save(bar, state)
+}
+
+// WritePID records the PID of the running --follow instance of a bar. The
+// record is locked while the instance runs, so a record left behind by a
+// crashed instance is never mistaken for a live one. It fails when another
+// instance holds the record. The returned function removes the record.
+func (s *Store) WritePID(bar string) (func(), error) {
+	path, err := s.path(bar, ".pid")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	f, err := lockPIDFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(0); err == nil {
+		_, err = f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to write pid file: %w", err)
+	}
+	return func() {
+		// Remove before unlocking, so no one sees an unlocked live record
+		_ = os.Remove(path)
+		_ = unlockFile(f)
+		_ = f.Close()
+	}, nil
+}
+
+// lockPIDFile opens and locks a pid file, retrying when a reader removed a
+// stale file between opening and locking it
+func lockPIDFile(path string) (*os.File, error) {
+	for range 3 {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600) //nolint:gosec
+		if err != nil {
+			return nil, fmt.Errorf("failed to open pid file: %w", err)
+		}
+		locked, err := tryLockFile(f)
+		if err != nil || !locked {
+			_ = f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to lock pid file: %w", err)
+			}
+			return nil, fmt.Errorf("another --follow instance is running (%s)", path)
+		}
+		if sameFile(f, path) {
+			return f, nil
+		}
+		_ = unlockFile(f)
+		_ = f.Close()
+	}
+	return nil, fmt.Errorf("failed to lock pid file %s", path)
+}
+
+// sameFile reports whether the open file is still the one at path
+func sameFile(f *os.File, path string) bool {
+	a, errA := f.Stat()
+	b, errB := os.Stat(path)
+	return errA == nil && errB == nil && os.SameFile(a, b)
+}
+
+// ReadPID returns the PID of the running --follow instance of a bar, or 0 if
+// there is none. A record whose instance no longer holds its lock is stale;
+// it is removed, since its PID may have been reused by another process.
+func (s *Store) ReadPID(bar string) (int, error) {
+	path, err := s.path(bar, ".pid")
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Open(path) //nolint:gosec
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read pid file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	locked, err := tryLockFile(f)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock pid file: %w", err)
+	}
+	if locked {
+		defer func() { _ = unlockFile(f) }()
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("failed to remove stale pid file: %w", err)
+		}
+		return 0, nil
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read pid file: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid pid file %s: %w", path, err)
+	}
+	return pid, nil
+}
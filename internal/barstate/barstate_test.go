@@ -0,0 +1,88 @@
+package barstate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_State(t *testing.T) {
+	s := NewStoreFromDir(t.TempDir())
+
+	state, err := s.Load("top")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != (State{}) {
+		t.Errorf("Load() = %+v, want zero state", state)
+	}
+
+	for range 2 {
+		if _, err := s.Update("top", func(st *State) { st.Window++ }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Update("top", func(st *State) { st.Detail = !st.Detail }); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = s.Load("top")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (State{Window: 2, Detail: true}); state != want {
+		t.Errorf("Load() = %+v, want %+v", state, want)
+	}
+
+	// Bars are independent
+	if other, _ := s.Load("bottom"); other != (State{}) {
+		t.Errorf("Load(bottom) = %+v, want zero state", other)
+	}
+}
+
+func TestStore_InvalidName(t *testing.T) {
+	s := NewStoreFromDir(t.TempDir())
+	for _, name := range []string{"", "../x", "a/b", "a b"} {
+		if _, err := s.Load(name); err == nil {
+			t.Errorf("Load(%q) should fail", name)
+		}
+	}
+}
+
+func TestStore_PID(t *testing.T) {
+	s := NewStoreFromDir(t.TempDir())
+
+	if pid, err := s.ReadPID(DefaultBar); err != nil || pid != 0 {
+		t.Fatalf("ReadPID() = %d, %v, want 0, nil", pid, err)
+	}
+
+	remove, err := s.WritePID(DefaultBar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid, err := s.ReadPID(DefaultBar); err != nil || pid != os.Getpid() {
+		t.Errorf("ReadPID() = %d, %v, want %d", pid, err, os.Getpid())
+	}
+
+	if _, err := s.WritePID(DefaultBar); err == nil {
+		t.Error("WritePID() succeeded while another instance holds the record")
+	}
+
+	remove()
+	if pid, _ := s.ReadPID(DefaultBar); pid != 0 {
+		t.Errorf("ReadPID() after remove = %d, want 0", pid)
+	}
+
+	// A crashed instance leaves an unlocked record whose PID may be reused
+	path := filepath.Join(s.dir, DefaultBar+".pid")
+	if err := os.WriteFile(path, []byte("1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if pid, err := s.ReadPID(DefaultBar); err != nil || pid != 0 {
+		t.Errorf("ReadPID() of a stale record = %d, %v, want 0, nil", pid, err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("stale pid file was not removed: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package barstate
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockOffset places the lock past the PID, since Windows locks block reads
+// of the locked range
+var lockOffset = windows.Overlapped{OffsetHigh: 1}
+
+// tryLockFile takes an exclusive lock on f without blocking. It returns
+// false when another open file holds the lock.
+func tryLockFile(f *os.File) (bool, error) {
+	ol := lockOffset
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlockFile releases the lock taken by tryLockFile
+func unlockFile(f *os.File) error {
+	ol := lockOffset
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &ol)
+}
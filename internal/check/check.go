@@ -0,0 +1,241 @@
+// Package check evaluates usage against thresholds with Nagios/Icinga
+// compatible states and performance data.
+package check
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// State is a Nagios plugin state, which is also the process exit code
+type State int
+
+// Nagios plugin states
+const (
+	OK State = iota
+	Warning
+	Critical
+	Unknown
+)
+
+// String returns the state as Nagios prints it, e.g. "CRITICAL"
+func (s State) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case Warning:
+		return "WARNING"
+	case Critical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// severity orders states from best to worst like Icinga: OK, WARNING, UNKNOWN, CRITICAL
+func (s State) severity() int {
+	return [...]int{OK: 0, Warning: 1, Unknown: 2, Critical: 3}[s]
+}
+
+// Threshold is a utilization limit for the windows a selector matches
+type Threshold struct {
+	provider.Selector
+	Value float64
+}
+
+// ParseThreshold parses "provider[/account][:window]=percent", e.g.
+// "claude:5-Hour=90", "claude/work:*=80" or "kimi=95"
+func ParseThreshold(s string) (Threshold, error) {
+	target, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: expected provider[/account][:window]=percent", s)
+	}
+	v, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: %w", s, err)
+	}
+	sel, err := provider.ParseSelector(target)
+	if err != nil {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: %w", s, err)
+	}
+	return Threshold{Selector: sel, Value: v}, nil
+}
+
+// String formats the threshold as ParseThreshold accepts it
+func (t Threshold) String() string {
+	return t.Selector.String() + "=" + formatFloat(t.Value)
+}
+
+// Options configure Evaluate
+type Options struct {
+	Warn []Threshold // warning thresholds, the configured ones when none matches
+	Max  []Threshold // critical thresholds, the configured ones when none matches
+
+	// AllWindows checks every window even when Warn or Max are given, instead
+	// of only the windows they match
+	AllWindows bool
+
+	// RequireConfigured makes providers named by thresholds UNKNOWN when they
+	// have no successfully fetched account
+	RequireConfigured bool
+}
+
+// Perf is one Nagios performance data entry
+type Perf struct {
+	Label    string
+	Value    float64
+	Warning  float64
+	Critical float64
+}
+
+// String formats the entry as 'label'=value%;warn;crit;0;100
+func (p Perf) String() string {
+	label := strings.ReplaceAll(p.Label, "'", "''")
+	return fmt.Sprintf("'%s'=%s%%;%s;%s;0;100", label, formatFloat(p.Value), formatFloat(p.Warning), formatFloat(p.Critical))
+}
+
+// Problem is a window or provider that isn't OK
+type Problem struct {
+	State State
+	Text  string
+}
+
+// Result is the outcome of a check
+type Result struct {
+	State    State
+	Problems []Problem // worst first
+	Perf     []Perf
+}
+
+// Evaluate checks the windows of the usage stats against the thresholds.
+// With Warn or Max thresholds only the windows and failed accounts they match
+// are checked, unless AllWindows is set.
+func Evaluate(stats *provider.UsageStats, opts Options) Result {
+	r := Result{}
+	fetched := map[string]bool{}
+	thresholds := slices.Concat(opts.Warn, opts.Max)
+	scoped := len(thresholds) > 0 && !opts.AllWindows
+
+	for _, u := range stats.Providers {
+		account, _ := u.Extra["account"].(string)
+		name := u.Provider
+		if account != "" {
+			name += "/" + account
+		}
+		if u.Error != nil {
+			if scoped && !matchesAccount(thresholds, u.Provider, account) {
+				continue
+			}
+			r.raise(Unknown, fmt.Sprintf("%s: %v", name, u.Error))
+			continue
+		}
+		fetched[u.Provider] = true
+
+		for _, w := range u.Windows {
+			if scoped && !slices.ContainsFunc(thresholds, func(t Threshold) bool { return t.Match(u.Provider, account, w.Label) >= 0 }) {
+				continue
+			}
+			defaults := provider.ThresholdsFor(u.Provider, account, w.Label)
+			warn := match(opts.Warn, u.Provider, account, w.Label, defaults.Warning)
+			crit := match(opts.Max, u.Provider, account, w.Label, defaults.Critical)
+			r.Perf = append(r.Perf, Perf{Label: name + " " + w.Label, Value: w.Utilization, Warning: warn, Critical: crit})
+
+			switch {
+			case w.Utilization >= crit:
+				r.raise(Critical, fmt.Sprintf("%s %s %s%% >= %s%%", name, w.Label, formatFloat(w.Utilization), formatFloat(crit)))
+			case w.Utilization >= warn:
+				r.raise(Warning, fmt.Sprintf("%s %s %s%% >= %s%%", name, w.Label, formatFloat(w.Utilization), formatFloat(warn)))
+			}
+		}
+	}
+
+	if opts.RequireConfigured {
+		var required []string
+		for _, t := range thresholds {
+			if t.Provider != "*" && !slices.Contains(required, t.Provider) {
+				required = append(required, t.Provider)
+			}
+		}
+		for _, p := range required {
+			if !fetched[p] {
+				r.raise(Unknown, p+": not configured")
+			}
+		}
+	}
+
+	slices.SortStableFunc(r.Problems, func(a, b Problem) int {
+		return b.State.severity() - a.State.severity()
+	})
+	return r
+}
+
+// raise records a problem and worsens the state if needed
+func (r *Result) raise(s State, text string) {
+	if s.severity() > r.State.severity() {
+		r.State = s
+	}
+	r.Problems = append(r.Problems, Problem{State: s, Text: text})
+}
+
+// matchesAccount reports whether any threshold may match a window of the account
+func matchesAccount(thresholds []Threshold, providerID, account string) bool {
+	return slices.ContainsFunc(thresholds, func(t Threshold) bool {
+		sel := t.Selector
+		sel.Window = ""
+		return sel.Match(providerID, account, "") >= 0
+	})
+}
+
+// match returns the value of the most specific threshold for a window
+func match(thresholds []Threshold, providerID, account, window string, fallback float64) float64 {
+	best, value := -1, fallback
+	for _, t := range thresholds {
+		// Later thresholds win ties, so flags can override earlier ones
+		if s := t.Match(providerID, account, window); s >= 0 && s >= best {
+			best, value = s, t.Value
+		}
+	}
+	return value
+}
+
+// Output returns the plugin output: a status line with perfdata, followed
+// by one line per problem
+func (r Result) Output() string {
+	var b strings.Builder
+	b.WriteString("LLM USAGE " + r.State.String())
+	switch {
+	case len(r.Problems) > 0:
+		b.WriteString(" - " + r.Problems[0].Text)
+		if n := len(r.Problems) - 1; n > 0 {
+			fmt.Fprintf(&b, " (+%d more)", n)
+		}
+	case len(r.Perf) == 0:
+		b.WriteString(" - no usage windows")
+	default:
+		b.WriteString(" - all windows below thresholds")
+	}
+
+	if len(r.Perf) > 0 {
+		perf := make([]string, len(r.Perf))
+		for i, p := range r.Perf {
+			perf[i] = p.String()
+		}
+		b.WriteString(" | " + strings.Join(perf, " "))
+	}
+	b.WriteString("\n")
+
+	if len(r.Problems) > 1 {
+		for _, p := range r.Problems {
+			b.WriteString(p.State.String() + ": " + p.Text + "\n")
+		}
+	}
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,103 @@
+package check
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Threshold
+		wantErr bool
+	}{
+		{"claude:5-Hour=90", Threshold{provider.Selector{Provider: "claude", Window: "5-Hour"}, 90}, false},
+		{"kimi:*=95", Threshold{provider.Selector{Provider: "kimi", Window: "*"}, 95}, false},
+		{"claude/work=80%", Threshold{provider.Selector{Provider: "claude", Account: "work"}, 80}, false},
+		{"*:Daily=50.5", Threshold{provider.Selector{Provider: "*", Window: "Daily"}, 50.5}, false},
+		{"claude:5-Hour", Threshold{}, true},
+		{"=90", Threshold{}, true},
+		{"claude=high", Threshold{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseThreshold(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseThreshold(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseThreshold(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func sampleStats() *provider.UsageStats {
+	return &provider.UsageStats{Providers: []provider.Usage{
+		{Provider: "claude", Extra: map[string]any{"account": "work"}, Windows: []provider.UsageWindow{
+			{Label: "5-Hour", Utilization: 85},
+			{Label: "7-Day", Utilization: 40},
+		}},
+		{Provider: "kimi", Windows: []provider.UsageWindow{{Label: "Weekly", Utilization: 96}}},
+	}}
+}
+
+func mustParse(t *testing.T, specs ...string) []Threshold {
+	t.Helper()
+	var out []Threshold
+	for _, s := range specs {
+		th, err := ParseThreshold(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, th)
+	}
+	return out
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats *provider.UsageStats
+		opts  Options
+		want  State
+	}{
+		{"defaults", sampleStats(), Options{}, Critical},
+		{"relaxed", sampleStats(), Options{Max: mustParse(t, "kimi:*=97", "claude:5-Hour=90")}, Warning},
+		{"all ok", sampleStats(), Options{Warn: mustParse(t, "*=99"), Max: mustParse(t, "*=100")}, OK},
+		{"specific wins", sampleStats(), Options{Warn: mustParse(t, "*=99"), Max: mustParse(t, "*=100", "claude/work:5-Hour=80")}, Critical},
+		{"required missing", sampleStats(), Options{Max: mustParse(t, "*=100", "zai=90"), Warn: mustParse(t, "*=99"), RequireConfigured: true}, Unknown},
+		{"unknown beats warning", sampleStats(), Options{Max: mustParse(t, "*=100", "zai=90"), RequireConfigured: true}, Unknown},
+		{"fetch error", &provider.UsageStats{Providers: []provider.Usage{{Provider: "zai", Error: errors.New("timeout")}}}, Options{}, Unknown},
+		{"only named windows", sampleStats(), Options{Max: mustParse(t, "claude:7-Day=90")}, OK},
+		{"all windows", sampleStats(), Options{Max: mustParse(t, "claude:7-Day=90"), AllWindows: true}, Critical},
+		{"unrelated fetch error", &provider.UsageStats{Providers: []provider.Usage{{Provider: "zai", Error: errors.New("timeout")}}}, Options{Max: mustParse(t, "claude=90")}, OK},
+		{"named fetch error", &provider.UsageStats{Providers: []provider.Usage{{Provider: "zai", Error: errors.New("timeout")}}}, Options{Max: mustParse(t, "zai:Weekly=90")}, Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Evaluate(tt.stats, tt.opts); got.State != tt.want {
+				t.Errorf("Evaluate() = %v %v, want %v", got.State, got.Problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutput(t *testing.T) {
+	r := Evaluate(sampleStats(), Options{Max: mustParse(t, "claude:5-Hour=90"), AllWindows: true})
+	out := r.Output()
+
+	want := "LLM USAGE CRITICAL - kimi Weekly 96% >= 90% (+1 more) | 'claude/work 5-Hour'=85%;75;90;0;100 'claude/work 7-Day'=40%;75;90;0;100 'kimi Weekly'=96%;75;90;0;100\n"
+	if first, _, _ := strings.Cut(out, "\n"); first+"\n" != want {
+		t.Errorf("Output() first line = %q, want %q", first, want)
+	}
+	if !strings.Contains(out, "WARNING: claude/work 5-Hour 85% >= 75%\n") {
+		t.Errorf("Output() is missing the warning detail:\n%s", out)
+	}
+
+	if got := (Result{}).Output(); got != "LLM USAGE OK - no usage windows\n" {
+		t.Errorf("Output() = %q for an empty result", got)
+	}
+}
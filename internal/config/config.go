@@ -0,0 +1,377 @@
+// Package config loads the optional config file with defaults, thresholds
+// and display settings ($XDG_CONFIG_HOME/llm-usage/config.yaml).
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of the config file
+type Config struct {
+	Defaults   Defaults             `yaml:"defaults,omitempty"`
+	Thresholds map[string]Threshold `yaml:"thresholds,omitempty"`  // selector -> thresholds
+	LowBalance map[string]Threshold `yaml:"low_balance,omitempty"` // provider[/account] -> balance amounts
+	Order      []string             `yaml:"order,omitempty"`       // selectors, shown first to last
+	Hide       []string             `yaml:"hide,omitempty"`        // selectors of hidden windows
+	Aliases    map[string]string    `yaml:"aliases,omitempty"`     // alias -> account name
+	Labels     map[string]string    `yaml:"labels,omitempty"`      // selector -> window label
+	Output     Output               `yaml:"output,omitempty"`
+
+	// Parsed selectors, set by Validate
+	thresholds []selected[Threshold]
+	lowBalance []selected[Threshold]
+	order      []provider.Selector
+	hide       []provider.Selector
+	labels     []selected[string]
+}
+
+// Defaults replace the built-in defaults of --provider, --account and --all-accounts
+type Defaults struct {
+	Providers   []string `yaml:"providers,omitempty"`
+	Account     string   `yaml:"account,omitempty"`
+	AllAccounts bool     `yaml:"all_accounts,omitempty"`
+}
+
+// Threshold overrides the warning and critical utilization of matching
+// windows. Under low_balance, they are balance amounts instead.
+type Threshold struct {
+	Warning  *float64 `yaml:"warning,omitempty"`
+	Critical *float64 `yaml:"critical,omitempty"`
+}
+
+// Output replaces the built-in defaults of the output flags
+type Output struct {
+	Format   string        `yaml:"format,omitempty"`   // --output
+	Template string        `yaml:"template,omitempty"` // --format
+	Interval time.Duration `yaml:"interval,omitempty"` // --interval
+	Bar      string        `yaml:"bar,omitempty"`      // --bar
+}
+
+// selected pairs a parsed selector with its value
+type selected[T any] struct {
+	sel   provider.Selector
+	value T
+}
+
+// Path returns the config file path: $LLM_USAGE_CONFIG or
+// $XDG_CONFIG_HOME/llm-usage/config.yaml
+func Path() string {
+	if path := os.Getenv("LLM_USAGE_CONFIG"); path != "" {
+		return path
+	}
+	return filepath.Join(xdg.ConfigHome, "llm-usage", "config.yaml")
+}
+
+// Load reads and validates a config file. A missing file is an empty config.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if errors.Is(err, os.ErrNotExist) {
+		cfg := &Config{}
+		return cfg, cfg.Validate()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates config YAML, rejecting unknown keys
+func Parse(data []byte) (*Config, error) {
+	cfg := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Env lists the environment variables that override config values
+var Env = []string{
+	"LLM_USAGE_DEFAULT_PROVIDERS",    // defaults.providers, comma-separated
+	"LLM_USAGE_DEFAULT_ACCOUNT",      // defaults.account
+	"LLM_USAGE_DEFAULT_ALL_ACCOUNTS", // defaults.all_accounts
+	"LLM_USAGE_OUTPUT",               // output.format
+	"LLM_USAGE_INTERVAL",             // output.interval
+	"LLM_USAGE_WARNING",              // thresholds["*"].warning
+	"LLM_USAGE_CRITICAL",             // thresholds["*"].critical
+}
+
+// ApplyEnv overrides config values with the environment variables in Env
+func (c *Config) ApplyEnv(getenv func(string) string) error {
+	if v := getenv("LLM_USAGE_DEFAULT_PROVIDERS"); v != "" {
+		c.Defaults.Providers = strings.Split(v, ",")
+	}
+	if v := getenv("LLM_USAGE_DEFAULT_ACCOUNT"); v != "" {
+		c.Defaults.Account = v
+	}
+	if v := getenv("LLM_USAGE_DEFAULT_ALL_ACCOUNTS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid LLM_USAGE_DEFAULT_ALL_ACCOUNTS: %w", err)
+		}
+		c.Defaults.AllAccounts = b
+	}
+	if v := getenv("LLM_USAGE_OUTPUT"); v != "" {
+		c.Output.Format = v
+	}
+	if v := getenv("LLM_USAGE_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid LLM_USAGE_INTERVAL: %w", err)
+		}
+		c.Output.Interval = d
+	}
+	for _, level := range []string{"WARNING", "CRITICAL"} {
+		v := getenv("LLM_USAGE_" + level)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid LLM_USAGE_%s: %w", level, err)
+		}
+		if c.Thresholds == nil {
+			c.Thresholds = map[string]Threshold{}
+		}
+		t := c.Thresholds["*"]
+		if level == "WARNING" {
+			t.Warning = &f
+		} else {
+			t.Critical = &f
+		}
+		c.Thresholds["*"] = t
+	}
+	return c.Validate()
+}
+
+// Validate checks the config and parses its selectors
+func (c *Config) Validate() error {
+	var errs []error
+	parse := func(field, s string) provider.Selector {
+		sel, err := provider.ParseSelector(s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field, err))
+		}
+		return sel
+	}
+
+	c.thresholds = c.thresholds[:0]
+	for _, key := range slices.Sorted(maps.Keys(c.Thresholds)) {
+		t := c.Thresholds[key]
+		for _, v := range []*float64{t.Warning, t.Critical} {
+			if v != nil && (*v < 0 || *v > 100) {
+				errs = append(errs, fmt.Errorf("thresholds %q: %v is not a percentage between 0 and 100", key, *v))
+			}
+		}
+		if t.Warning != nil && t.Critical != nil && *t.Warning > *t.Critical {
+			errs = append(errs, fmt.Errorf("thresholds %q: warning %v is above critical %v", key, *t.Warning, *t.Critical))
+		}
+		c.thresholds = append(c.thresholds, selected[Threshold]{parse("thresholds", key), t})
+	}
+
+	c.lowBalance = c.lowBalance[:0]
+	for _, key := range slices.Sorted(maps.Keys(c.LowBalance)) {
+		t := c.LowBalance[key]
+		for _, v := range []*float64{t.Warning, t.Critical} {
+			if v != nil && *v < 0 {
+				errs = append(errs, fmt.Errorf("low_balance %q: %v is negative", key, *v))
+			}
+		}
+		if t.Warning != nil && t.Critical != nil && *t.Warning < *t.Critical {
+			errs = append(errs, fmt.Errorf("low_balance %q: warning %v is below critical %v", key, *t.Warning, *t.Critical))
+		}
+		sel := parse("low_balance", key)
+		if sel.Window != "" {
+			errs = append(errs, fmt.Errorf("low_balance %q: selector must not name a window", key))
+		}
+		c.lowBalance = append(c.lowBalance, selected[Threshold]{sel, t})
+	}
+
+	c.order = c.order[:0]
+	for _, s := range c.Order {
+		c.order = append(c.order, parse("order", s))
+	}
+	c.hide = c.hide[:0]
+	for _, s := range c.Hide {
+		c.hide = append(c.hide, parse("hide", s))
+	}
+	c.labels = c.labels[:0]
+	for _, key := range slices.Sorted(maps.Keys(c.Labels)) {
+		sel := parse("labels", key)
+		if sel.Window == "" || sel.Window == "*" {
+			errs = append(errs, fmt.Errorf("labels %q: selector must name a window, e.g. claude:7-Day", key))
+		}
+		c.labels = append(c.labels, selected[string]{sel, c.Labels[key]})
+	}
+
+	for alias, account := range c.Aliases {
+		if alias == "" || account == "" {
+			errs = append(errs, fmt.Errorf("aliases: empty alias or account in %q: %q", alias, account))
+		}
+	}
+	if c.Output.Format != "" && c.Output.Template != "" {
+		errs = append(errs, fmt.Errorf("output: format and template are mutually exclusive"))
+	}
+	if c.Output.Interval < 0 {
+		errs = append(errs, fmt.Errorf("output.interval: must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// ThresholdsFor returns the thresholds of a provider account's window: the
+// most specific configured warning and critical values, falling back to
+// provider.DefaultThresholds. Renamed window labels match under their
+// original label too.
+func (c *Config) ThresholdsFor(providerID, account, window string) provider.Thresholds {
+	windows := []string{window}
+	for _, l := range c.labels {
+		if l.value == window && l.sel.Match(providerID, account, l.sel.Window) >= 0 {
+			windows = append(windows, l.sel.Window)
+		}
+	}
+
+	out := provider.DefaultThresholds
+	warnScore, critScore := -1, -1
+	for _, t := range c.thresholds {
+		score := -1
+		for _, w := range windows {
+			score = max(score, t.sel.Match(providerID, account, w))
+		}
+		if score < 0 {
+			continue
+		}
+		if t.value.Warning != nil && score > warnScore {
+			out.Warning, warnScore = *t.value.Warning, score
+		}
+		if t.value.Critical != nil && score > critScore {
+			out.Critical, critScore = *t.value.Critical, score
+		}
+	}
+	return out
+}
+
+// LowBalanceFor returns the most specific configured low-balance amounts of
+// a provider account, or zero amounts when none are configured
+func (c *Config) LowBalanceFor(providerID, account string) provider.Thresholds {
+	var out provider.Thresholds
+	warnScore, critScore := -1, -1
+	for _, t := range c.lowBalance {
+		score := t.sel.Match(providerID, account, provider.BalanceLabel)
+		if score < 0 {
+			continue
+		}
+		if t.value.Warning != nil && score > warnScore {
+			out.Warning, warnScore = *t.value.Warning, score
+		}
+		if t.value.Critical != nil && score > critScore {
+			out.Critical, critScore = *t.value.Critical, score
+		}
+	}
+	return out
+}
+
+// ResolveAccount returns the account an alias stands for, or the name itself
+func (c *Config) ResolveAccount(name string) string {
+	if account, ok := c.Aliases[name]; ok {
+		return account
+	}
+	return name
+}
+
+// Label returns the display label of a window
+func (c *Config) Label(providerID, account, window string) string {
+	best, label := -1, window
+	for _, l := range c.labels {
+		if s := l.sel.Match(providerID, account, window); s > best {
+			best, label = s, l.value
+		}
+	}
+	return label
+}
+
+// Apply returns a copy of the stats with hidden windows removed, labels
+// renamed and providers sorted by the configured order
+func (c *Config) Apply(stats *provider.UsageStats) *provider.UsageStats {
+	out := &provider.UsageStats{Providers: make([]provider.Usage, 0, len(stats.Providers))}
+	for _, u := range stats.Providers {
+		account, _ := u.Extra["account"].(string)
+		var windows []provider.UsageWindow
+		for _, w := range u.Windows {
+			if c.hidden(u.Provider, account, w.Label) {
+				continue
+			}
+			w.Label = c.Label(u.Provider, account, w.Label)
+			windows = append(windows, w)
+		}
+		// Drop accounts whose windows are all hidden
+		if len(u.Windows) > 0 && len(windows) == 0 {
+			continue
+		}
+		u.Windows = windows
+		out.Providers = append(out.Providers, u)
+	}
+
+	if len(c.order) > 0 {
+		slices.SortStableFunc(out.Providers, func(a, b provider.Usage) int {
+			return cmp.Compare(c.rank(a), c.rank(b))
+		})
+	}
+	return out
+}
+
+// rank returns the display rank of a usage
+func (c *Config) rank(u provider.Usage) int {
+	account, _ := u.Extra["account"].(string)
+	return c.Rank(u.Provider, account)
+}
+
+// hidden reports whether a window is hidden
+func (c *Config) hidden(providerID, account, window string) bool {
+	return slices.ContainsFunc(c.hide, func(sel provider.Selector) bool {
+		return sel.Match(providerID, account, window) >= 0
+	})
+}
+
+// Rank returns the position of the first order entry matching the account,
+// or len(order) for accounts that aren't listed
+func (c *Config) Rank(providerID, account string) int {
+	for i, sel := range c.order {
+		if sel.Match(providerID, account, "") >= 0 {
+			return i
+		}
+	}
+	return len(c.order)
+}
+
+// Marshal encodes the config as YAML
+func (c *Config) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+const sample = `
+defaults:
+  providers: [claude, kimi]
+  account: work
+thresholds:
+  "*": {warning: 70, critical: 85}
+  claude:5-Hour: {critical: 95}
+  kimi/main: {warning: 50}
+low_balance:
+  "*": {warning: 5, critical: 1}
+  deepseek/work: {warning: 20}
+order: [kimi, claude/home]
+hide: ["claude:7-Day Opus"]
+aliases:
+  w: work
+labels:
+  claude:5-Hour: Session
+output:
+  format: waybar
+  interval: 2m
+`
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if cfg.Defaults.Account != "work" || cfg.Output.Interval != 2*time.Minute || cfg.Output.Format != "waybar" {
+		t.Errorf("Parse() = %+v", cfg)
+	}
+	if got := cfg.ResolveAccount("w"); got != "work" {
+		t.Errorf("ResolveAccount(w) = %q, want work", got)
+	}
+	if got := cfg.ResolveAccount("home"); got != "home" {
+		t.Errorf("ResolveAccount(home) = %q, want home", got)
+	}
+
+	for _, in := range []string{
+		"unknown: true",
+		`thresholds: {"claude": {warning: 120}}`,
+		`thresholds: {"claude": {warning: 90, critical: 80}}`,
+		`labels: {claude: Session}`,
+		`order: [":5-Hour"]`,
+		`low_balance: {"deepseek": {warning: 1, critical: 5}}`,
+		`low_balance: {"deepseek:Balance": {warning: 5}}`,
+	} {
+		if _, err := Parse([]byte(in)); err == nil {
+			t.Errorf("Parse(%q) should fail", in)
+		}
+	}
+}
+
+func TestThresholdsFor(t *testing.T) {
+	cfg, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		provider, account, window string
+		want                      provider.Thresholds
+	}{
+		{"zai", "", "Tokens", provider.Thresholds{Warning: 70, Critical: 85}},
+		{"claude", "work", "5-Hour", provider.Thresholds{Warning: 70, Critical: 95}},
+		{"claude", "work", "Session", provider.Thresholds{Warning: 70, Critical: 95}},
+		{"kimi", "main", "Weekly", provider.Thresholds{Warning: 50, Critical: 85}},
+	}
+	for _, tt := range tests {
+		if got := cfg.ThresholdsFor(tt.provider, tt.account, tt.window); got != tt.want {
+			t.Errorf("ThresholdsFor(%s, %s, %s) = %+v, want %+v", tt.provider, tt.account, tt.window, got, tt.want)
+		}
+	}
+
+	if got := (&Config{}).ThresholdsFor("claude", "", "5-Hour"); got != provider.DefaultThresholds {
+		t.Errorf("ThresholdsFor() = %+v for an empty config, want the defaults", got)
+	}
+}
+
+func TestLowBalanceFor(t *testing.T) {
+	cfg, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := cfg.LowBalanceFor("deepseek", "work"), (provider.Thresholds{Warning: 20, Critical: 1}); got != want {
+		t.Errorf("LowBalanceFor(deepseek, work) = %+v, want %+v", got, want)
+	}
+	if got, want := cfg.LowBalanceFor("kimi", ""), (provider.Thresholds{Warning: 5, Critical: 1}); got != want {
+		t.Errorf("LowBalanceFor(kimi) = %+v, want %+v", got, want)
+	}
+	if got := (&Config{}).LowBalanceFor("kimi", ""); got != (provider.Thresholds{}) {
+		t.Errorf("LowBalanceFor() = %+v for an empty config, want zero amounts", got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	cfg, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := &provider.UsageStats{Providers: []provider.Usage{
+		{Provider: "claude", Extra: map[string]any{"account": "work"}, Windows: []provider.UsageWindow{
+			{Label: "5-Hour", Utilization: 10},
+			{Label: "7-Day Opus", Utilization: 20},
+		}},
+		{Provider: "claude", Extra: map[string]any{"account": "home"}, Windows: []provider.UsageWindow{{Label: "7-Day Opus"}}},
+		{Provider: "zai", Windows: []provider.UsageWindow{{Label: "Tokens"}}},
+		{Provider: "kimi", Windows: []provider.UsageWindow{{Label: "Weekly"}}},
+	}}
+
+	got := cfg.Apply(stats)
+	var order []string
+	for _, u := range got.Providers {
+		labels := make([]string, len(u.Windows))
+		for i, w := range u.Windows {
+			labels[i] = w.Label
+		}
+		order = append(order, u.Provider+"="+strings.Join(labels, ","))
+	}
+	if want := "kimi=Weekly claude=Session zai=Tokens"; strings.Join(order, " ") != want {
+		t.Errorf("Apply() = %v, want %s", order, want)
+	}
+	if stats.Providers[0].Windows[0].Label != "5-Hour" || len(stats.Providers[0].Windows) != 2 {
+		t.Error("Apply() modified its input")
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	cfg, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"LLM_USAGE_DEFAULT_PROVIDERS": "zai",
+		"LLM_USAGE_OUTPUT":            "json",
+		"LLM_USAGE_INTERVAL":          "30s",
+		"LLM_USAGE_CRITICAL":          "99",
+	}
+	if err := cfg.ApplyEnv(func(k string) string { return env[k] }); err != nil {
+		t.Fatalf("ApplyEnv() failed: %v", err)
+	}
+	if cfg.Defaults.Providers[0] != "zai" || cfg.Output.Format != "json" || cfg.Output.Interval != 30*time.Second {
+		t.Errorf("ApplyEnv() = %+v", cfg)
+	}
+	if got := cfg.ThresholdsFor("zai", "", "Tokens"); got.Critical != 99 || got.Warning != 70 {
+		t.Errorf("ThresholdsFor() = %+v, want critical 99 from the env", got)
+	}
+
+	env = map[string]string{"LLM_USAGE_INTERVAL": "soon"}
+	if err := cfg.ApplyEnv(func(k string) string { return env[k] }); err == nil {
+		t.Error("ApplyEnv() should reject an invalid interval")
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if len(cfg.Thresholds) != 0 || cfg.Output.Format != "" {
+		t.Errorf("Load() = %+v, want an empty config", cfg)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("order: [claude]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := Load(path); err != nil || len(cfg.Order) != 1 {
+		t.Errorf("Load() = %+v, %v", cfg, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/denysvitali/llm-usage/internal/keychain"
@@ -88,3 +89,13 @@ func LoadFromPath(path string) (*Credentials, error) {
 
 	return parseCredentials(data)
 }
+
+// ExpandHome expands a leading ~/ in a path, e.g. a configured configDir
+func ExpandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -149,3 +149,53 @@ func TestLoadFromPath(t *testing.T) {
 		}
 	})
 }
+
+func TestReadSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"plain", "plain", false},
+		{"@" + path, "s3cret", false},
+		{"@" + path + ".missing", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ReadSecret(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ReadSecret(%q) = %q, %v, want %q (error: %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSecretEnv(t *testing.T) {
+	if got, want := SecretEnv("LLM_USAGE_PEER_TOKEN", "home-lab.2"), "LLM_USAGE_PEER_TOKEN_HOME_LAB_2"; got != want {
+		t.Errorf("SecretEnv() = %q, want %q", got, want)
+	}
+}
+
+func TestGenericCredentials_GetAccount(t *testing.T) {
+	creds := &GenericCredentials{Accounts: map[string]GenericAccount{
+		"zeta":  {"token": "z"},
+		"alpha": {"token": "a"},
+		"mid":   {"token": "m"},
+	}}
+	for range 10 {
+		if got := creds.GetAccount("")["token"]; got != "a" {
+			t.Fatalf("GetAccount(\"\") token = %q, want the first account in name order", got)
+		}
+	}
+
+	creds.Accounts["default"] = GenericAccount{"token": "d"}
+	if got := creds.GetAccount("")["token"]; got != "d" {
+		t.Errorf("GetAccount(\"\") token = %q, want the default account", got)
+	}
+	if got := creds.GetAccount("zeta")["token"]; got != "z" {
+		t.Errorf("GetAccount(zeta) token = %q, want z", got)
+	}
+}
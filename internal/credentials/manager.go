@@ -3,8 +3,11 @@ package credentials
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/adrg/xdg"
 )
@@ -46,6 +49,14 @@ func NewManagerFromFile(path string) *Manager {
 	}
 }
 
+// NewManagerFromDir creates a credential manager that reads per-provider
+// credential files from the given directory instead of the XDG config directory
+func NewManagerFromDir(dir string) *Manager {
+	return &Manager{
+		configDir: dir,
+	}
+}
+
 // ConfigDir returns the configuration directory path
 func (m *Manager) ConfigDir() string {
 	return m.configDir
@@ -209,6 +220,21 @@ func (m *Manager) LoadMiniMax() (*MiniMaxCredentials, error) {
 	return &creds, nil
 }
 
+// LoadGeneric loads credentials for a config-defined provider
+func (m *Manager) LoadGeneric(providerID string) (*GenericCredentials, error) {
+	var creds GenericCredentials
+	if err := m.LoadProvider(providerID, &creds); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// AccountMeta holds the tags and group used to select accounts and roll up their usage
+type AccountMeta struct {
+	Tags  []string `json:"tags,omitempty"`  // e.g. "personal", "team-a"
+	Group string   `json:"group,omitempty"` // e.g. "client-x"
+}
+
 // ClaudeCredentials represents Claude OAuth credentials with multi-account support
 type ClaudeCredentials struct {
 	ClaudeAiOauth *OAuthCredentials         `json:"claudeAiOauth,omitempty"` // Legacy single-account format
@@ -221,6 +247,8 @@ type ClaudeAccount struct {
 	RefreshToken string   `json:"refreshToken"`
 	ExpiresAt    int64    `json:"expiresAt"`
 	Scopes       []string `json:"scopes"`
+	ConfigDir    string   `json:"configDir,omitempty"` // Claude Code config dir (CLAUDE_CONFIG_DIR) using this account
+	AccountMeta
 }
 
 // ToOAuthCredentials converts a ClaudeAccount to OAuthCredentials
@@ -259,6 +287,14 @@ func (c *ClaudeCredentials) GetAccount(accountName string) *OAuthCredentials {
 	return c.ClaudeAiOauth
 }
 
+// Meta returns the tags and group of a multi-account format account
+func (c *ClaudeCredentials) Meta(accountName string) AccountMeta {
+	if acc, ok := c.Accounts[accountName]; ok && acc != nil {
+		return acc.AccountMeta
+	}
+	return AccountMeta{}
+}
+
 // ListAccounts returns all account names for this provider
 func (c *ClaudeCredentials) ListAccounts() []string {
 	if c.Accounts != nil {
@@ -302,6 +338,7 @@ type KimiCredentials struct {
 // KimiAccount represents a single Kimi account's credentials
 type KimiAccount struct {
 	APIKey string `json:"apiKey"`
+	AccountMeta
 }
 
 // GetAccount returns the specified account's credentials, or the default/first available account
@@ -370,6 +407,7 @@ type ZAiCredentials struct {
 // ZAiAccount represents a single Z.AI account's credentials
 type ZAiAccount struct {
 	APIKey string `json:"apiKey"`
+	AccountMeta
 }
 
 // GetAccount returns the specified account's credentials, or the default/first available account
@@ -433,6 +471,7 @@ func (z *ZAiCredentials) Validate() error {
 type MiniMaxCredentials struct {
 	Cookie   string                     `json:"cookie,omitempty"`
 	GroupID  string                     `json:"groupId,omitempty"`
+	APIKey   string                     `json:"apiKey,omitempty"`
 	Accounts map[string]*MiniMaxAccount `json:"accounts,omitempty"`
 }
 
@@ -440,6 +479,8 @@ type MiniMaxCredentials struct {
 type MiniMaxAccount struct {
 	Cookie  string `json:"cookie"`
 	GroupID string `json:"groupId"`
+	APIKey  string `json:"apiKey,omitempty"` // Optional API key passed to tools by llm-usage exec
+	AccountMeta
 }
 
 // GetAccount returns the specified account's credentials, or the default/first available account
@@ -463,7 +504,7 @@ func (m *MiniMaxCredentials) GetAccount(accountName string) *MiniMaxAccount {
 
 	// Fall back to legacy format
 	if m.Cookie != "" {
-		return &MiniMaxAccount{Cookie: m.Cookie, GroupID: m.GroupID}
+		return &MiniMaxAccount{Cookie: m.Cookie, GroupID: m.GroupID, APIKey: m.APIKey}
 	}
 	return nil
 }
@@ -505,6 +546,55 @@ func (m *MiniMaxCredentials) Validate() error {
 	return nil
 }
 
+// GenericCredentials represents credentials for config-defined providers.
+// Each account is a free-form set of string values that provider definitions
+// reference from their request templates (e.g. {{.token}}).
+type GenericCredentials struct {
+	Accounts map[string]GenericAccount `json:"accounts"`
+}
+
+// GenericAccount holds the named values of a single config-defined account
+type GenericAccount map[string]string
+
+// Meta returns the account's tags ("tags", comma-separated) and group ("group")
+func (a GenericAccount) Meta() AccountMeta {
+	meta := AccountMeta{Group: a["group"]}
+	for _, tag := range strings.Split(a["tags"], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			meta.Tags = append(meta.Tags, tag)
+		}
+	}
+	return meta
+}
+
+// GetAccount returns the specified account's values, or the default account,
+// falling back to the first account in name order
+func (g *GenericCredentials) GetAccount(accountName string) GenericAccount {
+	if accountName == "" {
+		if acc, ok := g.Accounts["default"]; ok {
+			return acc
+		}
+		if names := g.ListAccounts(); len(names) > 0 {
+			return g.Accounts[names[0]]
+		}
+		return nil
+	}
+	return g.Accounts[accountName]
+}
+
+// ListAccounts returns all account names for this provider, sorted
+func (g *GenericCredentials) ListAccounts() []string {
+	return slices.Sorted(maps.Keys(g.Accounts))
+}
+
+// Validate checks if the generic credentials are valid
+func (g *GenericCredentials) Validate() error {
+	if len(g.Accounts) == 0 {
+		return fmt.Errorf("no accounts found")
+	}
+	return nil
+}
+
 // SaveProvider saves provider credentials to the config file
 func (m *Manager) SaveProvider(providerID string, data any) error {
 	if err := m.EnsureConfigDir(); err != nil {
@@ -565,7 +655,14 @@ func (m *Manager) ListAccounts(providerID string) ([]string, error) {
 		}
 		return creds.ListAccounts(), nil
 	default:
-		return nil, fmt.Errorf("unknown provider: %s", providerID)
+		if !m.ProviderExists(providerID) && m.credentialsFile == "" {
+			return nil, fmt.Errorf("unknown provider: %s", providerID)
+		}
+		creds, err := m.LoadGeneric(providerID)
+		if err != nil {
+			return nil, err
+		}
+		return creds.ListAccounts(), nil
 	}
 }
 
@@ -0,0 +1,37 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReadSecret resolves a secret given on the command line: "@path" reads the
+// secret from a file, trimming trailing newlines, so it stays out of argv.
+// Any other value is the secret itself.
+func ReadSecret(value string) (string, error) {
+	path, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		return value, nil
+	}
+	data, err := os.ReadFile(ExpandHome(path)) //nolint:gosec
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// SecretEnv returns the environment variable holding a named secret, e.g.
+// LLM_USAGE_PEER_TOKEN_HOME_LAB for prefix LLM_USAGE_PEER_TOKEN and name home-lab
+func SecretEnv(prefix, name string) string {
+	return prefix + "_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
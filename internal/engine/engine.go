@@ -0,0 +1,257 @@
+// Package engine resolves provider accounts and fetches their usage. It
+// backs the public pkg/llmusage client, which the llm-usage CLI uses.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// ErrNoProviders is returned by Fetch when no provider accounts are configured
+var ErrNoProviders = errors.New("no providers configured")
+
+// Account identifies a single provider account resolved by a Client
+type Account struct {
+	Provider string
+	Name     string
+}
+
+// Unwrap converts a pkg/llmusage result back to the provider types. It is set
+// by pkg/llmusage, so the CLI can fetch through the public client without the
+// public API exposing internal types.
+var Unwrap func(stats any) *provider.UsageStats
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithCredentialsDir reads per-provider credential files from dir instead of
+// $XDG_CONFIG_HOME/llm-usage
+func WithCredentialsDir(dir string) Option {
+	return func(c *Client) {
+		c.credsMgr = credentials.NewManagerFromDir(dir)
+	}
+}
+
+// WithCredentialsFile reads credentials from a combined credentials file.
+// Values may reference environment variables using $VAR or ${VAR}.
+func WithCredentialsFile(path string) Option {
+	return func(c *Client) {
+		c.credsMgr = credentials.NewManagerFromFile(path)
+	}
+}
+
+// WithExplicit queries the instances built by accounts instead of any
+// configuration on disk. It is called whenever accounts are resolved, and
+// its instances are still filtered by provider, account and selection.
+func WithExplicit(accounts func() []usage.ProviderInstance) Option {
+	return func(c *Client) {
+		c.explicit = accounts
+	}
+}
+
+// WithProviders restricts queries to the given provider IDs (e.g. "claude", "kimi")
+func WithProviders(ids ...string) Option {
+	return func(c *Client) {
+		c.providers = append(c.providers, ids...)
+	}
+}
+
+// WithAccount restricts queries to the named account
+func WithAccount(name string) Option {
+	return func(c *Client) {
+		c.account = name
+	}
+}
+
+// WithAllAccounts queries every account of each provider even when an account is set
+func WithAllAccounts() Option {
+	return func(c *Client) {
+		c.allAccounts = true
+	}
+}
+
+// WithSelection restricts queries to the accounts matching sel
+func WithSelection(sel usage.Selection) Option {
+	return func(c *Client) {
+		c.selection.Tags = append(c.selection.Tags, sel.Tags...)
+		c.selection.Groups = append(c.selection.Groups, sel.Groups...)
+		c.selection.ExcludeTags = append(c.selection.ExcludeTags, sel.ExcludeTags...)
+	}
+}
+
+// WithRemote fetches usage from a llm-usage server (llm-usage serve) instead of
+// querying providers directly. token is sent as a bearer token and may be empty.
+func WithRemote(baseURL, token string) Option {
+	return func(c *Client) {
+		c.remoteURL = baseURL
+		c.remoteToken = token
+	}
+}
+
+// WithPersistentProviders reuses provider clients across Fetch calls, keeping
+// their HTTP connections warm in long-running programs. Accounts are resolved
+// again after a fetch reports an error, picking up refreshed credentials.
+func WithPersistentProviders() Option {
+	return func(c *Client) {
+		c.persistent = true
+	}
+}
+
+// Client fetches usage statistics from configured provider accounts
+type Client struct {
+	credsMgr    *credentials.Manager
+	explicit    func() []usage.ProviderInstance
+	providers   []string
+	account     string
+	allAccounts bool
+	selection   usage.Selection
+	remoteURL   string
+	remoteToken string
+
+	persistent bool
+	mu         sync.Mutex
+	cached     []usage.ProviderInstance
+	busy       chan struct{} // held while a persistent client's fetch runs
+}
+
+// New creates a Client. Without credential options it uses the same
+// configuration as the llm-usage CLI.
+func New(opts ...Option) (*Client, error) {
+	c := &Client{busy: make(chan struct{}, 1)}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.explicit != nil && c.credsMgr != nil {
+		return nil, fmt.Errorf("WithCredentials cannot be combined with WithCredentialsDir or WithCredentialsFile")
+	}
+	if c.remoteURL != "" && (c.explicit != nil || c.credsMgr != nil) {
+		return nil, fmt.Errorf("WithRemote cannot be combined with local credentials")
+	}
+	if c.credsMgr == nil {
+		c.credsMgr = credentials.NewManager()
+	}
+
+	return c, nil
+}
+
+// Accounts returns the provider accounts that Fetch would query.
+// It returns nil for remote clients, whose accounts are resolved by the server.
+func (c *Client) Accounts() []Account {
+	if c.remoteURL != "" {
+		return nil
+	}
+
+	instances := c.instances()
+	accounts := make([]Account, 0, len(instances))
+	for _, inst := range instances {
+		accounts = append(accounts, Account{Provider: inst.ID(), Name: inst.AccountName})
+	}
+	return accounts
+}
+
+// Fetch queries all resolved provider accounts concurrently.
+// Per-account failures are reported in Usage.Error rather than as an error.
+// When ctx is done first, the providers' requests finish in the background;
+// persistent clients wait for them before reusing the providers.
+func (c *Client) Fetch(ctx context.Context) (*provider.UsageStats, error) {
+	if c.remoteURL != "" {
+		return usage.FetchRemote(ctx, c.remoteURL, c.remoteToken, strings.Join(c.providers, ","), c.account, c.selection)
+	}
+
+	if c.persistent {
+		select {
+		case c.busy <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if c.persistent {
+			<-c.busy
+		}
+	}
+
+	instances := c.fetchInstances()
+	if len(instances) == 0 {
+		release()
+		return nil, ErrNoProviders
+	}
+
+	done := make(chan *provider.UsageStats, 1)
+	go func() {
+		defer release()
+		stats := usage.FetchAllUsage(instances)
+		if c.persistent && slices.ContainsFunc(stats.Providers, func(u provider.Usage) bool { return u.Error != nil }) {
+			c.mu.Lock()
+			c.cached = nil
+			c.mu.Unlock()
+		}
+		done <- stats
+	}()
+
+	select {
+	case stats := <-done:
+		return stats, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchInstances returns the provider instances for Fetch, reusing them
+// between calls for persistent clients
+func (c *Client) fetchInstances() []usage.ProviderInstance {
+	if !c.persistent {
+		return c.instances()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached == nil {
+		c.cached = c.instances()
+	}
+	return c.cached
+}
+
+// instances resolves the provider instances to query
+func (c *Client) instances() []usage.ProviderInstance {
+	if c.explicit != nil {
+		return c.explicitInstances()
+	}
+
+	providerFlag := "all"
+	if len(c.providers) > 0 {
+		providerFlag = strings.Join(c.providers, ",")
+	}
+	return usage.GetProviders(providerFlag, c.account, c.allAccounts, c.credsMgr, c.selection)
+}
+
+// explicitInstances filters the explicitly configured instances
+func (c *Client) explicitInstances() []usage.ProviderInstance {
+	var instances []usage.ProviderInstance
+	for _, inst := range c.explicit() {
+		// Explicit credentials carry no tags or group
+		if c.wants(inst.ID(), inst.AccountName) && c.selection.Match(nil, "") {
+			instances = append(instances, inst)
+		}
+	}
+	return instances
+}
+
+// wants reports whether the provider/account pair passes the client's filters
+func (c *Client) wants(providerID, account string) bool {
+	if len(c.providers) > 0 {
+		if !slices.Contains(c.providers, providerID) {
+			return false
+		}
+	}
+	return c.allAccounts || c.account == "" || c.account == account
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/provider/kimi"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+func TestClient_PersistentProviders(t *testing.T) {
+	creds := func() []usage.ProviderInstance {
+		return []usage.ProviderInstance{{Provider: kimi.NewProvider("k"), AccountName: "default"}}
+	}
+
+	persistent, err := New(WithExplicit(creds), WithPersistentProviders())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if a, b := persistent.fetchInstances(), persistent.fetchInstances(); a[0].Provider != b[0].Provider {
+		t.Error("persistent client should reuse provider instances")
+	}
+
+	client, err := New(WithExplicit(creds))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if a, b := client.fetchInstances(), client.fetchInstances(); a[0].Provider == b[0].Provider {
+		t.Error("client should resolve provider instances on every fetch")
+	}
+}
+
+// blockingProvider reports usage once released and counts concurrent calls
+type blockingProvider struct {
+	release chan struct{}
+
+	mu            sync.Mutex
+	running, peak int
+}
+
+func (p *blockingProvider) Name() string { return "Blocking" }
+func (p *blockingProvider) ID() string   { return "blocking" }
+
+func (p *blockingProvider) GetUsage() (*provider.Usage, error) {
+	p.mu.Lock()
+	p.running++
+	p.peak = max(p.peak, p.running)
+	p.mu.Unlock()
+
+	<-p.release
+
+	p.mu.Lock()
+	p.running--
+	p.mu.Unlock()
+	return &provider.Usage{Provider: "blocking"}, nil
+}
+
+func TestClient_PersistentFetchCancelled(t *testing.T) {
+	p := &blockingProvider{release: make(chan struct{})}
+	client, err := New(WithExplicit(func() []usage.ProviderInstance {
+		return []usage.ProviderInstance{{Provider: p}}
+	}), WithPersistentProviders())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Fetch(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Fetch error = %v, want deadline exceeded", err)
+	}
+
+	// The abandoned fetch still holds the provider
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel2()
+	if _, err := client.Fetch(ctx2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Fetch error = %v, want deadline exceeded", err)
+	}
+
+	close(p.release)
+	if _, err := client.Fetch(context.Background()); err != nil {
+		t.Fatalf("Fetch after release failed: %v", err)
+	}
+	if p.peak != 1 {
+		t.Errorf("peak concurrent GetUsage calls = %d, want 1", p.peak)
+	}
+}
@@ -0,0 +1,171 @@
+// Package launch prepares the environment for running tools under an account
+// and records which account was chosen.
+package launch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/denysvitali/llm-usage/internal/credentials"
+)
+
+// Env returns the environment variables ("KEY=value") that make a tool use
+// the given account. Every provider gets LLM_USAGE_PROVIDER and
+// LLM_USAGE_ACCOUNT; Claude accounts set CLAUDE_CONFIG_DIR when they have a
+// configDir and CLAUDE_CODE_OAUTH_TOKEN otherwise, and API key providers set
+// their API key variables. Providers without credential variables, such as
+// custom providers, are an error rather than a run without credentials.
+func Env(m *credentials.Manager, providerID, account string) ([]string, error) {
+	env := []string{"LLM_USAGE_PROVIDER=" + providerID, "LLM_USAGE_ACCOUNT=" + account}
+
+	switch providerID {
+	case "claude":
+		vars, err := claudeEnv(m, account)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, vars...)
+	case "kimi":
+		creds, err := m.LoadKimi()
+		if err != nil {
+			return nil, err
+		}
+		acc := creds.GetAccount(account)
+		if acc == nil {
+			return nil, fmt.Errorf("kimi account %q not found", account)
+		}
+		env = append(env, "KIMI_API_KEY="+acc.APIKey, "MOONSHOT_API_KEY="+acc.APIKey)
+	case "zai":
+		creds, err := m.LoadZAi()
+		if err != nil {
+			return nil, err
+		}
+		acc := creds.GetAccount(account)
+		if acc == nil {
+			return nil, fmt.Errorf("zai account %q not found", account)
+		}
+		env = append(env, "ZAI_API_KEY="+acc.APIKey)
+	case "minimax":
+		creds, err := m.LoadMiniMax()
+		if err != nil {
+			return nil, err
+		}
+		acc := creds.GetAccount(account)
+		if acc == nil {
+			return nil, fmt.Errorf("minimax account %q not found", account)
+		}
+		// The usage API only needs a cookie; tools need an API key
+		if acc.APIKey == "" {
+			return nil, fmt.Errorf("minimax account %q has no apiKey to pass to the command", account)
+		}
+		env = append(env, "MINIMAX_API_KEY="+acc.APIKey, "MINIMAX_GROUP_ID="+acc.GroupID)
+	default:
+		return nil, fmt.Errorf("no credential environment variables are known for provider %q", providerID)
+	}
+	return env, nil
+}
+
+// claudeEnv points Claude Code at the account's config dir or OAuth token
+func claudeEnv(m *credentials.Manager, account string) ([]string, error) {
+	creds, err := m.LoadClaude()
+	if err == nil {
+		if acc, ok := creds.Accounts[account]; ok {
+			if acc.ConfigDir != "" {
+				return []string{"CLAUDE_CONFIG_DIR=" + credentials.ExpandHome(acc.ConfigDir)}, nil
+			}
+			return []string{"CLAUDE_CODE_OAUTH_TOKEN=" + acc.AccessToken}, nil
+		}
+		if account == "default" && creds.ClaudeAiOauth != nil {
+			return []string{"CLAUDE_CODE_OAUTH_TOKEN=" + creds.ClaudeAiOauth.AccessToken}, nil
+		}
+	}
+
+	// The default account is the one Claude Code keeps in ~/.claude
+	if account == "default" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		return []string{"CLAUDE_CONFIG_DIR=" + filepath.Join(home, ".claude")}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("claude account %q not found", account)
+}
+
+// Merge overrides entries of base ("KEY=value", e.g. os.Environ()) with vars
+func Merge(base, vars []string) []string {
+	keys := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		keys[envKey(v)] = true
+	}
+	out := make([]string, 0, len(base)+len(vars))
+	for _, v := range base {
+		if !keys[envKey(v)] {
+			out = append(out, v)
+		}
+	}
+	return append(out, vars...)
+}
+
+func envKey(v string) string {
+	key, _, _ := strings.Cut(v, "=")
+	return key
+}
+
+// Choice records the account picked to run a command
+type Choice struct {
+	Time        time.Time `json:"time"`
+	Provider    string    `json:"provider"`
+	Account     string    `json:"account"`
+	Window      string    `json:"window,omitempty"`
+	Utilization float64   `json:"utilization"`
+	Forced      bool      `json:"forced,omitempty"`
+	Command     string    `json:"command"` // the program only; arguments may hold prompts or keys
+}
+
+// Log is an append-only JSON Lines history of account choices
+type Log struct {
+	path string
+}
+
+// NewLog returns the log at $XDG_STATE_HOME/llm-usage/exec.jsonl
+func NewLog() *Log {
+	return &Log{path: filepath.Join(xdg.StateHome, "llm-usage", "exec.jsonl")}
+}
+
+// NewLogFromPath returns a log at the given path
+func NewLogFromPath(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the log file path
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Record appends a choice to the log
+func (l *Log) Record(c Choice) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode choice: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to open exec log: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write exec log: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,90 @@
+package launch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "claude.json"), `{"accounts": {
+		"work": {"accessToken": "tok-work", "configDir": "/srv/claude-work"},
+		"home": {"accessToken": "tok-home"}
+	}}`)
+	writeFile(t, filepath.Join(dir, "kimi.json"), `{"accounts": {"main": {"apiKey": "sk-kimi"}}}`)
+	writeFile(t, filepath.Join(dir, "minimax.json"), `{"accounts": {
+		"team": {"cookie": "c", "groupId": "g1", "apiKey": "sk-minimax"},
+		"web": {"cookie": "c", "groupId": "g2"}
+	}}`)
+	m := credentials.NewManagerFromDir(dir)
+
+	tests := []struct {
+		provider string
+		account  string
+		want     string
+	}{
+		{"claude", "work", "CLAUDE_CONFIG_DIR=/srv/claude-work"},
+		{"claude", "home", "CLAUDE_CODE_OAUTH_TOKEN=tok-home"},
+		{"kimi", "main", "KIMI_API_KEY=sk-kimi"},
+		{"minimax", "team", "MINIMAX_API_KEY=sk-minimax"},
+	}
+	for _, tt := range tests {
+		env, err := Env(m, tt.provider, tt.account)
+		if err != nil {
+			t.Fatalf("Env(%s, %s) failed: %v", tt.provider, tt.account, err)
+		}
+		if !slices.Contains(env, tt.want) || !slices.Contains(env, "LLM_USAGE_ACCOUNT="+tt.account) {
+			t.Errorf("Env(%s, %s) = %v, want %s", tt.provider, tt.account, env, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ provider, account string }{
+		{"claude", "missing"},
+		{"minimax", "web"}, // no API key
+		{"acme", "default"},
+	} {
+		if _, err := Env(m, tt.provider, tt.account); err == nil {
+			t.Errorf("Env(%s, %s) should fail", tt.provider, tt.account)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got := Merge([]string{"PATH=/bin", "CLAUDE_CONFIG_DIR=/old", "EMPTY"}, []string{"CLAUDE_CONFIG_DIR=/new"})
+	want := []string{"PATH=/bin", "EMPTY", "CLAUDE_CONFIG_DIR=/new"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Merge() = %v, want %v", got, want)
+	}
+}
+
+func TestLogRecord(t *testing.T) {
+	log := NewLogFromPath(filepath.Join(t.TempDir(), "state", "exec.jsonl"))
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, account := range []string{"work", "home"} {
+		if err := log.Record(Choice{Time: now, Provider: "claude", Account: account, Command: "claude"}); err != nil {
+			t.Fatalf("Record() failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(log.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"account":"home"`) {
+		t.Errorf("log = %q, want two choices ending with home", data)
+	}
+}
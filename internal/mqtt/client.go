@@ -0,0 +1,318 @@
+// Package mqtt implements a minimal MQTT 3.1.1 publisher and Home Assistant discovery
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Packet types (upper nibble of the fixed header)
+const (
+	packetConnect    = 0x10
+	packetConnack    = 0x20
+	packetPublish    = 0x30
+	packetPingreq    = 0xc0
+	packetDisconnect = 0xe0
+)
+
+// Options configures a broker connection
+type Options struct {
+	// Broker is the broker URL: tcp://host:1883, mqtt://, ssl://, tls:// or mqtts://
+	Broker   string
+	ClientID string
+	Username string
+	Password string
+
+	// Will is published retained by the broker when the connection is lost
+	Will *Message
+
+	KeepAlive time.Duration
+}
+
+// Message is an MQTT application message
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// Client is a QoS 0 MQTT publisher
+type Client struct {
+	conn net.Conn
+
+	mu     sync.Mutex
+	closed chan struct{}
+	err    error
+}
+
+// connackErrors describes CONNACK return codes
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Dial connects to the broker and completes the MQTT handshake
+func Dial(ctx context.Context, opts Options) (*Client, error) {
+	u, err := url.Parse(opts.Broker)
+	if err != nil {
+		return nil, fmt.Errorf("invalid broker URL: %w", err)
+	}
+
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	switch u.Scheme {
+	case "tcp", "mqtt":
+		conn, err = dialer.DialContext(ctx, "tcp", hostPort(u, "1883"))
+	case "ssl", "tls", "mqtts":
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", hostPort(u, "8883"))
+	default:
+		return nil, fmt.Errorf("unsupported broker scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to broker: %w", err)
+	}
+
+	if opts.KeepAlive <= 0 {
+		opts.KeepAlive = time.Minute
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	}
+
+	if _, err := conn.Write(connectPacket(opts)); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	header, body, err := readPacket(reader)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to read CONNACK: %w", err)
+	}
+	if header&0xf0 != packetConnack || len(body) != 2 {
+		_ = conn.Close()
+		return nil, fmt.Errorf("unexpected packet 0x%02x instead of CONNACK", header)
+	}
+	if code := body[1]; code != 0 {
+		_ = conn.Close()
+		if msg, ok := connackErrors[code]; ok {
+			return nil, fmt.Errorf("broker refused connection: %s", msg)
+		}
+		return nil, fmt.Errorf("broker refused connection: code %d", code)
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+
+	c := &Client{conn: conn, closed: make(chan struct{})}
+	go c.readLoop(reader)
+	go c.keepAlive(opts.KeepAlive)
+	return c, nil
+}
+
+// hostPort returns the URL's host with a default port
+func hostPort(u *url.URL, defaultPort string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), defaultPort)
+}
+
+// Publish sends a QoS 0 message
+func (c *Client) Publish(msg Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+
+	header := byte(packetPublish)
+	if msg.Retain {
+		header |= 0x01
+	}
+
+	body := appendString(nil, msg.Topic)
+	body = append(body, msg.Payload...)
+
+	if err := c.write(header, body); err != nil {
+		c.err = fmt.Errorf("failed to publish: %w", err)
+		return c.err
+	}
+	return nil
+}
+
+// Err returns the error that broke the connection, if any
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close sends DISCONNECT and closes the connection. The will message is not
+// published after a clean disconnect.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.err == nil {
+		_ = c.write(packetDisconnect, nil)
+		c.err = net.ErrClosed
+	}
+	c.mu.Unlock()
+
+	return c.conn.Close()
+}
+
+// write sends a packet; callers hold c.mu
+func (c *Client) write(header byte, body []byte) error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	packet := append([]byte{header}, encodeLength(len(body))...)
+	_, err := c.conn.Write(append(packet, body...))
+	return err
+}
+
+// readLoop drains incoming packets (PINGRESP) until the connection closes
+func (c *Client) readLoop(r *bufio.Reader) {
+	defer close(c.closed)
+	for {
+		if _, _, err := readPacket(r); err != nil {
+			c.mu.Lock()
+			if c.err == nil {
+				c.err = fmt.Errorf("connection lost: %w", err)
+			}
+			c.mu.Unlock()
+			return
+		}
+	}
+}
+
+// keepAlive pings the broker until the connection closes
+func (c *Client) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			if c.err == nil {
+				if err := c.write(packetPingreq, nil); err != nil {
+					c.err = fmt.Errorf("failed to ping broker: %w", err)
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// connectPacket encodes a CONNECT packet with a clean session
+func connectPacket(opts Options) []byte {
+	flags := byte(0x02)
+	if opts.Will != nil {
+		flags |= 0x04
+		if opts.Will.Retain {
+			flags |= 0x20
+		}
+	}
+	if opts.Username != "" {
+		flags |= 0x80
+		if opts.Password != "" {
+			flags |= 0x40
+		}
+	}
+
+	keepAlive := int(opts.KeepAlive / time.Second)
+	if keepAlive > 0xffff {
+		keepAlive = 0xffff
+	}
+
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags, byte(keepAlive>>8), byte(keepAlive))
+	body = appendString(body, opts.ClientID)
+	if opts.Will != nil {
+		body = appendString(body, opts.Will.Topic)
+		body = appendBytes(body, opts.Will.Payload)
+	}
+	if opts.Username != "" {
+		body = appendString(body, opts.Username)
+		if opts.Password != "" {
+			body = appendString(body, opts.Password)
+		}
+	}
+
+	packet := append([]byte{packetConnect}, encodeLength(len(body))...)
+	return append(packet, body...)
+}
+
+// appendString appends a length-prefixed UTF-8 string
+func appendString(b []byte, s string) []byte {
+	return appendBytes(b, []byte(s))
+}
+
+// appendBytes appends length-prefixed binary data
+func appendBytes(b, data []byte) []byte {
+	b = append(b, byte(len(data)>>8), byte(len(data)))
+	return append(b, data...)
+}
+
+// encodeLength encodes the variable-length "remaining length" field
+func encodeLength(n int) []byte {
+	var out []byte
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			return out
+		}
+	}
+}
+
+// readPacket reads a packet, returning its fixed header byte and body
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
@@ -0,0 +1,177 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// broker is a minimal in-process MQTT broker recording retained messages
+type broker struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	connect  []byte
+	retained map[string]string
+	done     chan struct{}
+}
+
+func newBroker(t *testing.T) *broker {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &broker{listener: l, retained: make(map[string]string), done: make(chan struct{})}
+	go b.serve()
+	t.Cleanup(func() { _ = l.Close() })
+	return b
+}
+
+func (b *broker) url() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *broker) serve() {
+	conn, err := b.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+	defer close(b.done)
+
+	r := bufio.NewReader(conn)
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch header & 0xf0 {
+		case packetConnect:
+			b.mu.Lock()
+			b.connect = body
+			b.mu.Unlock()
+			_, _ = conn.Write([]byte{packetConnack, 2, 0, 0})
+		case packetPublish:
+			n := int(body[0])<<8 | int(body[1])
+			if header&0x01 != 0 {
+				b.mu.Lock()
+				b.retained[string(body[2:2+n])] = string(body[2+n:])
+				b.mu.Unlock()
+			}
+		case packetDisconnect:
+			return
+		}
+	}
+}
+
+func (b *broker) get(topic string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	v, ok := b.retained[topic]
+	return v, ok
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	b := newBroker(t)
+
+	client, err := Dial(context.Background(), Options{
+		Broker:   b.url(),
+		ClientID: "test",
+		Username: "user",
+		Password: "pass",
+		Will:     &Message{Topic: StatusTopic("llm-usage"), Payload: []byte(Offline), Retain: true},
+	})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+
+	resetsAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	stats := &provider.UsageStats{Providers: []provider.Usage{
+		{
+			Provider: "claude",
+			Windows:  []provider.UsageWindow{{Label: "5-Hour", Utilization: 42.5, ResetsAt: &resetsAt}},
+			Extra:    map[string]any{"account": "Work"},
+		},
+		{
+			Provider: "kimi",
+			Error:    errors.New("Kimi: unauthorized"),
+			Extra:    map[string]any{"account": "personal"},
+		},
+	}}
+
+	p := NewPublisher(client, "llm-usage", "homeassistant")
+	if err := p.Publish(stats); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	<-b.done
+
+	want := map[string]string{
+		"llm-usage/status":                         Online,
+		"llm-usage/claude/work/5_hour/utilization": "42.5",
+		"llm-usage/claude/work/5_hour/remaining":   "57.5",
+		"llm-usage/claude/work/5_hour/resets_at":   "2026-01-02T03:04:05Z",
+		"llm-usage/claude/work/availability":       Online,
+		"llm-usage/kimi/personal/availability":     Offline,
+	}
+	for topic, value := range want {
+		if got, _ := b.get(topic); got != value {
+			t.Errorf("%s = %q, want %q", topic, got, value)
+		}
+	}
+
+	raw, ok := b.get("homeassistant/sensor/llm_usage_claude_work_5_hour_utilization/config")
+	if !ok {
+		t.Fatal("missing discovery config")
+	}
+	var config map[string]any
+	if err := json.Unmarshal([]byte(raw), &config); err != nil {
+		t.Fatal(err)
+	}
+	if config["state_topic"] != "llm-usage/claude/work/5_hour/utilization" || config["unit_of_measurement"] != "%" {
+		t.Errorf("discovery config = %v", config)
+	}
+}
+
+func TestConnectPacket(t *testing.T) {
+	packet := connectPacket(Options{
+		ClientID:  "id",
+		Username:  "u",
+		Password:  "p",
+		Will:      &Message{Topic: "t", Payload: []byte("off"), Retain: true},
+		KeepAlive: 30 * time.Second,
+	})
+
+	_, body, err := readPacket(bufio.NewReader(bytes.NewReader(packet)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// protocol name, level, flags: username|password|will retain|will|clean session
+	if string(body[2:6]) != "MQTT" || body[6] != 4 || body[7] != 0xe6 || body[9] != 30 {
+		t.Errorf("unexpected CONNECT header % x", body[:10])
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := map[string]string{
+		"5-Hour":          "5_hour",
+		"Work Account":    "work_account",
+		"  Weekly (Opus)": "weekly_opus",
+	}
+	for in, want := range tests {
+		if got := slug(in); got != want {
+			t.Errorf("slug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
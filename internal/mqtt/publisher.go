@@ -0,0 +1,195 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// Availability payloads
+const (
+	Online  = "online"
+	Offline = "offline"
+)
+
+// Publisher publishes usage as retained topics with Home Assistant discovery
+type Publisher struct {
+	client *Client
+
+	// TopicPrefix is the root of all state topics (e.g. "llm-usage")
+	TopicPrefix string
+
+	// DiscoveryPrefix is Home Assistant's discovery prefix, empty to disable discovery
+	DiscoveryPrefix string
+
+	// NameOf resolves provider IDs to display names
+	NameOf func(string) string
+
+	// announced tracks sensors whose discovery config was already sent
+	announced map[string]bool
+}
+
+// NewPublisher creates a publisher on an established connection
+func NewPublisher(client *Client, topicPrefix, discoveryPrefix string) *Publisher {
+	return &Publisher{
+		client:          client,
+		TopicPrefix:     topicPrefix,
+		DiscoveryPrefix: discoveryPrefix,
+		NameOf:          func(id string) string { return id },
+		announced:       make(map[string]bool),
+	}
+}
+
+// StatusTopic is the bridge availability topic, set offline by the broker via the will message
+func StatusTopic(topicPrefix string) string {
+	return topicPrefix + "/status"
+}
+
+// sensor describes a single Home Assistant sensor
+type sensor struct {
+	metric      string
+	name        string
+	unit        string
+	deviceClass string
+	value       string
+}
+
+// Publish publishes the state of every provider account and window
+func (p *Publisher) Publish(stats *provider.UsageStats) error {
+	if err := p.publish(StatusTopic(p.TopicPrefix), Online); err != nil {
+		return err
+	}
+
+	for _, u := range stats.Providers {
+		account, _ := u.Extra["account"].(string)
+		if account == "" {
+			account = "default"
+		}
+		base := p.TopicPrefix + "/" + slug(u.Provider) + "/" + slug(account)
+
+		// Keep the last values but mark the account unavailable on errors
+		if u.Error != nil {
+			if err := p.publish(base+"/availability", Offline); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, w := range u.Windows {
+			windowTopic := base + "/" + slug(w.Label)
+			for _, s := range windowSensors(w) {
+				if err := p.announce(u.Provider, account, w.Label, base, windowTopic, s); err != nil {
+					return err
+				}
+				if err := p.publish(windowTopic+"/"+s.metric, s.value); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := p.publish(base+"/availability", Online); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// windowSensors returns the sensors published for a usage window
+func windowSensors(w provider.UsageWindow) []sensor {
+	remaining := 100 - w.Utilization
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	resetsAt := "None"
+	if w.ResetsAt != nil {
+		resetsAt = w.ResetsAt.UTC().Format(time.RFC3339)
+	}
+
+	return []sensor{
+		{metric: "utilization", name: "utilization", unit: "%", value: formatFloat(w.Utilization)},
+		{metric: "remaining", name: "remaining", unit: "%", value: formatFloat(remaining)},
+		{metric: "resets_at", name: "reset", deviceClass: "timestamp", value: resetsAt},
+	}
+}
+
+// announce sends a sensor's discovery config once per publisher
+func (p *Publisher) announce(providerID, account, window, base, windowTopic string, s sensor) error {
+	if p.DiscoveryPrefix == "" {
+		return nil
+	}
+
+	objectID := strings.Join([]string{"llm_usage", slug(providerID), slug(account), slug(window), s.metric}, "_")
+	if p.announced[objectID] {
+		return nil
+	}
+
+	providerName := p.NameOf(providerID)
+	config := map[string]any{
+		"name":        window + " " + s.name,
+		"unique_id":   objectID,
+		"object_id":   objectID,
+		"state_topic": windowTopic + "/" + s.metric,
+		"availability": []map[string]string{
+			{"topic": StatusTopic(p.TopicPrefix)},
+			{"topic": base + "/availability"},
+		},
+		"availability_mode": "all",
+		"device": map[string]any{
+			"identifiers":  []string{strings.Join([]string{"llm_usage", slug(providerID), slug(account)}, "_")},
+			"name":         providerName + " (" + account + ")",
+			"manufacturer": "llm-usage",
+			"model":        providerName,
+		},
+	}
+	if s.unit != "" {
+		config["unit_of_measurement"] = s.unit
+		config["state_class"] = "measurement"
+	}
+	if s.deviceClass != "" {
+		config["device_class"] = s.deviceClass
+	}
+
+	payload, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal discovery config: %w", err)
+	}
+
+	topic := p.DiscoveryPrefix + "/sensor/" + objectID + "/config"
+	if err := p.client.Publish(Message{Topic: topic, Payload: payload, Retain: true}); err != nil {
+		return err
+	}
+	p.announced[objectID] = true
+	return nil
+}
+
+// publish sends a retained state message
+func (p *Publisher) publish(topic, value string) error {
+	return p.client.Publish(Message{Topic: topic, Payload: []byte(value), Retain: true})
+}
+
+// formatFloat formats a value with at most one decimal
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+// slug converts a label to a topic- and ID-safe lowercase string
+func slug(s string) string {
+	var b strings.Builder
+	lastUnderscore := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastUnderscore = false
+		} else if !lastUnderscore && b.Len() > 0 {
+			b.WriteByte('_')
+			lastUnderscore = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
@@ -0,0 +1,275 @@
+// Package notify sends usage threshold and reset events to webhooks
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// Event types
+const (
+	EventThreshold = "threshold"
+	EventReset     = "reset"
+	EventExpiry    = "expiry" // a subscription renews or ends soon
+)
+
+// ExpiryNotice is how long before a subscription renews or ends an expiry event fires
+const ExpiryNotice = 3 * 24 * time.Hour
+
+// subscriptionWindow is the Window of expiry events
+const subscriptionWindow = "Subscription"
+
+// Event is a usage change worth notifying about
+type Event struct {
+	Type         string     `json:"type"`
+	Provider     string     `json:"provider"`
+	ProviderName string     `json:"provider_name"`
+	Account      string     `json:"account,omitempty"`
+	Window       string     `json:"window"`
+	Utilization  float64    `json:"utilization"`
+	Level        string     `json:"level"` // "warning" or "critical" for threshold and expiry events
+	ResetsAt     *time.Time `json:"resets_at,omitempty"`
+	Renews       bool       `json:"renews,omitempty"` // expiry events: the subscription renews instead of ending
+
+	// Balance is set on threshold events of a low prepaid balance
+	Balance *provider.Balance `json:"balance,omitempty"`
+	Time    time.Time         `json:"time"`
+}
+
+// Title returns a short event summary
+func (e Event) Title() string {
+	name := e.ProviderName
+	if e.Account != "" {
+		name += " (" + e.Account + ")"
+	}
+	switch e.Type {
+	case EventReset:
+		return name + " " + e.Window + " window reset"
+	case EventExpiry:
+		if e.Renews {
+			return name + " subscription renewing"
+		}
+		return name + " subscription expiring"
+	}
+	if e.Balance != nil {
+		return name + " balance " + e.Level
+	}
+	return fmt.Sprintf("%s %s usage %s", name, e.Window, e.Level)
+}
+
+// Message returns a human-readable event description
+func (e Event) Message() string {
+	if e.Type == EventReset {
+		return fmt.Sprintf("%s: utilization is back to %.1f%%", e.Title(), e.Utilization)
+	}
+	if e.Type == EventExpiry && e.ResetsAt != nil {
+		verb := "expires"
+		if e.Renews {
+			verb = "renews"
+		}
+		if remaining := time.Until(*e.ResetsAt); remaining > 0 {
+			return fmt.Sprintf("%s: %s %s on %s (in %s)", e.Title(), e.Window, verb, e.ResetsAt.Format("2006-01-02"), formatDuration(remaining))
+		}
+		return fmt.Sprintf("%s: %s expired on %s", e.Title(), e.Window, e.ResetsAt.Format("2006-01-02"))
+	}
+	if e.Balance != nil {
+		return fmt.Sprintf("%s: %.2f %s left", e.Title(), e.Balance.Amount, e.Balance.Currency)
+	}
+	msg := fmt.Sprintf("%s: %.1f%% used", e.Title(), e.Utilization)
+	if e.ResetsAt != nil {
+		msg += fmt.Sprintf(", resets in %s", formatDuration(time.Until(*e.ResetsAt)))
+	}
+	return msg
+}
+
+// formatDuration formats a duration as "2h15m" style text
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Round(time.Minute)
+	if d >= 24*time.Hour {
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// windowState is the last seen state of a usage window
+type windowState struct {
+	Level    string     `json:"level"`
+	ResetsAt *time.Time `json:"resets_at,omitempty"`
+}
+
+// Detector turns successive usage results into events. Its state is
+// persisted so one-shot CLI runs don't repeat notifications.
+type Detector struct {
+	path string
+
+	mu    sync.Mutex
+	state map[string]windowState
+}
+
+// NewDetector creates a detector persisting its state to path (in memory only if empty)
+func NewDetector(path string) *Detector {
+	d := &Detector{path: path, state: make(map[string]windowState)}
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil { //nolint:gosec
+			_ = json.Unmarshal(data, &d.state)
+		}
+	}
+	return d
+}
+
+// Detect compares stats with the previous results and returns new events.
+// Threshold events fire when a window's level rises; reset events fire when
+// a window that had reached a threshold passed its previous reset time.
+func (d *Detector) Detect(stats *provider.UsageStats, nameOf func(string) string) []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	var events []Event
+
+	for _, u := range stats.Providers {
+		if u.Error != nil {
+			continue
+		}
+		account, _ := u.Extra["account"].(string)
+
+		for _, w := range u.Windows {
+			key := u.Provider + "/" + account + "/" + w.Label
+			prev, seen := d.state[key]
+			level := provider.ClassFor(u.Provider, account, w.Label, w.Utilization)
+
+			event := Event{
+				Provider:     u.Provider,
+				ProviderName: nameOf(u.Provider),
+				Account:      account,
+				Window:       w.Label,
+				Utilization:  w.Utilization,
+				Level:        level,
+				ResetsAt:     w.ResetsAt,
+				Time:         now,
+			}
+
+			switch {
+			case seen && prev.Level != "normal" && prev.ResetsAt != nil && now.After(*prev.ResetsAt) &&
+				(w.ResetsAt == nil || !w.ResetsAt.Equal(*prev.ResetsAt)):
+				event.Type = EventReset
+				events = append(events, event)
+			case levelRank(level) > levelRank(prev.Level):
+				event.Type = EventThreshold
+				events = append(events, event)
+			}
+
+			d.state[key] = windowState{Level: level, ResetsAt: w.ResetsAt}
+		}
+
+		if event, ok := d.detectBalance(u, account, nameOf, now); ok {
+			events = append(events, event)
+		}
+		if event, ok := d.detectExpiry(u, account, nameOf, now); ok {
+			events = append(events, event)
+		}
+	}
+
+	d.save()
+	return events
+}
+
+// detectBalance returns a threshold event when the class of a prepaid
+// balance rises. Topping up resets it, so the next drop notifies again.
+func (d *Detector) detectBalance(u provider.Usage, account string, nameOf func(string) string, now time.Time) (Event, bool) {
+	if u.Balance == nil {
+		return Event{}, false
+	}
+
+	key := u.Provider + "/" + account + "/" + provider.BalanceLabel
+	prev := d.state[key]
+	level := u.Balance.Class(u.Provider, account)
+	d.state[key] = windowState{Level: level}
+	if levelRank(level) <= levelRank(prev.Level) {
+		return Event{}, false
+	}
+
+	return Event{
+		Type:         EventThreshold,
+		Provider:     u.Provider,
+		ProviderName: nameOf(u.Provider),
+		Account:      account,
+		Window:       provider.BalanceLabel,
+		Utilization:  u.Balance.Utilization(),
+		Level:        level,
+		Balance:      u.Balance,
+		Time:         now,
+	}, true
+}
+
+// detectExpiry returns an expiry event once per billing period, when the
+// subscription renews or ends within ExpiryNotice
+func (d *Detector) detectExpiry(u provider.Usage, account string, nameOf func(string) string, now time.Time) (Event, bool) {
+	sub := u.Subscription
+	if sub == nil || !sub.Expiring(now, ExpiryNotice) {
+		return Event{}, false
+	}
+
+	key := u.Provider + "/" + account + "/" + subscriptionWindow
+	if prev, seen := d.state[key]; seen && prev.ResetsAt != nil && prev.ResetsAt.Equal(*sub.ExpiresAt) {
+		return Event{}, false
+	}
+
+	level := "warning"
+	if sub.ExpiresAt.Before(now) {
+		level = "critical"
+	}
+	d.state[key] = windowState{Level: level, ResetsAt: sub.ExpiresAt}
+
+	window := sub.Plan
+	if window == "" {
+		window = subscriptionWindow
+	}
+	return Event{
+		Type:         EventExpiry,
+		Provider:     u.Provider,
+		ProviderName: nameOf(u.Provider),
+		Account:      account,
+		Window:       window,
+		Level:        level,
+		ResetsAt:     sub.ExpiresAt,
+		Renews:       sub.Renews(),
+		Time:         now,
+	}, true
+}
+
+// save writes the detector state, ignoring errors
+func (d *Detector) save() {
+	if d.path == "" {
+		return
+	}
+	data, err := json.Marshal(d.state)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(d.path), 0700); err != nil {
+		return
+	}
+	_ = os.WriteFile(d.path, data, 0600)
+}
+
+// levelRank orders utilization levels
+func levelRank(level string) int {
+	switch level {
+	case "critical":
+		return 2
+	case "warning":
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,179 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// Delivery retry settings
+const (
+	maxAttempts  = 3
+	retryBackoff = time.Second
+)
+
+// StateDir is where notification state and the delivery log are kept
+func StateDir() string {
+	return filepath.Join(xdg.StateHome, "llm-usage")
+}
+
+// Delivery is a delivery log entry
+type Delivery struct {
+	Time     time.Time `json:"time"`
+	Webhook  string    `json:"webhook"`
+	Event    Event     `json:"event"`
+	Attempts int       `json:"attempts"`
+	Status   int       `json:"status,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Notifier detects usage events and delivers them to webhooks
+type Notifier struct {
+	webhooks   []*Webhook
+	detector   *Detector
+	logPath    string
+	httpClient *http.Client
+	backoff    time.Duration
+	nameOf     func(string) string
+
+	logMu sync.Mutex
+}
+
+// Option configures a Notifier
+type Option func(*Notifier)
+
+// WithStatePath persists threshold state to path instead of the default state directory
+func WithStatePath(path string) Option {
+	return func(n *Notifier) {
+		n.detector = NewDetector(path)
+	}
+}
+
+// WithLogPath appends delivery log entries to path instead of the default state directory
+func WithLogPath(path string) Option {
+	return func(n *Notifier) {
+		n.logPath = path
+	}
+}
+
+// WithProviderNames resolves provider IDs to display names in messages
+func WithProviderNames(nameOf func(string) string) Option {
+	return func(n *Notifier) {
+		n.nameOf = nameOf
+	}
+}
+
+// New creates a notifier delivering to the given webhooks
+func New(webhooks []*Webhook, opts ...Option) *Notifier {
+	n := &Notifier{
+		webhooks:   webhooks,
+		logPath:    filepath.Join(StateDir(), "notify.log"),
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		backoff:    retryBackoff,
+		nameOf:     func(id string) string { return id },
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	if n.detector == nil {
+		n.detector = NewDetector(filepath.Join(StateDir(), "notify-state.json"))
+	}
+	return n
+}
+
+// Process detects events in stats and delivers them to every webhook
+func (n *Notifier) Process(ctx context.Context, stats *provider.UsageStats) {
+	for _, e := range n.detector.Detect(stats, n.nameOf) {
+		for _, w := range n.webhooks {
+			n.deliver(ctx, w, e)
+		}
+	}
+}
+
+// deliver sends an event to a webhook with retries and logs the outcome
+func (n *Notifier) deliver(ctx context.Context, w *Webhook, e Event) {
+	d := Delivery{Webhook: w.String(), Event: e}
+
+retry:
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		d.Attempts = attempt
+		status, err := n.send(ctx, w, e)
+		d.Status = status
+		if err == nil {
+			d.Error = ""
+			break
+		}
+		d.Error = err.Error()
+
+		// Client errors other than rate limiting won't succeed on retry
+		if status >= 400 && status < 500 && status != http.StatusTooManyRequests {
+			break
+		}
+		if attempt == maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			break retry
+		case <-time.After(n.backoff << (attempt - 1)):
+		}
+	}
+
+	d.Time = time.Now()
+	if d.Error != "" {
+		log.Printf("Failed to deliver %s notification to %s: %s", e.Type, w, d.Error)
+	}
+	n.appendLog(d)
+}
+
+// send performs a single delivery attempt
+func (n *Notifier) send(ctx context.Context, w *Webhook, e Event) (int, error) {
+	req, err := w.request(ctx, e)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// appendLog appends a delivery to the JSON lines delivery log
+func (n *Notifier) appendLog(d Delivery) {
+	if n.logPath == "" {
+		return
+	}
+
+	n.logMu.Lock()
+	defer n.logMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(n.logPath), 0700); err != nil {
+		return
+	}
+	f, err := os.OpenFile(n.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) //nolint:gosec
+	if err != nil {
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	_ = json.NewEncoder(f).Encode(d)
+}
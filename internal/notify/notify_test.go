@@ -0,0 +1,247 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func statsWith(util float64, resetsAt time.Time) *provider.UsageStats {
+	return &provider.UsageStats{Providers: []provider.Usage{{
+		Provider: "claude",
+		Windows:  []provider.UsageWindow{{Label: "5-Hour", Utilization: util, ResetsAt: &resetsAt}},
+		Extra:    map[string]any{"account": "work"},
+	}}}
+}
+
+func TestDetector_Detect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	nameOf := func(id string) string { return id }
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Minute)
+
+	steps := []struct {
+		name  string
+		stats *provider.UsageStats
+		want  []string
+	}{
+		{"normal", statsWith(10, future), nil},
+		{"warning", statsWith(80, future), []string{EventThreshold + ":warning"}},
+		{"still warning", statsWith(85, future), nil},
+		{"critical", statsWith(95, past), []string{EventThreshold + ":critical"}},
+		{"reset", statsWith(0, future), []string{EventReset + ":normal"}},
+		{"normal again", statsWith(5, future), nil},
+	}
+
+	for _, step := range steps {
+		// A fresh detector per step checks that state survives across runs
+		events := NewDetector(path).Detect(step.stats, nameOf)
+		var got []string
+		for _, e := range events {
+			got = append(got, e.Type+":"+e.Level)
+		}
+		if len(got) != len(step.want) || (len(got) > 0 && got[0] != step.want[0]) {
+			t.Errorf("%s: events = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestDetector_Expiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	nameOf := func(id string) string { return id }
+	statsExpiring := func(expiresAt time.Time) *provider.UsageStats {
+		return &provider.UsageStats{Providers: []provider.Usage{{
+			Provider:     "kimi",
+			Subscription: &provider.Subscription{Plan: "Moderato", Status: provider.SubscriptionCancelled, ExpiresAt: &expiresAt},
+			Extra:        map[string]any{"account": "work"},
+		}}}
+	}
+	soon := time.Now().Add(48 * time.Hour)
+
+	steps := []struct {
+		name  string
+		stats *provider.UsageStats
+		want  int
+	}{
+		{"far away", statsExpiring(time.Now().Add(30 * 24 * time.Hour)), 0},
+		{"expiring", statsExpiring(soon), 1},
+		{"already notified", statsExpiring(soon), 0},
+		{"next period", statsExpiring(soon.Add(time.Hour)), 1},
+	}
+	for _, step := range steps {
+		events := NewDetector(path).Detect(step.stats, nameOf)
+		if len(events) != step.want {
+			t.Fatalf("%s: %d events, want %d", step.name, len(events), step.want)
+		}
+		if len(events) > 0 {
+			e := events[0]
+			if e.Type != EventExpiry || e.Renews || e.Title() != "kimi (work) subscription expiring" {
+				t.Errorf("%s: event = %+v, title %q", step.name, e, e.Title())
+			}
+		}
+	}
+}
+
+func TestDetector_Balance(t *testing.T) {
+	nameOf := func(id string) string { return id }
+	statsWithBalance := func(amount float64) *provider.UsageStats {
+		return &provider.UsageStats{Providers: []provider.Usage{{
+			Provider: "deepseek",
+			Balance:  &provider.Balance{Amount: amount, Currency: "USD"},
+		}}}
+	}
+
+	d := NewDetector("")
+	steps := []struct {
+		name   string
+		amount float64
+		want   int
+	}{
+		{"funded", 10, 0},
+		{"exhausted", 0, 1},
+		{"still exhausted", 0, 0},
+		{"topped up", 20, 0},
+		{"exhausted again", -1, 1},
+	}
+	for _, step := range steps {
+		events := d.Detect(statsWithBalance(step.amount), nameOf)
+		if len(events) != step.want {
+			t.Fatalf("%s: %d events, want %d", step.name, len(events), step.want)
+		}
+		if len(events) > 0 && events[0].Message() != fmt.Sprintf("deepseek balance critical: %.2f USD left", step.amount) {
+			t.Errorf("%s: Message() = %q", step.name, events[0].Message())
+		}
+	}
+}
+
+// receiver is a local webhook endpoint recording requests
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, string(body))
+}
+
+func newTestNotifier(t *testing.T, webhooks ...*Webhook) (*Notifier, string) {
+	t.Helper()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "notify.log")
+	n := New(webhooks, WithStatePath(filepath.Join(dir, "state.json")), WithLogPath(logPath))
+	n.backoff = time.Millisecond
+	return n, logPath
+}
+
+func TestNotifier_Payloads(t *testing.T) {
+	recv := &receiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	tmpl, err := ParseTemplate(`{"msg": {{ json .Title }}, "util": {{ .Utilization }}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	webhooks := []*Webhook{
+		{Kind: KindSlack, URL: srv.URL},
+		{Kind: KindDiscord, URL: srv.URL},
+		{Kind: KindGotify, URL: srv.URL},
+		{Kind: KindNtfy, URL: srv.URL},
+		{Kind: KindGeneric, URL: srv.URL, Template: tmpl},
+	}
+	n, _ := newTestNotifier(t, webhooks...)
+	n.Process(context.Background(), statsWith(92, time.Now().Add(time.Hour)))
+
+	if len(recv.bodies) != len(webhooks) {
+		t.Fatalf("got %d deliveries, want %d", len(recv.bodies), len(webhooks))
+	}
+
+	var slack map[string]string
+	if err := json.Unmarshal([]byte(recv.bodies[0]), &slack); err != nil || slack["text"] == "" {
+		t.Errorf("slack payload = %s", recv.bodies[0])
+	}
+	var discord map[string]string
+	if err := json.Unmarshal([]byte(recv.bodies[1]), &discord); err != nil || discord["content"] == "" {
+		t.Errorf("discord payload = %s", recv.bodies[1])
+	}
+	var gotify map[string]any
+	if err := json.Unmarshal([]byte(recv.bodies[2]), &gotify); err != nil || gotify["priority"] != float64(8) {
+		t.Errorf("gotify payload = %s", recv.bodies[2])
+	}
+	if recv.requests[3].Header.Get("Priority") != "high" || recv.requests[3].Header.Get("Title") == "" {
+		t.Errorf("ntfy headers = %v", recv.requests[3].Header)
+	}
+	var generic map[string]any
+	if err := json.Unmarshal([]byte(recv.bodies[4]), &generic); err != nil || generic["util"] != float64(92) {
+		t.Errorf("generic payload = %s", recv.bodies[4])
+	}
+}
+
+func TestNotifier_RetryAndLog(t *testing.T) {
+	recv := &receiver{failures: 2}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	n, logPath := newTestNotifier(t, &Webhook{Kind: KindSlack, URL: srv.URL})
+	n.Process(context.Background(), statsWith(80, time.Now().Add(time.Hour)))
+
+	if len(recv.bodies) != 1 {
+		t.Fatalf("got %d successful deliveries, want 1", len(recv.bodies))
+	}
+
+	f, err := os.Open(logPath) //nolint:gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []Delivery
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var d Delivery
+		if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, d)
+	}
+
+	if len(entries) != 1 || entries[0].Attempts != 3 || entries[0].Error != "" || entries[0].Status != http.StatusOK {
+		t.Errorf("log entries = %+v, want one successful delivery after 3 attempts", entries)
+	}
+}
+
+func TestParseWebhook(t *testing.T) {
+	w, err := ParseWebhook("ntfy=https://ntfy.sh/topic")
+	if err != nil || w.Kind != KindNtfy || w.URL != "https://ntfy.sh/topic" {
+		t.Errorf("ParseWebhook = %+v, %v", w, err)
+	}
+	for _, spec := range []string{"https://example.com", "teams=https://example.com", "slack="} {
+		if _, err := ParseWebhook(spec); err == nil {
+			t.Errorf("ParseWebhook(%q) succeeded, want error", spec)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Webhook kinds with built-in payload templates
+const (
+	KindSlack   = "slack"
+	KindDiscord = "discord"
+	KindNtfy    = "ntfy"
+	KindGotify  = "gotify"
+	KindGeneric = "generic"
+)
+
+// Webhook is a notification endpoint
+type Webhook struct {
+	Kind     string
+	URL      string
+	Template *template.Template // used by generic webhooks
+}
+
+// String returns a log-friendly webhook name without the URL, which may contain secrets
+func (w *Webhook) String() string {
+	return w.Kind
+}
+
+// ParseWebhook parses a kind=url specification
+func ParseWebhook(spec string) (*Webhook, error) {
+	kind, url, ok := strings.Cut(spec, "=")
+	if !ok || url == "" {
+		return nil, fmt.Errorf("invalid webhook %q, expected kind=url", spec)
+	}
+
+	switch kind {
+	case KindSlack, KindDiscord, KindNtfy, KindGotify, KindGeneric:
+	default:
+		return nil, fmt.Errorf("unknown webhook kind %q (supported: slack, discord, ntfy, gotify, generic)", kind)
+	}
+
+	return &Webhook{Kind: kind, URL: url}, nil
+}
+
+// templateFuncs are available in generic webhook templates
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// defaultGenericTemplate posts the event as JSON
+const defaultGenericTemplate = `{{ json . }}`
+
+// ParseTemplate parses a generic webhook payload template. The template is
+// executed with an Event and may use {{ json .Field }} to escape values.
+func ParseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("webhook").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// LoadTemplate reads and parses a generic webhook payload template file
+func LoadTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook template: %w", err)
+	}
+	return ParseTemplate(string(data))
+}
+
+// request builds the HTTP request delivering an event to the webhook
+func (w *Webhook) request(ctx context.Context, e Event) (*http.Request, error) {
+	var body []byte
+	var err error
+	contentType := "application/json"
+
+	switch w.Kind {
+	case KindSlack:
+		body, err = json.Marshal(map[string]string{"text": e.Message()})
+	case KindDiscord:
+		body, err = json.Marshal(map[string]string{"content": e.Message()})
+	case KindGotify:
+		priority := 5
+		if e.Level == "critical" && e.Type == EventThreshold {
+			priority = 8
+		}
+		body, err = json.Marshal(map[string]any{
+			"title":    e.Title(),
+			"message":  e.Message(),
+			"priority": priority,
+		})
+	case KindNtfy:
+		body = []byte(e.Message())
+		contentType = "text/plain"
+	default:
+		tmpl := w.Template
+		if tmpl == nil {
+			tmpl, err = ParseTemplate(defaultGenericTemplate)
+			if err != nil {
+				return nil, err
+			}
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, e); err != nil {
+			return nil, fmt.Errorf("failed to render webhook template: %w", err)
+		}
+		body = buf.Bytes()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	if w.Kind == KindNtfy {
+		req.Header.Set("Title", e.Title())
+		switch {
+		case e.Type == EventReset:
+			req.Header.Set("Tags", "white_check_mark")
+		case e.Type == EventExpiry:
+			req.Header.Set("Tags", "calendar")
+		case e.Level == "critical":
+			req.Header.Set("Priority", "high")
+			req.Header.Set("Tags", "rotating_light")
+		default:
+			req.Header.Set("Tags", "warning")
+		}
+	}
+
+	return req, nil
+}
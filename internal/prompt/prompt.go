@@ -0,0 +1,111 @@
+// Package prompt renders usage for shell prompts and generates prompt snippets.
+package prompt
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// Shells supported by Wrap and Snippet
+var Shells = []string{"bash", "zsh", "fish", "starship"}
+
+// ANSI colors by class
+var classANSI = map[string]string{
+	"normal":   "\033[32m",
+	"warning":  "\033[33m",
+	"critical": "\033[31m",
+}
+
+const ansiReset = "\033[0m"
+
+// Filter restricts the usage shown in the prompt
+type Filter struct {
+	Providers []string // provider IDs, empty for all
+	Account   string   // account name, empty for all
+}
+
+// Match reports whether a provider account passes the filter
+func (f Filter) Match(u provider.Usage) bool {
+	if len(f.Providers) > 0 && !slices.Contains(f.Providers, u.Provider) {
+		return false
+	}
+	account, _ := u.Extra["account"].(string)
+	return f.Account == "" || account == f.Account
+}
+
+// Apply returns the usage stats passing the filter, skipping failed fetches
+func (f Filter) Apply(stats *provider.UsageStats) *provider.UsageStats {
+	out := &provider.UsageStats{}
+	for _, u := range stats.Providers {
+		if u.Error == nil && f.Match(u) {
+			out.Providers = append(out.Providers, u)
+		}
+	}
+	return out
+}
+
+// Render returns the compact prompt text (e.g. "C:42% K:10%"), colored by
+// severity for the given shell, or uncolored when shell is empty
+func Render(stats *provider.UsageStats, shell string) string {
+	summary := usage.Summarize(stats, usage.BarView{})
+	if summary.Text == "" || shell == "" {
+		return summary.Text
+	}
+	return Wrap(shell, classANSI[summary.Class]) + summary.Text + Wrap(shell, ansiReset)
+}
+
+// Wrap marks an escape sequence as zero-width for the shell's line editor
+func Wrap(shell, seq string) string {
+	switch shell {
+	case "zsh":
+		return "%{" + seq + "%}"
+	case "bash":
+		// Readline's RL_PROMPT_START_IGNORE and RL_PROMPT_END_IGNORE
+		return "\001" + seq + "\002"
+	default:
+		return seq
+	}
+}
+
+// Snippet returns the shell configuration that adds usage to the prompt
+func Snippet(shell, exe string) (string, error) {
+	switch shell {
+	case "bash":
+		return fmt.Sprintf(`# llm-usage: add to ~/.bashrc
+__llm_usage_prompt() { %[1]s prompt --shell bash 2>/dev/null; }
+PS1='$(__llm_usage_prompt) '"$PS1"
+`, exe), nil
+	case "zsh":
+		return fmt.Sprintf(`# llm-usage: add to ~/.zshrc
+setopt prompt_subst
+__llm_usage_prompt() { %[1]s prompt --shell zsh 2>/dev/null }
+RPROMPT='$(__llm_usage_prompt)'"$RPROMPT"
+`, exe), nil
+	case "fish":
+		return fmt.Sprintf(`# llm-usage: save as ~/.config/fish/functions/fish_right_prompt.fish
+# (or call __llm_usage_prompt from your existing right prompt)
+function __llm_usage_prompt
+    %[1]s prompt --shell fish 2>/dev/null
+end
+
+function fish_right_prompt
+    __llm_usage_prompt
+end
+`, exe), nil
+	case "starship":
+		return fmt.Sprintf(`# llm-usage: add to ~/.config/starship.toml
+[custom.llm_usage]
+command = "%[1]s prompt"
+when = true
+shell = ["sh"]
+format = "[$output]($style) "
+style = "bold yellow"
+`, strings.ReplaceAll(exe, `"`, `\"`)), nil
+	default:
+		return "", fmt.Errorf("unsupported shell %q (supported: %s)", shell, strings.Join(Shells, ", "))
+	}
+}
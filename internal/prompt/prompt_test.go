@@ -0,0 +1,58 @@
+package prompt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func sampleStats() *provider.UsageStats {
+	return &provider.UsageStats{Providers: []provider.Usage{
+		{Provider: "claude", Extra: map[string]any{"account": "work"}, Windows: []provider.UsageWindow{{Label: "5-Hour", Utilization: 80}}},
+		{Provider: "claude", Extra: map[string]any{"account": "home"}, Windows: []provider.UsageWindow{{Label: "5-Hour", Utilization: 10}}},
+		{Provider: "kimi", Error: errors.New("unauthorized")},
+		{Provider: "zai", Windows: []provider.UsageWindow{{Label: "Tokens", Utilization: 5}}},
+	}}
+}
+
+func TestFilterRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		shell  string
+		want   string
+	}{
+		{"all", Filter{}, "", "C:80% C:10% Z:5%"},
+		{"provider", Filter{Providers: []string{"zai"}}, "", "Z:5%"},
+		{"account", Filter{Account: "home"}, "", "C:10%"},
+		{"none", Filter{Providers: []string{"kimi"}}, "zsh", ""},
+		{"zsh", Filter{Account: "work"}, "zsh", "%{\033[33m%}C:80%%{\033[0m%}"},
+		{"bash", Filter{Account: "home"}, "bash", "\001\033[32m\002C:10%\001\033[0m\002"},
+		{"fish", Filter{Account: "home"}, "fish", "\033[32mC:10%\033[0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.filter.Apply(sampleStats()), tt.shell); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	for _, shell := range Shells {
+		snippet, err := Snippet(shell, "/opt/bin/llm-usage")
+		if err != nil {
+			t.Fatalf("Snippet(%s) failed: %v", shell, err)
+		}
+		if !strings.Contains(snippet, "/opt/bin/llm-usage prompt") {
+			t.Errorf("Snippet(%s) doesn't run the prompt command:\n%s", shell, snippet)
+		}
+	}
+
+	if _, err := Snippet("tcsh", "llm-usage"); err == nil {
+		t.Error("Snippet(tcsh) should fail")
+	}
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"sync"
+	"time"
+)
+
+// BalanceLabel is the window label balances use for thresholds and notifications
+const BalanceLabel = "Balance"
+
+// Balance is a prepaid currency or credit balance
+type Balance struct {
+	Amount    float64    `json:"amount"`               // Spendable amount left
+	Currency  string     `json:"currency,omitempty"`   // ISO 4217 code, e.g. "USD", or "credits"
+	Granted   *float64   `json:"granted,omitempty"`    // Free credits granted
+	ToppedUp  *float64   `json:"topped_up,omitempty"`  // Credits bought
+	Used      *float64   `json:"used,omitempty"`       // Credits spent
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // When the granted credits expire
+}
+
+// Total returns the granted plus topped-up credits, or 0 when unknown
+func (b *Balance) Total() float64 {
+	var total float64
+	if b.Granted != nil {
+		total += *b.Granted
+	}
+	if b.ToppedUp != nil {
+		total += *b.ToppedUp
+	}
+	return total
+}
+
+// Utilization returns the spent share of the total credits as a percentage.
+// Without a known total, an exhausted balance counts as 100% and any other as 0%.
+func (b *Balance) Utilization() float64 {
+	total := b.Total()
+	switch {
+	case total > 0:
+		return max(0, min(100, (total-b.Amount)/total*100))
+	case b.Amount <= 0:
+		return 100
+	default:
+		return 0
+	}
+}
+
+// Class returns the balance's class: "critical" once exhausted or at the
+// critical low-balance amount, "warning" at the warning amount, and the
+// utilization class of the BalanceLabel window when the total is known
+func (b *Balance) Class(providerID, account string) string {
+	class := "normal"
+	low := LowBalanceFor(providerID, account)
+	switch {
+	case b.Amount <= 0 || b.Amount <= low.Critical:
+		class = "critical"
+	case b.Amount <= low.Warning:
+		class = "warning"
+	}
+	if b.Total() > 0 {
+		class = WorseClass(class, ClassFor(providerID, account, BalanceLabel, b.Utilization()))
+	}
+	return class
+}
+
+var (
+	lowBalanceMu   sync.RWMutex
+	lowBalanceFunc func(providerID, account string) Thresholds
+)
+
+// SetLowBalance installs a lookup of the per provider and account balance
+// amounts at or below which a balance is "warning" or "critical", e.g. from
+// the config file. nil restores the default of no low-balance amounts.
+func SetLowBalance(fn func(providerID, account string) Thresholds) {
+	lowBalanceMu.Lock()
+	defer lowBalanceMu.Unlock()
+	lowBalanceFunc = fn
+}
+
+// LowBalanceFor returns the low-balance amounts of a provider account
+func LowBalanceFor(providerID, account string) Thresholds {
+	lowBalanceMu.RLock()
+	fn := lowBalanceFunc
+	lowBalanceMu.RUnlock()
+	if fn == nil {
+		return Thresholds{}
+	}
+	return fn(providerID, account)
+}
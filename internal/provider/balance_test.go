@@ -0,0 +1,33 @@
+package provider
+
+import "testing"
+
+func TestBalanceClass(t *testing.T) {
+	t.Cleanup(func() { SetLowBalance(nil) })
+	f := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name    string
+		balance Balance
+		low     Thresholds
+		want    string
+	}{
+		{"plenty", Balance{Amount: 50}, Thresholds{}, "normal"},
+		{"exhausted", Balance{Amount: 0}, Thresholds{}, "critical"},
+		{"low warning", Balance{Amount: 4}, Thresholds{Warning: 5, Critical: 1}, "warning"},
+		{"low critical", Balance{Amount: 0.5}, Thresholds{Warning: 5, Critical: 1}, "critical"},
+		{"mostly spent", Balance{Amount: 2, Granted: f(10), ToppedUp: f(10)}, Thresholds{}, "critical"},
+		{"partly spent", Balance{Amount: 4, Granted: f(10), ToppedUp: f(10)}, Thresholds{}, "warning"},
+	}
+	for _, tt := range tests {
+		SetLowBalance(func(string, string) Thresholds { return tt.low })
+		if got := tt.balance.Class("deepseek", ""); got != tt.want {
+			t.Errorf("%s: Class() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	u := Usage{Provider: "deepseek", Balance: &Balance{Amount: 0}, Windows: []UsageWindow{{Label: "Daily", Utilization: 10}}}
+	if got := u.Class(); got != "critical" {
+		t.Errorf("Usage.Class() = %q with an exhausted balance, want critical", got)
+	}
+}
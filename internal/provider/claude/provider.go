@@ -6,6 +6,12 @@ import (
 	"github.com/denysvitali/llm-usage/internal/provider"
 )
 
+// Claude window lengths
+const (
+	fiveHours = 5 * time.Hour
+	sevenDays = 7 * 24 * time.Hour
+)
+
 // Provider implements the provider.Provider interface for Claude
 type Provider struct {
 	client *Client
@@ -38,51 +44,27 @@ func (p *Provider) GetUsage() (*provider.Usage, error) {
 	windows := make([]provider.UsageWindow, 0)
 
 	if usage.FiveHour != nil {
-		windows = append(windows, provider.UsageWindow{
-			Label:       "5-Hour",
-			Utilization: usage.FiveHour.Utilization,
-			ResetsAt:    usage.FiveHour.ResetsAt,
-		})
+		windows = append(windows, toWindow("5-Hour", usage.FiveHour, fiveHours, ""))
 	}
 
 	if usage.SevenDay != nil {
-		windows = append(windows, provider.UsageWindow{
-			Label:       "7-Day",
-			Utilization: usage.SevenDay.Utilization,
-			ResetsAt:    usage.SevenDay.ResetsAt,
-		})
+		windows = append(windows, toWindow("7-Day", usage.SevenDay, sevenDays, ""))
 	}
 
 	if usage.SevenDaySonnet != nil {
-		windows = append(windows, provider.UsageWindow{
-			Label:       "7-Day Sonnet",
-			Utilization: usage.SevenDaySonnet.Utilization,
-			ResetsAt:    usage.SevenDaySonnet.ResetsAt,
-		})
+		windows = append(windows, toWindow("7-Day Sonnet", usage.SevenDaySonnet, sevenDays, "sonnet"))
 	}
 
 	if usage.SevenDayOpus != nil {
-		windows = append(windows, provider.UsageWindow{
-			Label:       "7-Day Opus",
-			Utilization: usage.SevenDayOpus.Utilization,
-			ResetsAt:    usage.SevenDayOpus.ResetsAt,
-		})
+		windows = append(windows, toWindow("7-Day Opus", usage.SevenDayOpus, sevenDays, "opus"))
 	}
 
 	if usage.SevenDayOAuthApp != nil {
-		windows = append(windows, provider.UsageWindow{
-			Label:       "7-Day OAuth Apps",
-			Utilization: usage.SevenDayOAuthApp.Utilization,
-			ResetsAt:    usage.SevenDayOAuthApp.ResetsAt,
-		})
+		windows = append(windows, toWindow("7-Day OAuth Apps", usage.SevenDayOAuthApp, sevenDays, ""))
 	}
 
 	if usage.IguanaNecktie != nil {
-		windows = append(windows, provider.UsageWindow{
-			Label:       "Iguana Necktie",
-			Utilization: usage.IguanaNecktie.Utilization,
-			ResetsAt:    usage.IguanaNecktie.ResetsAt,
-		})
+		windows = append(windows, toWindow("Iguana Necktie", usage.IguanaNecktie, 0, ""))
 	}
 
 	extra := make(map[string]interface{})
@@ -111,3 +93,21 @@ func IsExpired(expiresAt int64) bool {
 func ExpiresIn(expiresAt int64) time.Duration {
 	return time.Until(time.UnixMilli(expiresAt))
 }
+
+// toWindow converts a Claude usage window. Claude windows are rolling and
+// report utilization only; d is 0 for windows of unknown length.
+func toWindow(label string, w *UsageWindow, d time.Duration, model string) provider.UsageWindow {
+	window := provider.UsageWindow{
+		Label:       label,
+		Utilization: w.Utilization,
+		ResetsAt:    w.ResetsAt,
+		Unit:        provider.UnitPercent,
+		Model:       model,
+	}
+	if d > 0 {
+		window.Kind = provider.KindRolling
+		window.Duration = provider.Duration(d)
+		window.StartsAt = window.Start()
+	}
+	return window
+}
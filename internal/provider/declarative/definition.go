@@ -0,0 +1,162 @@
+// Package declarative implements config-defined HTTP providers for llm-usage.
+//
+// A definition describes a single HTTP request (method, URL, headers, cookie
+// and optional body, all rendered as Go templates against the account's
+// credential values) and a set of JSONPath expressions mapping the response
+// to usage windows.
+package declarative
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefinitionsDir is the directory, relative to the config directory, that
+// holds provider definitions
+const DefinitionsDir = "providers"
+
+// Definition describes a config-defined HTTP provider
+type Definition struct {
+	// ID is the provider's unique identifier (defaults to the file name)
+	ID string `json:"id"`
+
+	// Name is the provider's display name (defaults to the ID)
+	Name string `json:"name"`
+
+	// Request describes the HTTP request used to fetch usage
+	Request RequestDefinition `json:"request"`
+
+	// Windows maps the response to usage windows
+	Windows []WindowDefinition `json:"windows"`
+
+	// Balance maps the response to a prepaid balance (optional)
+	Balance *BalanceDefinition `json:"balance,omitempty"`
+}
+
+// RequestDefinition describes the HTTP request for a provider.
+// URL, header values, cookie and body are Go templates rendered with the
+// account's credential values, e.g. "Bearer {{.token}}".
+type RequestDefinition struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Cookie  string            `json:"cookie"`
+	Body    string            `json:"body"`
+}
+
+// WindowDefinition maps parts of the JSON response to a usage window.
+//
+// All expressions are JSONPath expressions such as "$.data.quota[0].used".
+// When Items is set, it must select an array and the other expressions are
+// evaluated relative to each element, producing one window per element.
+// Label is treated as a literal unless it starts with "$".
+type WindowDefinition struct {
+	Items       string `json:"items,omitempty"`
+	Label       string `json:"label"`
+	Used        string `json:"used,omitempty"`
+	Limit       string `json:"limit,omitempty"`
+	Remaining   string `json:"remaining,omitempty"`
+	Utilization string `json:"utilization,omitempty"`
+	ResetsAt    string `json:"resets_at,omitempty"`
+
+	// UtilizationRatio indicates the utilization expression yields 0-1
+	// instead of 0-100
+	UtilizationRatio bool `json:"utilization_ratio,omitempty"`
+}
+
+// BalanceDefinition maps parts of the JSON response to a prepaid balance.
+// Amount is required; the other expressions are optional. Currency is
+// treated as a literal unless it starts with "$".
+type BalanceDefinition struct {
+	Amount    string `json:"amount"`
+	Currency  string `json:"currency,omitempty"`
+	Granted   string `json:"granted,omitempty"`
+	ToppedUp  string `json:"topped_up,omitempty"`
+	Used      string `json:"used,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// Validate checks that the definition is usable
+func (d *Definition) Validate() error {
+	if d.ID == "" {
+		return fmt.Errorf("missing id")
+	}
+	if d.Request.URL == "" {
+		return fmt.Errorf("provider %q: missing request url", d.ID)
+	}
+	if len(d.Windows) == 0 && d.Balance == nil {
+		return fmt.Errorf("provider %q: no windows or balance defined", d.ID)
+	}
+	if d.Balance != nil && d.Balance.Amount == "" {
+		return fmt.Errorf("provider %q: balance: missing amount", d.ID)
+	}
+	for i, w := range d.Windows {
+		if w.Label == "" {
+			return fmt.Errorf("provider %q: window %d: missing label", d.ID, i)
+		}
+		if w.Utilization == "" && (w.Used == "" || w.Limit == "") {
+			return fmt.Errorf("provider %q: window %d: needs utilization or used and limit", d.ID, i)
+		}
+	}
+	return nil
+}
+
+// LoadDefinition reads a single provider definition from a JSON file
+func LoadDefinition(path string) (*Definition, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // Path comes from the user's config directory
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider definition: %w", err)
+	}
+
+	var def Definition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("failed to parse provider definition %s: %w", path, err)
+	}
+
+	if def.ID == "" {
+		def.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if def.Name == "" {
+		def.Name = def.ID
+	}
+	if def.Request.Method == "" {
+		def.Request.Method = "GET"
+	}
+
+	if err := def.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid provider definition %s: %w", path, err)
+	}
+
+	return &def, nil
+}
+
+// LoadDefinitions reads all provider definitions from a directory.
+// A missing directory is not an error. Invalid definitions are returned
+// as errors alongside the valid ones so a single bad file doesn't hide the rest.
+func LoadDefinitions(dir string) ([]*Definition, []error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, []error{fmt.Errorf("failed to read provider definitions: %w", err)}
+	}
+
+	var defs []*Definition
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		def, err := LoadDefinition(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		defs = append(defs, def)
+	}
+	return defs, errs
+}
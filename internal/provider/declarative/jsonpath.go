@@ -0,0 +1,129 @@
+package declarative
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// evalPath evaluates a JSONPath expression against a decoded JSON document.
+//
+// Only the subset needed to pick scalar values is supported: the root "$",
+// dot-notation keys ("$.data.used"), bracketed keys ("$['x-limit']") and
+// array indices ("$.items[0]", negative indices count from the end).
+func evalPath(doc any, expr string) (any, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("path %q must start with $", expr)
+	}
+
+	cur := doc
+	rest := expr[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" {
+				return nil, fmt.Errorf("path %q: empty key", expr)
+			}
+			next, err := lookupKey(cur, key)
+			if err != nil {
+				return nil, fmt.Errorf("path %q: %w", expr, err)
+			}
+			cur = next
+			rest = rest[end:]
+
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("path %q: unterminated [", expr)
+			}
+			sel := rest[1:end]
+			rest = rest[end+1:]
+
+			var next any
+			var err error
+			if unquoted, ok := unquote(sel); ok {
+				next, err = lookupKey(cur, unquoted)
+			} else {
+				next, err = lookupIndex(cur, sel)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("path %q: %w", expr, err)
+			}
+			cur = next
+
+		default:
+			return nil, fmt.Errorf("path %q: unexpected %q", expr, rest[0])
+		}
+	}
+
+	return cur, nil
+}
+
+// lookupKey returns the value of key in a JSON object
+func lookupKey(v any, key string) (any, error) {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot select key %q from non-object", key)
+	}
+	val, ok := obj[key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found", key)
+	}
+	return val, nil
+}
+
+// lookupIndex returns the element at index sel in a JSON array
+func lookupIndex(v any, sel string) (any, error) {
+	arr, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot index non-array with [%s]", sel)
+	}
+	idx, err := strconv.Atoi(strings.TrimSpace(sel))
+	if err != nil {
+		return nil, fmt.Errorf("invalid index [%s]", sel)
+	}
+	if idx < 0 {
+		idx += len(arr)
+	}
+	if idx < 0 || idx >= len(arr) {
+		return nil, fmt.Errorf("index [%s] out of range", sel)
+	}
+	return arr[idx], nil
+}
+
+// unquote strips matching single or double quotes from a bracketed key
+func unquote(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1], true
+	}
+	return "", false
+}
+
+// toFloat converts a JSON value (number or numeric string) to float64
+func toFloat(v any) (float64, error) {
+	switch val := v.(type) {
+	case float64:
+		return val, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return 0, fmt.Errorf("value %q is not a number", val)
+		}
+		return f, nil
+	case bool:
+		if val {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("value of type %T is not a number", v)
+	}
+}
@@ -0,0 +1,334 @@
+package declarative
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/version"
+)
+
+// Provider implements the provider.Provider interface for a config-defined provider
+type Provider struct {
+	def        *Definition
+	account    credentials.GenericAccount
+	httpClient *http.Client
+}
+
+// NewProvider creates a new provider from a definition and the account's credential values
+func NewProvider(def *Definition, account credentials.GenericAccount) *Provider {
+	return &Provider{
+		def:     def,
+		account: account,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name returns the provider's display name
+func (p *Provider) Name() string {
+	return p.def.Name
+}
+
+// ID returns the provider's unique identifier
+func (p *Provider) ID() string {
+	return p.def.ID
+}
+
+// GetUsage fetches current usage statistics using the provider definition
+func (p *Provider) GetUsage() (*provider.Usage, error) {
+	doc, err := p.fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make([]provider.UsageWindow, 0, len(p.def.Windows))
+	for i, wd := range p.def.Windows {
+		parsed, err := parseWindows(doc, wd)
+		if err != nil {
+			return nil, fmt.Errorf("window %d: %w", i, err)
+		}
+		windows = append(windows, parsed...)
+	}
+
+	usage := &provider.Usage{
+		Provider: p.def.ID,
+		Windows:  windows,
+	}
+	if p.def.Balance != nil {
+		if usage.Balance, err = parseBalance(doc, p.def.Balance); err != nil {
+			return nil, fmt.Errorf("balance: %w", err)
+		}
+	}
+	return usage, nil
+}
+
+// fetch performs the configured request and decodes the JSON response
+func (p *Provider) fetch() (any, error) {
+	reqURL, err := p.render("url", p.def.Request.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if p.def.Request.Body != "" {
+		rendered, err := p.render("body", p.def.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBufferString(rendered)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(p.def.Request.Method), reqURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "llm-usage/"+version.Version)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range p.def.Request.Headers {
+		rendered, err := p.render("header "+name, value)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, rendered)
+	}
+	if p.def.Request.Cookie != "" {
+		cookie, err := p.render("cookie", p.def.Request.Cookie)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Cookie", cookie)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var doc any
+	if err := json.Unmarshal(respBody, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return doc, nil
+}
+
+// render executes a request template with the account's credential values
+func (p *Provider) render(name, text string) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+
+	data := map[string]string(p.account)
+	if data == nil {
+		data = map[string]string{}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// parseWindows evaluates a window definition against the response document
+func parseWindows(doc any, wd WindowDefinition) ([]provider.UsageWindow, error) {
+	if wd.Items == "" {
+		w, err := parseWindow(doc, wd)
+		if err != nil {
+			return nil, err
+		}
+		return []provider.UsageWindow{*w}, nil
+	}
+
+	itemsVal, err := evalPath(doc, wd.Items)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := itemsVal.([]any)
+	if !ok {
+		return nil, fmt.Errorf("items path %q does not select an array", wd.Items)
+	}
+
+	windows := make([]provider.UsageWindow, 0, len(items))
+	for _, item := range items {
+		w, err := parseWindow(item, wd)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, *w)
+	}
+	return windows, nil
+}
+
+// parseWindow builds a single UsageWindow from a JSON value
+func parseWindow(doc any, wd WindowDefinition) (*provider.UsageWindow, error) {
+	label := wd.Label
+	if strings.HasPrefix(label, "$") {
+		v, err := evalPath(doc, label)
+		if err != nil {
+			return nil, err
+		}
+		label = fmt.Sprint(v)
+	}
+
+	window := &provider.UsageWindow{Label: label}
+
+	var err error
+	if window.Used, err = optionalNumber(doc, wd.Used); err != nil {
+		return nil, err
+	}
+	if window.Limit, err = optionalNumber(doc, wd.Limit); err != nil {
+		return nil, err
+	}
+	if window.Remaining, err = optionalNumber(doc, wd.Remaining); err != nil {
+		return nil, err
+	}
+	if window.Remaining == nil && window.Used != nil && window.Limit != nil {
+		remaining := *window.Limit - *window.Used
+		window.Remaining = &remaining
+	}
+
+	switch {
+	case wd.Utilization != "":
+		util, err := optionalNumber(doc, wd.Utilization)
+		if err != nil {
+			return nil, err
+		}
+		window.Utilization = *util
+		if wd.UtilizationRatio {
+			window.Utilization *= 100
+		}
+	case window.Used != nil && window.Limit != nil && *window.Limit > 0:
+		window.Utilization = (*window.Used / *window.Limit) * 100
+	}
+
+	if wd.ResetsAt != "" {
+		v, err := evalPath(doc, wd.ResetsAt)
+		if err != nil {
+			return nil, err
+		}
+		resetsAt, err := parseTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("resets_at: %w", err)
+		}
+		window.ResetsAt = resetsAt
+	}
+
+	return window, nil
+}
+
+// parseBalance builds a Balance from the response document
+func parseBalance(doc any, bd *BalanceDefinition) (*provider.Balance, error) {
+	amount, err := optionalNumber(doc, bd.Amount)
+	if err != nil {
+		return nil, err
+	}
+	balance := &provider.Balance{Amount: *amount, Currency: bd.Currency}
+
+	if strings.HasPrefix(bd.Currency, "$") {
+		v, err := evalPath(doc, bd.Currency)
+		if err != nil {
+			return nil, err
+		}
+		balance.Currency = fmt.Sprint(v)
+	}
+	if balance.Granted, err = optionalNumber(doc, bd.Granted); err != nil {
+		return nil, err
+	}
+	if balance.ToppedUp, err = optionalNumber(doc, bd.ToppedUp); err != nil {
+		return nil, err
+	}
+	if balance.Used, err = optionalNumber(doc, bd.Used); err != nil {
+		return nil, err
+	}
+	if bd.ExpiresAt != "" {
+		v, err := evalPath(doc, bd.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		if balance.ExpiresAt, err = parseTime(v); err != nil {
+			return nil, fmt.Errorf("expires_at: %w", err)
+		}
+	}
+	return balance, nil
+}
+
+// optionalNumber evaluates expr as a number, returning nil when expr is empty
+func optionalNumber(doc any, expr string) (*float64, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	v, err := evalPath(doc, expr)
+	if err != nil {
+		return nil, err
+	}
+	f, err := toFloat(v)
+	if err != nil {
+		return nil, fmt.Errorf("path %q: %w", expr, err)
+	}
+	return &f, nil
+}
+
+// parseTime converts an RFC 3339 string or a Unix timestamp (seconds or
+// milliseconds) to a time. Null values yield nil.
+func parseTime(v any) (*time.Time, error) {
+	switch val := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if val == "" {
+			return nil, nil
+		}
+		if t, err := time.Parse(time.RFC3339Nano, val); err == nil {
+			return &t, nil
+		}
+		f, err := toFloat(val)
+		if err != nil {
+			return nil, fmt.Errorf("unrecognized time %q", val)
+		}
+		return unixTime(f), nil
+	case float64:
+		return unixTime(val), nil
+	default:
+		return nil, fmt.Errorf("unrecognized time value of type %T", v)
+	}
+}
+
+// unixTime interprets values above 1e12 as milliseconds, otherwise seconds
+func unixTime(f float64) *time.Time {
+	var t time.Time
+	if f > 1e12 {
+		t = time.UnixMilli(int64(f))
+	} else {
+		t = time.Unix(int64(f), 0)
+	}
+	return &t
+}
@@ -0,0 +1,194 @@
+package declarative
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+)
+
+func TestEvalPath(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(`{
+		"data": {
+			"quota": [{"used": 10, "limit": "40"}, {"used": 5}],
+			"x-reset": "2025-01-01T00:00:00Z"
+		}
+	}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr     string
+		expected any
+		wantErr  bool
+	}{
+		{"$.data.quota[0].used", 10.0, false},
+		{"$.data.quota[0].limit", "40", false},
+		{"$.data.quota[-1].used", 5.0, false},
+		{"$['data']['x-reset']", "2025-01-01T00:00:00Z", false},
+		{"$.data.missing", nil, true},
+		{"$.data.quota[5]", nil, true},
+		{"data.quota", nil, true},
+	}
+
+	for _, tc := range tests {
+		got, err := evalPath(doc, tc.expr)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("evalPath(%q) expected error, got %v", tc.expr, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("evalPath(%q) unexpected error: %v", tc.expr, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("evalPath(%q) = %v, want %v", tc.expr, got, tc.expected)
+		}
+	}
+}
+
+func TestProvider_GetUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+		}
+		if got := r.URL.Query().Get("org"); got != "acme" {
+			t.Errorf("org = %q, want %q", got, "acme")
+		}
+		_, _ = w.Write([]byte(`{
+			"daily": {"used": 25, "limit": 100, "reset": 1735689600},
+			"models": [
+				{"name": "fast", "ratio": 0.5},
+				{"name": "smart", "ratio": 0.9}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	def := &Definition{
+		ID:   "acme",
+		Name: "Acme",
+		Request: RequestDefinition{
+			Method:  "GET",
+			URL:     server.URL + "/quota?org={{.org}}",
+			Headers: map[string]string{"Authorization": "Bearer {{.token}}"},
+		},
+		Windows: []WindowDefinition{
+			{Label: "Daily", Used: "$.daily.used", Limit: "$.daily.limit", ResetsAt: "$.daily.reset"},
+			{Items: "$.models", Label: "$.name", Utilization: "$.ratio", UtilizationRatio: true},
+		},
+	}
+
+	p := NewProvider(def, credentials.GenericAccount{"token": "secret", "org": "acme"})
+	usage, err := p.GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage failed: %v", err)
+	}
+
+	if usage.Provider != "acme" {
+		t.Errorf("Provider = %q, want %q", usage.Provider, "acme")
+	}
+	if len(usage.Windows) != 3 {
+		t.Fatalf("got %d windows, want 3", len(usage.Windows))
+	}
+
+	daily := usage.Windows[0]
+	if daily.Utilization != 25 {
+		t.Errorf("Daily utilization = %v, want 25", daily.Utilization)
+	}
+	if daily.Remaining == nil || *daily.Remaining != 75 {
+		t.Errorf("Daily remaining = %v, want 75", daily.Remaining)
+	}
+	if daily.ResetsAt == nil || !daily.ResetsAt.Equal(time.Unix(1735689600, 0)) {
+		t.Errorf("Daily resets_at = %v, want %v", daily.ResetsAt, time.Unix(1735689600, 0))
+	}
+
+	if usage.Windows[1].Label != "fast" || usage.Windows[1].Utilization != 50 {
+		t.Errorf("window 1 = %s %.1f, want fast 50.0", usage.Windows[1].Label, usage.Windows[1].Utilization)
+	}
+	if usage.Windows[2].Label != "smart" || usage.Windows[2].Utilization != 90 {
+		t.Errorf("window 2 = %s %.1f, want smart 90.0", usage.Windows[2].Label, usage.Windows[2].Utilization)
+	}
+}
+
+func TestProvider_Balance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"balance_infos": [{"currency": "CNY", "total_balance": "12.50", "granted_balance": "10", "topped_up_balance": "5"}]}`))
+	}))
+	defer server.Close()
+
+	def := &Definition{
+		ID:      "deepseek",
+		Request: RequestDefinition{Method: "GET", URL: server.URL},
+		Balance: &BalanceDefinition{
+			Amount:   "$.balance_infos[0].total_balance",
+			Currency: "$.balance_infos[0].currency",
+			Granted:  "$.balance_infos[0].granted_balance",
+			ToppedUp: "$.balance_infos[0].topped_up_balance",
+		},
+	}
+	if err := def.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+
+	usage, err := NewProvider(def, credentials.GenericAccount{}).GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage failed: %v", err)
+	}
+	b := usage.Balance
+	if b == nil {
+		t.Fatal("Balance = nil")
+	}
+	if b.Amount != 12.5 || b.Currency != "CNY" || b.Total() != 15 {
+		t.Errorf("Balance = %v %s of %v, want 12.5 CNY of 15", b.Amount, b.Currency, b.Total())
+	}
+}
+
+func TestProvider_MissingTemplateValue(t *testing.T) {
+	def := &Definition{
+		ID:      "acme",
+		Request: RequestDefinition{Method: "GET", URL: "http://127.0.0.1/{{.token}}"},
+		Windows: []WindowDefinition{{Label: "Daily", Utilization: "$.u"}},
+	}
+
+	p := NewProvider(def, credentials.GenericAccount{})
+	if _, err := p.GetUsage(); err == nil {
+		t.Error("expected error for missing template value")
+	}
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := `{"name": "Acme", "request": {"url": "https://example.com"}, "windows": [{"label": "Daily", "utilization": "$.u"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "acme.json"), []byte(valid), 0600); err != nil {
+		t.Fatal(err)
+	}
+	invalid := `{"request": {"url": "https://example.com"}, "windows": []}`
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte(invalid), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	defs, errs := LoadDefinitions(dir)
+	if len(defs) != 1 {
+		t.Fatalf("got %d definitions, want 1", len(defs))
+	}
+	if len(errs) != 1 {
+		t.Errorf("got %d errors, want 1", len(errs))
+	}
+	if defs[0].ID != "acme" || defs[0].Request.Method != "GET" {
+		t.Errorf("definition defaults not applied: id=%q method=%q", defs[0].ID, defs[0].Request.Method)
+	}
+
+	if defs, errs := LoadDefinitions(filepath.Join(dir, "missing")); defs != nil || errs != nil {
+		t.Error("expected missing directory to yield no definitions and no errors")
+	}
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"slices"
+)
+
+// Tags returns the account tags recorded in Extra["tags"]
+func (u *Usage) Tags() []string {
+	switch tags := u.Extra["tags"].(type) {
+	case []string:
+		return tags
+	case []any:
+		// Decoded from JSON, e.g. a remote server or the snapshot
+		out := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if s, ok := t.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// Group returns the account group recorded in Extra["group"]
+func (u *Usage) Group() string {
+	group, _ := u.Extra["group"].(string)
+	return group
+}
+
+// GroupRollup aggregates the usage of the accounts in a group
+type GroupRollup struct {
+	Group          string         `json:"group"`
+	Accounts       []string       `json:"accounts"` // "provider/account"
+	Errors         int            `json:"errors"`   // accounts whose fetch failed
+	MaxUtilization float64        `json:"max_utilization"`
+	Class          string         `json:"class"`
+	Windows        []RollupWindow `json:"windows"`
+}
+
+// RollupWindow aggregates a window label across a group's accounts
+type RollupWindow struct {
+	Label          string  `json:"label"`
+	Accounts       int     `json:"accounts"`
+	MaxUtilization float64 `json:"max_utilization"`
+	AvgUtilization float64 `json:"avg_utilization"`
+}
+
+// Rollup aggregates usage by account group, in order of first appearance.
+// Accounts without a group are left out.
+func (s *UsageStats) Rollup() []GroupRollup {
+	var groups []GroupRollup
+	for i := range s.Providers {
+		u := &s.Providers[i]
+		name := u.Group()
+		if name == "" {
+			continue
+		}
+
+		idx := slices.IndexFunc(groups, func(g GroupRollup) bool { return g.Group == name })
+		if idx < 0 {
+			groups = append(groups, GroupRollup{Group: name, Class: "normal"})
+			idx = len(groups) - 1
+		}
+		g := &groups[idx]
+
+		account := u.Provider
+		if acc, _ := u.Extra["account"].(string); acc != "" {
+			account += "/" + acc
+		}
+		g.Accounts = append(g.Accounts, account)
+		if u.Error != nil {
+			g.Errors++
+			continue
+		}
+
+		g.Class = WorseClass(g.Class, u.Class())
+		for _, w := range u.Windows {
+			g.MaxUtilization = max(g.MaxUtilization, w.Utilization)
+			wi := slices.IndexFunc(g.Windows, func(rw RollupWindow) bool { return rw.Label == w.Label })
+			if wi < 0 {
+				g.Windows = append(g.Windows, RollupWindow{Label: w.Label})
+				wi = len(g.Windows) - 1
+			}
+			rw := &g.Windows[wi]
+			// Running average over the accounts reporting this window
+			rw.AvgUtilization = (rw.AvgUtilization*float64(rw.Accounts) + w.Utilization) / float64(rw.Accounts+1)
+			rw.Accounts++
+			rw.MaxUtilization = max(rw.MaxUtilization, w.Utilization)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestRollup(t *testing.T) {
+	stats := &UsageStats{Providers: []Usage{
+		{Provider: "claude", Extra: map[string]any{"account": "a", "group": "team"}, Windows: []UsageWindow{{Label: "5-Hour", Utilization: 80}}},
+		{Provider: "claude", Extra: map[string]any{"account": "b", "group": "team"}, Windows: []UsageWindow{{Label: "5-Hour", Utilization: 20}}},
+		{Provider: "kimi", Extra: map[string]any{"account": "c", "group": "team"}, Error: errors.New("boom")},
+		{Provider: "zai", Extra: map[string]any{"account": "d"}, Windows: []UsageWindow{{Label: "5-Hour", Utilization: 99}}},
+	}}
+
+	groups := stats.Rollup()
+	if len(groups) != 1 {
+		t.Fatalf("Rollup() = %d groups, want 1", len(groups))
+	}
+	g := groups[0]
+	if want := []string{"claude/a", "claude/b", "kimi/c"}; !slices.Equal(g.Accounts, want) {
+		t.Errorf("Accounts = %v, want %v", g.Accounts, want)
+	}
+	if g.Errors != 1 {
+		t.Errorf("Errors = %d, want 1", g.Errors)
+	}
+	if g.MaxUtilization != 80 || g.Class != "warning" {
+		t.Errorf("MaxUtilization, Class = %v, %q, want 80, warning", g.MaxUtilization, g.Class)
+	}
+	if len(g.Windows) != 1 || g.Windows[0].Accounts != 2 || g.Windows[0].AvgUtilization != 50 {
+		t.Errorf("Windows = %+v, want one 5-Hour window over 2 accounts averaging 50", g.Windows)
+	}
+}
+
+func TestTagsFromJSON(t *testing.T) {
+	u := Usage{Extra: map[string]any{"tags": []any{"team-a", 1, "personal"}}}
+	if got, want := u.Tags(), []string{"team-a", "personal"}; !slices.Equal(got, want) {
+		t.Errorf("Tags() = %v, want %v", got, want)
+	}
+}
@@ -69,10 +69,7 @@ func (p *Provider) GetUsage() (*provider.Usage, error) {
 
 	// Fetch subscription info (with caching)
 	if sub := p.getSubscription(); sub != nil {
-		if usage.Extra == nil {
-			usage.Extra = make(map[string]any)
-		}
-		usage.Extra["subscription"] = p.formatSubscriptionExtra(sub)
+		usage.Subscription = p.toSubscription(sub)
 	}
 
 	return usage, nil
@@ -108,6 +105,8 @@ func (p *Provider) parseScopeWindow(item UsageItem) *provider.UsageWindow {
 		Limit:       &limit,
 		Used:        &used,
 		Remaining:   &remaining,
+		Kind:        provider.KindFixed,
+		Unit:        provider.UnitRequests,
 	}
 }
 
@@ -136,14 +135,36 @@ func (p *Provider) parseLimitWindow(_ string, limit LimitItem) *provider.UsageWi
 
 	label := p.formatDurationLabel(limit.Window.Duration, limit.Window.TimeUnit)
 
-	return &provider.UsageWindow{
+	window := &provider.UsageWindow{
 		Label:       label,
 		Utilization: utilization,
 		ResetsAt:    resetsAt,
 		Limit:       &limitVal,
 		Used:        &usedVal,
 		Remaining:   &remaining,
+		Kind:        provider.KindRateLimit,
+		Duration:    provider.Duration(windowDuration(limit.Window.Duration, limit.Window.TimeUnit)),
+		Unit:        provider.UnitRequests,
+	}
+	window.StartsAt = window.Start()
+	return window
+}
+
+// windowDuration converts a rate limit window, e.g. 5 TIME_UNIT_MINUTE, to a
+// duration. It returns 0 for unknown units.
+func windowDuration(duration int, timeUnit string) time.Duration {
+	var unit time.Duration
+	switch strings.TrimSuffix(strings.TrimPrefix(timeUnit, "TIME_UNIT_"), "S") {
+	case "SECOND":
+		unit = time.Second
+	case "MINUTE":
+		unit = time.Minute
+	case "HOUR":
+		unit = time.Hour
+	case "DAY":
+		unit = 24 * time.Hour
 	}
+	return time.Duration(duration) * unit
 }
 
 // formatScopeLabel formats the scope name for display
@@ -194,63 +215,79 @@ func (p *Provider) getSubscription() *SubscriptionResponse {
 	return sub
 }
 
-// formatSubscriptionExtra formats subscription data for the Extra map
-func (p *Provider) formatSubscriptionExtra(sub *SubscriptionResponse) map[string]any {
-	result := map[string]any{
-		"subscribed": sub.Subscribed,
+// toSubscription converts the subscription response, or returns nil when it
+// carries neither a plan nor feature quotas
+func (p *Provider) toSubscription(sub *SubscriptionResponse) *provider.Subscription {
+	if sub.Subscription == nil && len(sub.Memberships) == 0 {
+		return nil
 	}
 
-	if sub.Subscription != nil {
-		// Parse expiry date
-		var expiresAt *time.Time
-		if sub.Subscription.CurrentEndTime != "" {
-			if t, err := time.Parse(time.RFC3339Nano, sub.Subscription.CurrentEndTime); err == nil {
-				expiresAt = &t
+	result := &provider.Subscription{}
+	if s := sub.Subscription; s != nil {
+		result.Plan = s.Goods.Title
+		result.Tier = formatMembershipLevel(s.Goods.MembershipLevel)
+		result.Status = parseSubscriptionStatus(s.Status)
+		result.BillingCycle = formatBillingCycle(s.Goods.BillingCycle)
+		result.ExpiresAt = parseTime(s.CurrentEndTime)
+
+		if len(s.Goods.Amounts) > 0 {
+			amount := s.Goods.Amounts[0]
+			if cents, err := strconv.ParseFloat(amount.PriceInCents, 64); err == nil {
+				price := cents / 100
+				result.Price = &price
+				result.Currency = strings.ToUpper(amount.Currency)
 			}
 		}
-
-		// Format status for display
-		status := formatSubscriptionStatus(sub.Subscription.Status)
-
-		plan := map[string]any{
-			"title":  sub.Subscription.Goods.Title,
-			"level":  formatMembershipLevel(sub.Subscription.Goods.MembershipLevel),
-			"status": status,
-		}
-		result["plan"] = plan
-
-		if expiresAt != nil {
-			result["expires_at"] = expiresAt.Format(time.RFC3339)
-		}
 	}
 
-	if len(sub.Memberships) > 0 {
-		features := make([]map[string]any, 0, len(sub.Memberships))
-		for _, m := range sub.Memberships {
-			features = append(features, map[string]any{
-				"feature": formatFeatureName(m.Feature),
-				"left":    m.LeftCount,
-				"total":   m.TotalCount,
-			})
-		}
-		result["features"] = features
+	for _, m := range sub.Memberships {
+		result.Features = append(result.Features, provider.Feature{
+			Name:     formatFeatureName(m.Feature),
+			Left:     m.LeftCount,
+			Total:    m.TotalCount,
+			ResetsAt: parseTime(m.EndTime),
+		})
 	}
 
 	return result
 }
 
-// formatSubscriptionStatus converts status constants to display strings
-func formatSubscriptionStatus(status string) string {
+// parseTime parses an RFC 3339 timestamp, returning nil when empty or invalid
+func parseTime(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// parseSubscriptionStatus converts status constants to provider statuses
+func parseSubscriptionStatus(status string) string {
 	switch status {
 	case "SUBSCRIPTION_STATUS_ACTIVE":
-		return "Active"
+		return provider.SubscriptionActive
 	case "SUBSCRIPTION_STATUS_CANCELLED":
-		return "Cancelled"
+		return provider.SubscriptionCancelled
 	case "SUBSCRIPTION_STATUS_EXPIRED":
-		return "Expired"
+		return provider.SubscriptionExpired
 	default:
-		return strings.TrimPrefix(status, "SUBSCRIPTION_STATUS_")
+		return strings.ToLower(strings.TrimPrefix(status, "SUBSCRIPTION_STATUS_"))
+	}
+}
+
+// formatBillingCycle formats a billing cycle, e.g. "1 month" or "12 months"
+func formatBillingCycle(cycle BillingCycle) string {
+	if cycle.Duration <= 0 || cycle.TimeUnit == "" {
+		return ""
+	}
+	unit := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(cycle.TimeUnit, "TIME_UNIT_"), "S"))
+	if cycle.Duration != 1 {
+		unit += "s"
 	}
+	return fmt.Sprintf("%d %s", cycle.Duration, unit)
 }
 
 // formatMembershipLevel converts level constants to display strings
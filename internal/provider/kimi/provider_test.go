@@ -2,23 +2,44 @@ package kimi
 
 import (
 	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
 )
 
-func TestFormatSubscriptionStatus(t *testing.T) {
+func TestParseSubscriptionStatus(t *testing.T) {
 	tests := []struct {
 		input    string
 		expected string
 	}{
-		{"SUBSCRIPTION_STATUS_ACTIVE", "Active"},
-		{"SUBSCRIPTION_STATUS_CANCELLED", "Cancelled"},
-		{"SUBSCRIPTION_STATUS_EXPIRED", "Expired"},
-		{"UNKNOWN_STATUS", "UNKNOWN_STATUS"},
+		{"SUBSCRIPTION_STATUS_ACTIVE", provider.SubscriptionActive},
+		{"SUBSCRIPTION_STATUS_CANCELLED", provider.SubscriptionCancelled},
+		{"SUBSCRIPTION_STATUS_EXPIRED", provider.SubscriptionExpired},
+		{"SUBSCRIPTION_STATUS_PAUSED", "paused"},
 	}
 
 	for _, tc := range tests {
-		result := formatSubscriptionStatus(tc.input)
+		result := parseSubscriptionStatus(tc.input)
 		if result != tc.expected {
-			t.Errorf("formatSubscriptionStatus(%q) = %q, want %q", tc.input, result, tc.expected)
+			t.Errorf("parseSubscriptionStatus(%q) = %q, want %q", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestFormatBillingCycle(t *testing.T) {
+	tests := []struct {
+		cycle    BillingCycle
+		expected string
+	}{
+		{BillingCycle{Duration: 1, TimeUnit: "TIME_UNIT_MONTH"}, "1 month"},
+		{BillingCycle{Duration: 12, TimeUnit: "TIME_UNIT_MONTHS"}, "12 months"},
+		{BillingCycle{}, ""},
+	}
+
+	for _, tc := range tests {
+		result := formatBillingCycle(tc.cycle)
+		if result != tc.expected {
+			t.Errorf("formatBillingCycle(%+v) = %q, want %q", tc.cycle, result, tc.expected)
 		}
 	}
 }
@@ -102,7 +123,28 @@ func TestProvider_FormatDurationLabel(t *testing.T) {
 	}
 }
 
-func TestProvider_FormatSubscriptionExtra(t *testing.T) {
+func TestProvider_ParseLimitWindow(t *testing.T) {
+	p := &Provider{}
+
+	w := p.parseLimitWindow("FEATURE_CODING", LimitItem{
+		Window: WindowDetail{Duration: 5, TimeUnit: "TIME_UNIT_MINUTE"},
+		Detail: UsageDetail{Limit: "100", Used: "25", ResetTime: "2025-01-01T00:05:00Z"},
+	})
+	if w == nil {
+		t.Fatal("parseLimitWindow() = nil")
+	}
+	if w.Kind != provider.KindRateLimit || w.Unit != provider.UnitRequests {
+		t.Errorf("Kind, Unit = %q, %q, want rate_limit, requests", w.Kind, w.Unit)
+	}
+	if time.Duration(w.Duration) != 5*time.Minute {
+		t.Errorf("Duration = %v, want 5m", time.Duration(w.Duration))
+	}
+	if want := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC); w.StartsAt == nil || !w.StartsAt.Equal(want) {
+		t.Errorf("StartsAt = %v, want %v", w.StartsAt, want)
+	}
+}
+
+func TestProvider_ToSubscription(t *testing.T) {
 	p := &Provider{}
 
 	sub := &SubscriptionResponse{
@@ -114,6 +156,8 @@ func TestProvider_FormatSubscriptionExtra(t *testing.T) {
 			Goods: Goods{
 				Title:           "Moderato",
 				MembershipLevel: "LEVEL_BASIC",
+				Amounts:         []Amount{{Currency: "usd", PriceInCents: "1900"}},
+				BillingCycle:    BillingCycle{Duration: 1, TimeUnit: "TIME_UNIT_MONTH"},
 			},
 		},
 		Memberships: []Membership{
@@ -125,43 +169,42 @@ func TestProvider_FormatSubscriptionExtra(t *testing.T) {
 		},
 	}
 
-	result := p.formatSubscriptionExtra(sub)
-
-	// Check subscribed flag
-	if subscribed, ok := result["subscribed"].(bool); !ok || !subscribed {
-		t.Error("Expected subscribed to be true")
+	result := p.toSubscription(sub)
+	if result == nil {
+		t.Fatal("toSubscription() = nil")
 	}
 
-	// Check plan
-	plan, ok := result["plan"].(map[string]any)
-	if !ok {
-		t.Fatal("Expected plan to be a map")
+	if result.Plan != "Moderato" {
+		t.Errorf("Plan = %q, want Moderato", result.Plan)
 	}
-	if plan["title"] != "Moderato" {
-		t.Errorf("Expected title to be 'Moderato', got %v", plan["title"])
+	if result.Tier != "Basic" {
+		t.Errorf("Tier = %q, want Basic", result.Tier)
 	}
-	if plan["level"] != "Basic" {
-		t.Errorf("Expected level to be 'Basic', got %v", plan["level"])
+	if result.Status != provider.SubscriptionActive || !result.Renews() {
+		t.Errorf("Status = %q, want active", result.Status)
 	}
-	if plan["status"] != "Active" {
-		t.Errorf("Expected status to be 'Active', got %v", plan["status"])
+	if result.BillingCycle != "1 month" {
+		t.Errorf("BillingCycle = %q, want 1 month", result.BillingCycle)
 	}
-
-	// Check features
-	features, ok := result["features"].([]map[string]any)
-	if !ok {
-		t.Fatal("Expected features to be a slice of maps")
+	if result.Price == nil || *result.Price != 19 || result.Currency != "USD" {
+		t.Errorf("Price = %v %q, want 19 USD", result.Price, result.Currency)
 	}
-	if len(features) != 1 {
-		t.Fatalf("Expected 1 feature, got %d", len(features))
+	if want := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC); result.ExpiresAt == nil || !result.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", result.ExpiresAt, want)
 	}
-	if features[0]["feature"] != "Coding" {
-		t.Errorf("Expected feature name to be 'Coding', got %v", features[0]["feature"])
+
+	if len(result.Features) != 1 {
+		t.Fatalf("Expected 1 feature, got %d", len(result.Features))
+	}
+	f := result.Features[0]
+	if f.Name != "Coding" || f.Left != 15 || f.Total != 20 {
+		t.Errorf("Features[0] = %+v, want Coding 15/20", f)
 	}
-	if features[0]["left"] != 15 {
-		t.Errorf("Expected left to be 15, got %v", features[0]["left"])
+	if f.Utilization() != 25 {
+		t.Errorf("Utilization() = %v, want 25", f.Utilization())
 	}
-	if features[0]["total"] != 20 {
-		t.Errorf("Expected total to be 20, got %v", features[0]["total"])
+
+	if p.toSubscription(&SubscriptionResponse{}) != nil {
+		t.Error("toSubscription() of an empty response should be nil")
 	}
 }
@@ -2,6 +2,7 @@
 package minimax
 
 import (
+	"strings"
 	"time"
 
 	"github.com/denysvitali/llm-usage/internal/cache"
@@ -58,13 +59,9 @@ func (p *Provider) GetUsage() (*provider.Usage, error) {
 	}
 
 	// Fetch subscription info (with caching)
-	if sub := p.getSubscription(); sub != nil {
-		if usage.Extra == nil {
-			usage.Extra = make(map[string]any)
-		}
-		usage.Extra["subscription"] = map[string]any{
-			"status": sub.BaseResp.StatusMsg,
-		}
+	// The response carries no plan details yet, only the API status
+	if sub := p.getSubscription(); sub != nil && sub.BaseResp.StatusMsg != "" {
+		usage.Subscription = &provider.Subscription{Status: strings.ToLower(sub.BaseResp.StatusMsg)}
 	}
 
 	return usage, nil
@@ -92,14 +89,24 @@ func (p *Provider) parseModelRemain(item ModelRemain) *provider.UsageWindow {
 		label = "MiniMax"
 	}
 
-	return &provider.UsageWindow{
+	window := &provider.UsageWindow{
 		Label:       label,
 		Utilization: utilization,
 		ResetsAt:    &resetsAt,
 		Limit:       &total,
 		Used:        &used,
 		Remaining:   &remaining,
+		Kind:        provider.KindFixed,
+		Unit:        provider.UnitPrompts,
+		Model:       item.ModelName,
+	}
+	// start_time..end_time is the current interval
+	if item.StartTime > 0 && item.EndTime > item.StartTime {
+		startsAt := time.UnixMilli(item.StartTime)
+		window.StartsAt = &startsAt
+		window.Duration = provider.Duration(resetsAt.Sub(startsAt))
 	}
+	return window
 }
 
 // getSubscription fetches subscription info with caching
@@ -2,6 +2,8 @@
 package provider
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -26,6 +28,12 @@ type Usage struct {
 	// Usage windows (provider-specific, can be nil)
 	Windows []UsageWindow `json:"windows"`
 
+	// Subscription plan (optional)
+	Subscription *Subscription `json:"subscription,omitempty"`
+
+	// Prepaid balance (optional)
+	Balance *Balance `json:"balance,omitempty"`
+
 	// Extra usage information (optional, provider-specific)
 	Extra map[string]any `json:"extra"`
 
@@ -33,6 +41,51 @@ type Usage struct {
 	Error error `json:"error"`
 }
 
+// usageJSON is the wire format of Usage, with the error flattened to its message
+type usageJSON struct {
+	Provider     string         `json:"provider"`
+	Windows      []UsageWindow  `json:"windows"`
+	Subscription *Subscription  `json:"subscription,omitempty"`
+	Balance      *Balance       `json:"balance,omitempty"`
+	Extra        map[string]any `json:"extra"`
+	Error        *string        `json:"error"`
+}
+
+// MarshalJSON encodes the usage with the error as a plain string (or null)
+func (u Usage) MarshalJSON() ([]byte, error) {
+	out := usageJSON{
+		Provider:     u.Provider,
+		Windows:      u.Windows,
+		Subscription: u.Subscription,
+		Balance:      u.Balance,
+		Extra:        u.Extra,
+	}
+	if u.Error != nil {
+		msg := u.Error.Error()
+		out.Error = &msg
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes usage produced by MarshalJSON, e.g. from a remote server
+func (u *Usage) UnmarshalJSON(data []byte) error {
+	var in usageJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*u = Usage{
+		Provider:     in.Provider,
+		Windows:      in.Windows,
+		Subscription: in.Subscription,
+		Balance:      in.Balance,
+		Extra:        in.Extra,
+	}
+	if in.Error != nil {
+		u.Error = errors.New(*in.Error)
+	}
+	return nil
+}
+
 // UsageWindow represents a usage time window
 type UsageWindow struct {
 	Label       string     `json:"label"`       // e.g., "5-Hour", "7-Day", "Daily"
@@ -43,6 +96,13 @@ type UsageWindow struct {
 	Limit     *float64 `json:"limit,omitempty"`     // Usage limit (e.g., token count)
 	Used      *float64 `json:"used,omitempty"`      // Amount used
 	Remaining *float64 `json:"remaining,omitempty"` // Amount remaining
+
+	// Structured metadata (see SchemaVersion); empty when the provider doesn't report it
+	Kind     WindowKind `json:"kind,omitempty"`
+	Duration Duration   `json:"duration,omitempty"`  // Window length, e.g. 5h
+	StartsAt *time.Time `json:"starts_at,omitempty"` // When the current window started
+	Unit     Unit       `json:"unit,omitempty"`
+	Model    string     `json:"model,omitempty"` // Model the window is scoped to, e.g. "opus"; empty for all models
 }
 
 // TimeUntilReset returns the duration until the window resets
@@ -57,6 +117,9 @@ func (w *UsageWindow) TimeUntilReset() *time.Duration {
 // UsageStats aggregates results from multiple providers
 type UsageStats struct {
 	Providers []Usage `json:"providers"`
+
+	// Groups rolls up accounts by group in JSON output (see Rollup)
+	Groups []GroupRollup `json:"groups,omitempty"`
 }
 
 // MaxUtilization returns the maximum utilization across all providers
@@ -75,15 +138,21 @@ func (s *UsageStats) MaxUtilization() float64 {
 	return maxUtil
 }
 
-// GetClass returns the CSS class based on maximum utilization
+// GetClass returns the CSS class of the worst window under its thresholds
 func (s *UsageStats) GetClass() string {
-	maxUtil := s.MaxUtilization()
-	if maxUtil >= 90 {
-		return "critical"
-	} else if maxUtil >= 75 {
-		return "warning"
+	class := "normal"
+	for i := range s.Providers {
+		if s.Providers[i].Error == nil {
+			class = WorseClass(class, s.Providers[i].Class())
+		}
 	}
-	return "normal"
+	return class
+}
+
+// ClassForUtilization returns "critical", "warning" or "normal" for a
+// utilization percentage under the general thresholds
+func ClassForUtilization(utilization float64) string {
+	return ThresholdsFor("", "", "").Class(utilization)
 }
 
 // ProviderByID returns a provider by its ID from the stats
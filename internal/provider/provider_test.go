@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUsage_JSONRoundTrip(t *testing.T) {
+	resetsAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	stats := UsageStats{
+		Providers: []Usage{
+			{
+				Provider: "claude",
+				Windows: []UsageWindow{{
+					Label: "5-Hour", Utilization: 42, ResetsAt: &resetsAt,
+					Kind: KindRolling, Duration: Duration(5 * time.Hour), Unit: UnitPercent,
+				}},
+				Extra: map[string]any{"account": "work"},
+			},
+			*NewUsageError("kimi", "Kimi", errors.New("boom")),
+		},
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var raw struct {
+		SchemaVersion int              `json:"schema_version"`
+		Providers     []map[string]any `json:"providers"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw.SchemaVersion != SchemaVersion {
+		t.Errorf("schema_version = %d, want %d", raw.SchemaVersion, SchemaVersion)
+	}
+	if w := raw.Providers[0]["windows"].([]any)[0].(map[string]any); w["duration"] != "5h0m0s" || w["kind"] != "rolling" {
+		t.Errorf("window = %v, want kind rolling and duration 5h0m0s", w)
+	}
+	if raw.Providers[0]["error"] != nil {
+		t.Errorf("error = %v, want null", raw.Providers[0]["error"])
+	}
+	if raw.Providers[1]["error"] != "Kimi: boom" {
+		t.Errorf("error = %v, want %q", raw.Providers[1]["error"], "Kimi: boom")
+	}
+
+	var decoded UsageStats
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Providers[0].Error != nil {
+		t.Errorf("decoded error = %v, want nil", decoded.Providers[0].Error)
+	}
+	if decoded.Providers[0].Windows[0].ResetsAt == nil || !decoded.Providers[0].Windows[0].ResetsAt.Equal(resetsAt) {
+		t.Errorf("decoded resets_at = %v, want %v", decoded.Providers[0].Windows[0].ResetsAt, resetsAt)
+	}
+	if w := decoded.Providers[0].Windows[0]; time.Duration(w.Duration) != 5*time.Hour || !w.Start().Equal(resetsAt.Add(-5*time.Hour)) {
+		t.Errorf("decoded duration, start = %v, %v, want 5h before resets_at", w.Duration, w.Start())
+	}
+	if decoded.Providers[1].Error == nil || decoded.Providers[1].Error.Error() != "Kimi: boom" {
+		t.Errorf("decoded error = %v, want %q", decoded.Providers[1].Error, "Kimi: boom")
+	}
+}
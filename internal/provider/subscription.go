@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"strings"
+	"time"
+)
+
+// Subscription statuses
+const (
+	SubscriptionActive    = "active"
+	SubscriptionCancelled = "cancelled" // paid until ExpiresAt, won't renew
+	SubscriptionExpired   = "expired"
+)
+
+// Subscription is the plan an account is subscribed to
+type Subscription struct {
+	Plan         string     `json:"plan,omitempty"`          // Plan name, e.g. "Moderato"
+	Tier         string     `json:"tier,omitempty"`          // Membership level, e.g. "Basic"
+	Status       string     `json:"status,omitempty"`        // One of the Subscription* statuses, or the provider's own
+	BillingCycle string     `json:"billing_cycle,omitempty"` // e.g. "1 month"
+	Price        *float64   `json:"price,omitempty"`         // Price per billing cycle
+	Currency     string     `json:"currency,omitempty"`      // ISO 4217 code, e.g. "USD"
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`    // End of the paid period: the renewal date while active
+	Features     []Feature  `json:"features,omitempty"`
+}
+
+// Feature is a per-feature quota included in a subscription
+type Feature struct {
+	Name     string     `json:"name"`
+	Left     int        `json:"left"`
+	Total    int        `json:"total"`
+	ResetsAt *time.Time `json:"resets_at,omitempty"`
+}
+
+// Utilization returns the used share of the feature quota as a percentage
+func (f Feature) Utilization() float64 {
+	if f.Total <= 0 {
+		return 0
+	}
+	return float64(f.Total-f.Left) / float64(f.Total) * 100
+}
+
+// Renews reports whether the subscription renews at ExpiresAt instead of ending
+func (s *Subscription) Renews() bool {
+	return s.Status == SubscriptionActive
+}
+
+// StatusTitle returns the status for display, e.g. "Active"
+func (s *Subscription) StatusTitle() string {
+	if s.Status == "" {
+		return ""
+	}
+	return strings.ToUpper(s.Status[:1]) + s.Status[1:]
+}
+
+// Expiring reports whether the subscription ends or renews within d of now
+func (s *Subscription) Expiring(now time.Time, d time.Duration) bool {
+	return s.ExpiresAt != nil && s.ExpiresAt.Sub(now) <= d
+}
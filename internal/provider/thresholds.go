@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Thresholds are the utilization percentages at which a window becomes
+// "warning" and "critical"
+type Thresholds struct {
+	Warning  float64
+	Critical float64
+}
+
+// DefaultThresholds apply unless the config file overrides them
+var DefaultThresholds = Thresholds{Warning: 75, Critical: 90}
+
+// Class returns "critical", "warning" or "normal" for a utilization percentage
+func (t Thresholds) Class(utilization float64) string {
+	if utilization >= t.Critical {
+		return "critical"
+	} else if utilization >= t.Warning {
+		return "warning"
+	}
+	return "normal"
+}
+
+var (
+	thresholdsMu   sync.RWMutex
+	thresholdsFunc func(providerID, account, window string) Thresholds
+)
+
+// SetThresholds installs a lookup of per provider, account and window
+// thresholds, e.g. from the config file. nil restores DefaultThresholds.
+func SetThresholds(fn func(providerID, account, window string) Thresholds) {
+	thresholdsMu.Lock()
+	defer thresholdsMu.Unlock()
+	thresholdsFunc = fn
+}
+
+// ThresholdsFor returns the thresholds of a provider account's window. Empty
+// arguments return the general thresholds.
+func ThresholdsFor(providerID, account, window string) Thresholds {
+	thresholdsMu.RLock()
+	fn := thresholdsFunc
+	thresholdsMu.RUnlock()
+	if fn == nil {
+		return DefaultThresholds
+	}
+	return fn(providerID, account, window)
+}
+
+// ClassFor returns the class of a window's utilization under its thresholds
+func ClassFor(providerID, account, window string, utilization float64) string {
+	return ThresholdsFor(providerID, account, window).Class(utilization)
+}
+
+// classRank orders classes from best to worst
+var classRank = map[string]int{"normal": 0, "warning": 1, "critical": 2}
+
+// WorseClass returns the worse of two classes
+func WorseClass(a, b string) string {
+	if classRank[b] > classRank[a] {
+		return b
+	}
+	return a
+}
+
+// Class returns the worst class across the usage's windows and balance
+func (u *Usage) Class() string {
+	account, _ := u.Extra["account"].(string)
+	class := "normal"
+	for _, w := range u.Windows {
+		class = WorseClass(class, ClassFor(u.Provider, account, w.Label, w.Utilization))
+	}
+	if u.Balance != nil {
+		class = WorseClass(class, u.Balance.Class(u.Provider, account))
+	}
+	return class
+}
+
+// Selector matches provider accounts and their windows. It is written as
+// "provider[/account][:window]", where empty or "*" parts match anything.
+type Selector struct {
+	Provider string
+	Account  string
+	Window   string
+}
+
+// ParseSelector parses a selector such as "claude", "claude/work:5-Hour" or "*:Weekly"
+func ParseSelector(s string) (Selector, error) {
+	var sel Selector
+	target, window, _ := strings.Cut(s, ":")
+	sel.Window = window
+	sel.Provider, sel.Account, _ = strings.Cut(target, "/")
+	if sel.Provider == "" {
+		return Selector{}, fmt.Errorf("invalid selector %q: missing provider (use * for any)", s)
+	}
+	return sel, nil
+}
+
+// String formats the selector as ParseSelector accepts it
+func (s Selector) String() string {
+	out := s.Provider
+	if s.Account != "" {
+		out += "/" + s.Account
+	}
+	if s.Window != "" {
+		out += ":" + s.Window
+	}
+	return out
+}
+
+// Match scores how specifically the selector matches a provider account's
+// window, from 0 (all wildcards) to 7 (exact), or -1 when it doesn't match.
+// Window labels compare case-insensitively.
+func (s Selector) Match(providerID, account, window string) int {
+	score := 0
+	for _, f := range []struct {
+		pattern, value string
+		weight         int
+	}{
+		{s.Provider, providerID, 4},
+		{s.Account, account, 2},
+		{s.Window, window, 1},
+	} {
+		switch {
+		case f.pattern == "" || f.pattern == "*":
+		case strings.EqualFold(f.pattern, f.value):
+			score += f.weight
+		default:
+			return -1
+		}
+	}
+	return score
+}
@@ -0,0 +1,64 @@
+package provider
+
+import "testing"
+
+func TestSelectorMatch(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     int
+	}{
+		{"*", 0},
+		{"claude", 4},
+		{"claude/work", 6},
+		{"claude:5-hour", 5},
+		{"claude/work:5-Hour", 7},
+		{"*:5-Hour", 1},
+		{"kimi", -1},
+		{"claude/home", -1},
+		{"claude:7-Day", -1},
+	}
+	for _, tt := range tests {
+		sel, err := ParseSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("ParseSelector(%q) failed: %v", tt.selector, err)
+		}
+		if got := sel.Match("claude", "work", "5-Hour"); got != tt.want {
+			t.Errorf("%q.Match() = %d, want %d", tt.selector, got, tt.want)
+		}
+		if sel.String() != tt.selector {
+			t.Errorf("String() = %q, want %q", sel.String(), tt.selector)
+		}
+	}
+
+	if _, err := ParseSelector(":5-Hour"); err == nil {
+		t.Error("ParseSelector(:5-Hour) should fail")
+	}
+}
+
+func TestSetThresholds(t *testing.T) {
+	t.Cleanup(func() { SetThresholds(nil) })
+
+	stats := &UsageStats{Providers: []Usage{
+		{Provider: "claude", Windows: []UsageWindow{{Label: "5-Hour", Utilization: 80}}},
+		{Provider: "kimi", Windows: []UsageWindow{{Label: "Weekly", Utilization: 60}}},
+	}}
+	if got := stats.GetClass(); got != "warning" {
+		t.Errorf("GetClass() = %q with default thresholds, want warning", got)
+	}
+
+	SetThresholds(func(providerID, _, _ string) Thresholds {
+		if providerID == "kimi" {
+			return Thresholds{Warning: 40, Critical: 50}
+		}
+		return Thresholds{Warning: 85, Critical: 95}
+	})
+	if got := stats.GetClass(); got != "critical" {
+		t.Errorf("GetClass() = %q, want critical", got)
+	}
+	if got := stats.Providers[0].Class(); got != "normal" {
+		t.Errorf("Class() = %q, want normal", got)
+	}
+	if got := ClassForUtilization(90); got != "warning" {
+		t.Errorf("ClassForUtilization(90) = %q, want warning", got)
+	}
+}
@@ -0,0 +1,183 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/denysvitali/llm-usage/internal/version"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+const (
+	hostModuleName = "llm_usage"
+	allocExport    = "llm_usage_alloc"
+	getUsageExport = "llm_usage_get_usage"
+
+	// maxResponseBytes caps the size of a fetched response body handed to the guest
+	maxResponseBytes = 4 << 20
+)
+
+// fetchRequest is the JSON request passed by the guest to http_fetch
+type fetchRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// fetchResponse is the JSON response returned to the guest from http_fetch
+type fetchResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// host provides the capabilities exposed to a single plugin instance
+type host struct {
+	plugin     *Plugin
+	httpClient *http.Client
+}
+
+// newHost creates the host of a plugin instance. Redirects are checked
+// against allowed_hosts like the original request.
+func newHost(plugin *Plugin) *host {
+	return &host{
+		plugin: plugin,
+		httpClient: &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 10 {
+					return fmt.Errorf("stopped after 10 redirects")
+				}
+				return plugin.hostAllowed(req.URL.String())
+			},
+		},
+	}
+}
+
+// instantiate registers the llm_usage host module in the runtime
+func (h *host) instantiate(ctx context.Context, r wazero.Runtime) error {
+	_, err := r.NewHostModuleBuilder(hostModuleName).
+		NewFunctionBuilder().WithFunc(h.httpFetch).Export("http_fetch").
+		NewFunctionBuilder().WithFunc(h.log).Export("log").
+		Instantiate(ctx)
+	return err
+}
+
+// httpFetch performs an allow-listed HTTP request on behalf of the guest
+func (h *host) httpFetch(ctx context.Context, m api.Module, reqPtr, reqLen uint32) uint64 {
+	resp := h.doFetch(ctx, m, reqPtr, reqLen)
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return 0
+	}
+	packed, err := writeGuest(ctx, m, data)
+	if err != nil {
+		return 0
+	}
+	return packed
+}
+
+func (h *host) doFetch(ctx context.Context, m api.Module, reqPtr, reqLen uint32) fetchResponse {
+	raw, ok := m.Memory().Read(reqPtr, reqLen)
+	if !ok {
+		return fetchResponse{Error: "request out of memory bounds"}
+	}
+
+	var fr fetchRequest
+	if err := json.Unmarshal(raw, &fr); err != nil {
+		return fetchResponse{Error: "invalid request: " + err.Error()}
+	}
+	return h.fetch(ctx, fr)
+}
+
+// fetch performs a guest request if its host is allowed
+func (h *host) fetch(ctx context.Context, fr fetchRequest) fetchResponse {
+	if fr.Method == "" {
+		fr.Method = http.MethodGet
+	}
+
+	if err := h.plugin.hostAllowed(fr.URL); err != nil {
+		return fetchResponse{Error: err.Error()}
+	}
+
+	var body io.Reader
+	if fr.Body != "" {
+		body = bytes.NewBufferString(fr.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, fr.Method, fr.URL, body)
+	if err != nil {
+		return fetchResponse{Error: "failed to create request: " + err.Error()}
+	}
+	req.Header.Set("User-Agent", "llm-usage/"+version.Version)
+	for k, v := range fr.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fetchResponse{Error: "failed to execute request: " + err.Error()}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return fetchResponse{Error: "failed to read response body: " + err.Error()}
+	}
+
+	headers := make(map[string]string, len(resp.Header))
+	for k := range resp.Header {
+		headers[k] = resp.Header.Get(k)
+	}
+
+	return fetchResponse{
+		Status:  resp.StatusCode,
+		Headers: headers,
+		Body:    string(respBody),
+	}
+}
+
+// log writes a guest log message to stderr
+func (h *host) log(_ context.Context, m api.Module, ptr, length uint32) {
+	if msg, ok := m.Memory().Read(ptr, length); ok {
+		fmt.Fprintf(os.Stderr, "[plugin %s] %s\n", h.plugin.ID, msg)
+	}
+}
+
+// writeGuest copies data into guest memory allocated through the guest's
+// allocator and returns the packed (ptr << 32 | len) location
+func writeGuest(ctx context.Context, m api.Module, data []byte) (uint64, error) {
+	alloc := m.ExportedFunction(allocExport)
+	if alloc == nil {
+		return 0, fmt.Errorf("plugin does not export %s", allocExport)
+	}
+
+	res, err := alloc.Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate guest memory: %w", err)
+	}
+
+	ptr := uint32(res[0])
+	if !m.Memory().Write(ptr, data) {
+		return 0, fmt.Errorf("guest allocation out of memory bounds")
+	}
+
+	return pack(ptr, uint32(len(data))), nil
+}
+
+// pack combines a guest pointer and length into a single i64
+func pack(ptr, length uint32) uint64 {
+	return uint64(ptr)<<32 | uint64(length)
+}
+
+// unpack splits a packed i64 into a guest pointer and length
+func unpack(v uint64) (ptr, length uint32) {
+	return uint32(v >> 32), uint32(v)
+}
@@ -0,0 +1,162 @@
+// Package wasm implements sandboxed WebAssembly provider plugins for llm-usage.
+//
+// A plugin is a WebAssembly module placed in the plugins directory as
+// <id>.wasm, with an optional <id>.json manifest next to it. Plugins run
+// inside wazero without filesystem access, environment variables or network
+// sockets. The only capability they get is an HTTP fetch mediated by the host,
+// restricted to the hosts listed in the manifest.
+//
+// # ABI
+//
+// The guest must export:
+//
+//	memory
+//	llm_usage_alloc(size i32) -> i32
+//	llm_usage_get_usage(config_ptr i32, config_len i32) -> i64
+//
+// llm_usage_get_usage receives the account's credential values as a JSON
+// object and returns a packed (ptr << 32 | len) pointer to a JSON result:
+//
+//	{"windows": [{"label": "Daily", "utilization": 42.0, ...}], "extra": {...}, "error": ""}
+//
+// The host provides the following imports in the "llm_usage" module:
+//
+//	http_fetch(req_ptr i32, req_len i32) -> i64
+//	log(ptr i32, len i32)
+//
+// http_fetch takes a JSON request {"method", "url", "headers", "body"} and
+// returns a packed pointer to a JSON response {"status", "headers", "body",
+// "error"} allocated in guest memory through llm_usage_alloc.
+//
+// Modules built for WASI (TinyGo, Rust wasm32-wasip1) are supported. An
+// exported _initialize function is called once before llm_usage_get_usage.
+package wasm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PluginsDir is the directory, relative to the config directory, that holds plugins
+const PluginsDir = "plugins"
+
+const defaultTimeout = 30 * time.Second
+
+// Manifest describes a plugin's metadata and the capabilities it is granted
+type Manifest struct {
+	// Name is the provider's display name (defaults to the ID)
+	Name string `json:"name"`
+
+	// AllowedHosts lists the hosts the plugin may fetch from.
+	// Entries may use a leading wildcard, e.g. "*.example.com".
+	AllowedHosts []string `json:"allowed_hosts"`
+
+	// TimeoutSeconds bounds a single GetUsage call, including all fetches
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// Plugin is a loaded WebAssembly provider plugin
+type Plugin struct {
+	ID       string
+	Path     string
+	Manifest Manifest
+	code     []byte
+}
+
+// Timeout returns the maximum duration of a single GetUsage call
+func (p *Plugin) Timeout() time.Duration {
+	if p.Manifest.TimeoutSeconds > 0 {
+		return time.Duration(p.Manifest.TimeoutSeconds) * time.Second
+	}
+	return defaultTimeout
+}
+
+// LoadPlugin reads a plugin module and its optional manifest
+func LoadPlugin(path string) (*Plugin, error) {
+	code, err := os.ReadFile(path) //nolint:gosec // Path comes from the user's config directory
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin: %w", err)
+	}
+
+	id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	p := &Plugin{
+		ID:   id,
+		Path: path,
+		code: code,
+	}
+
+	manifestPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".json"
+	data, err := os.ReadFile(manifestPath) //nolint:gosec // Path comes from the user's config directory
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &p.Manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse plugin manifest %s: %w", manifestPath, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read plugin manifest: %w", err)
+	}
+
+	if p.Manifest.Name == "" {
+		p.Manifest.Name = id
+	}
+
+	return p, nil
+}
+
+// LoadPlugins reads all plugins from a directory.
+// A missing directory is not an error.
+func LoadPlugins(dir string) ([]*Plugin, []error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, []error{fmt.Errorf("failed to read plugins: %w", err)}
+	}
+
+	var plugins []*Plugin
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".wasm" {
+			continue
+		}
+		p, err := LoadPlugin(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, errs
+}
+
+// hostAllowed reports whether the plugin may fetch the given URL
+func (p *Plugin) hostAllowed(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("scheme %q not allowed", u.Scheme)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range p.Manifest.AllowedHosts {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return nil
+			}
+			continue
+		}
+		if host == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("host %q is not in the plugin's allowed_hosts", host)
+}
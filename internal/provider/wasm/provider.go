@@ -0,0 +1,137 @@
+package wasm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// memoryLimitPages caps guest memory at 16 MiB (64 KiB pages)
+const memoryLimitPages = 256
+
+// compilationCache is shared so each account doesn't recompile the same module
+var compilationCache = wazero.NewCompilationCache()
+
+// pluginResult is the JSON document returned by llm_usage_get_usage
+type pluginResult struct {
+	Windows []provider.UsageWindow `json:"windows"`
+	Extra   map[string]any         `json:"extra"`
+	Error   string                 `json:"error"`
+}
+
+// Provider implements the provider.Provider interface for a WebAssembly plugin
+type Provider struct {
+	plugin  *Plugin
+	account credentials.GenericAccount
+}
+
+// NewProvider creates a new provider running the plugin with the account's credential values
+func NewProvider(plugin *Plugin, account credentials.GenericAccount) *Provider {
+	return &Provider{
+		plugin:  plugin,
+		account: account,
+	}
+}
+
+// Name returns the provider's display name
+func (p *Provider) Name() string {
+	return p.plugin.Manifest.Name
+}
+
+// ID returns the provider's unique identifier
+func (p *Provider) ID() string {
+	return p.plugin.ID
+}
+
+// GetUsage runs the plugin in a fresh sandbox and returns its usage statistics
+func (p *Provider) GetUsage() (*provider.Usage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.plugin.Timeout())
+	defer cancel()
+
+	rtConfig := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(memoryLimitPages).
+		WithCloseOnContextDone(true).
+		WithCompilationCache(compilationCache)
+	r := wazero.NewRuntimeWithConfig(ctx, rtConfig)
+	defer func() { _ = r.Close(ctx) }()
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+
+	h := newHost(p.plugin)
+	if err := h.instantiate(ctx, r); err != nil {
+		return nil, fmt.Errorf("failed to instantiate host module: %w", err)
+	}
+
+	// No filesystem, environment or arguments are exposed to the guest
+	modConfig := wazero.NewModuleConfig().
+		WithName(p.plugin.ID).
+		WithStartFunctions("_initialize").
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader).
+		WithStderr(os.Stderr)
+
+	mod, err := r.InstantiateWithConfig(ctx, p.plugin.code, modConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate plugin: %w", err)
+	}
+
+	getUsage := mod.ExportedFunction(getUsageExport)
+	if getUsage == nil {
+		return nil, fmt.Errorf("plugin does not export %s", getUsageExport)
+	}
+
+	config, err := json.Marshal(p.account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal plugin config: %w", err)
+	}
+
+	packed, err := writeGuest(ctx, mod, config)
+	if err != nil {
+		return nil, err
+	}
+	cfgPtr, cfgLen := unpack(packed)
+
+	res, err := getUsage.Call(ctx, uint64(cfgPtr), uint64(cfgLen))
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("plugin timed out after %s", p.plugin.Timeout())
+		}
+		return nil, fmt.Errorf("plugin call failed: %w", err)
+	}
+
+	resPtr, resLen := unpack(res[0])
+	data, ok := mod.Memory().Read(resPtr, resLen)
+	if !ok {
+		return nil, fmt.Errorf("plugin result out of memory bounds")
+	}
+
+	var result pluginResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse plugin result: %w", err)
+	}
+	if result.Error != "" {
+		return nil, errors.New(result.Error)
+	}
+
+	windows := result.Windows
+	if windows == nil {
+		windows = make([]provider.UsageWindow, 0)
+	}
+
+	return &provider.Usage{
+		Provider: p.plugin.ID,
+		Windows:  windows,
+		Extra:    result.Extra,
+	}, nil
+}
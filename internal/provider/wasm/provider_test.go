@@ -0,0 +1,209 @@
+package wasm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+)
+
+// buildStaticPlugin assembles a minimal module that ignores its config and
+// returns the given JSON result from llm_usage_get_usage
+func buildStaticPlugin(result string) []byte {
+	const resultOffset = 1024
+	const allocOffset = 4096
+
+	section := func(id byte, content []byte) []byte {
+		return append(append([]byte{id}, uleb(uint64(len(content)))...), content...)
+	}
+	name := func(s string) []byte {
+		return append(uleb(uint64(len(s))), s...)
+	}
+	body := func(code ...byte) []byte {
+		b := append([]byte{0x00}, code...) // no locals
+		return append(uleb(uint64(len(b))), b...)
+	}
+
+	types := []byte{
+		0x02,
+		0x60, 0x01, 0x7f, 0x01, 0x7f, // (i32) -> i32
+		0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e, // (i32, i32) -> i64
+	}
+	funcs := []byte{0x02, 0x00, 0x01}
+	memory := []byte{0x01, 0x00, 0x01}
+
+	exports := []byte{0x03}
+	exports = append(append(exports, name("memory")...), 0x02, 0x00)
+	exports = append(append(exports, name(allocExport)...), 0x00, 0x00)
+	exports = append(append(exports, name(getUsageExport)...), 0x00, 0x01)
+
+	allocBody := body(append(append([]byte{0x41}, sleb(allocOffset)...), 0x0b)...)
+	usageBody := body(append(append([]byte{0x42}, sleb(int64(pack(resultOffset, uint32(len(result)))))...), 0x0b)...)
+	code := append(append([]byte{0x02}, allocBody...), usageBody...)
+
+	data := []byte{0x01, 0x00, 0x41}
+	data = append(data, sleb(resultOffset)...)
+	data = append(data, 0x0b)
+	data = append(data, name(result)...)
+
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, section(1, types)...)
+	module = append(module, section(3, funcs)...)
+	module = append(module, section(5, memory)...)
+	module = append(module, section(7, exports)...)
+	module = append(module, section(10, code)...)
+	module = append(module, section(11, data)...)
+	return module
+}
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if v == 0 {
+			return out
+		}
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		done := (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0)
+		if !done {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if done {
+			return out
+		}
+	}
+}
+
+func writePlugin(t *testing.T, dir, id string, code []byte, manifest string) string {
+	t.Helper()
+	path := filepath.Join(dir, id+".wasm")
+	if err := os.WriteFile(path, code, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if manifest != "" {
+		if err := os.WriteFile(filepath.Join(dir, id+".json"), []byte(manifest), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestProvider_GetUsage(t *testing.T) {
+	dir := t.TempDir()
+	result := `{"windows":[{"label":"Daily","utilization":42.5}],"extra":{"plan":"pro"}}`
+	path := writePlugin(t, dir, "acme", buildStaticPlugin(result), `{"name":"Acme"}`)
+
+	plugin, err := LoadPlugin(path)
+	if err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+	if plugin.Manifest.Name != "Acme" {
+		t.Errorf("Name = %q, want %q", plugin.Manifest.Name, "Acme")
+	}
+
+	p := NewProvider(plugin, credentials.GenericAccount{"token": "secret"})
+	usage, err := p.GetUsage()
+	if err != nil {
+		t.Fatalf("GetUsage failed: %v", err)
+	}
+
+	if usage.Provider != "acme" {
+		t.Errorf("Provider = %q, want %q", usage.Provider, "acme")
+	}
+	if len(usage.Windows) != 1 || usage.Windows[0].Label != "Daily" || usage.Windows[0].Utilization != 42.5 {
+		t.Errorf("Windows = %+v, want one Daily window at 42.5", usage.Windows)
+	}
+	if usage.Extra["plan"] != "pro" {
+		t.Errorf("Extra[plan] = %v, want pro", usage.Extra["plan"])
+	}
+}
+
+func TestProvider_PluginError(t *testing.T) {
+	dir := t.TempDir()
+	path := writePlugin(t, dir, "acme", buildStaticPlugin(`{"error":"invalid token"}`), "")
+
+	plugin, err := LoadPlugin(path)
+	if err != nil {
+		t.Fatalf("LoadPlugin failed: %v", err)
+	}
+
+	_, err = NewProvider(plugin, nil).GetUsage()
+	if err == nil || err.Error() != "invalid token" {
+		t.Errorf("GetUsage error = %v, want %q", err, "invalid token")
+	}
+}
+
+func TestPlugin_HostAllowed(t *testing.T) {
+	p := &Plugin{Manifest: Manifest{AllowedHosts: []string{"api.example.com", "*.acme.ai"}}}
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://api.example.com/v1/usage", true},
+		{"https://API.example.com/v1/usage", true},
+		{"https://eu.acme.ai/quota", true},
+		{"https://acme.ai/quota", false},
+		{"https://evil.example.com/", false},
+		{"file:///etc/passwd", false},
+		{"ftp://api.example.com/", false},
+	}
+
+	for _, tc := range tests {
+		err := p.hostAllowed(tc.url)
+		if (err == nil) != tc.allowed {
+			t.Errorf("hostAllowed(%q) = %v, want allowed=%v", tc.url, err, tc.allowed)
+		}
+	}
+}
+
+func TestHost_FetchRedirect(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("secret"))
+	}))
+	defer target.Close()
+	// The allowed host is 127.0.0.1; the redirect goes to localhost
+	targetURL := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	allowed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, targetURL, http.StatusFound)
+	}))
+	defer allowed.Close()
+
+	h := newHost(&Plugin{Manifest: Manifest{AllowedHosts: []string{"127.0.0.1"}}})
+	resp := h.fetch(context.Background(), fetchRequest{URL: allowed.URL})
+	if resp.Error == "" || resp.Body == "secret" {
+		t.Errorf("fetch = %+v, want the redirect to localhost rejected", resp)
+	}
+}
+
+func TestLoadPlugins(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "one", buildStaticPlugin(`{}`), "")
+	writePlugin(t, dir, "two", buildStaticPlugin(`{}`), `{not json`)
+
+	plugins, errs := LoadPlugins(dir)
+	if len(plugins) != 1 || plugins[0].ID != "one" {
+		t.Errorf("got plugins %v, want [one]", plugins)
+	}
+	if len(errs) != 1 {
+		t.Errorf("got %d errors, want 1", len(errs))
+	}
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SchemaVersion is the version of the JSON usage format. Version 2 added
+// the structured window metadata (kind, duration, start, unit and model),
+// version 3 moved subscriptions from extra.subscription to a typed field.
+const SchemaVersion = 3
+
+// WindowKind describes how a usage window resets
+type WindowKind string
+
+// Window kinds
+const (
+	KindRolling   WindowKind = "rolling"    // slides with time, e.g. the last 5 hours
+	KindFixed     WindowKind = "fixed"      // resets at a fixed time, e.g. a weekly quota
+	KindMonthly   WindowKind = "monthly"    // resets with the billing month
+	KindRateLimit WindowKind = "rate_limit" // short burst limit, e.g. per minute
+)
+
+// Unit is what a usage window counts
+type Unit string
+
+// Window units
+const (
+	UnitTokens   Unit = "tokens"
+	UnitRequests Unit = "requests"
+	UnitPrompts  Unit = "prompts"
+	UnitCredits  Unit = "credits"
+	UnitPercent  Unit = "percent" // only the utilization is known
+)
+
+// Duration is a time.Duration encoded in JSON as a Go duration string, e.g. "5h0m0s"
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Start returns when the window started: StartsAt, or ResetsAt minus the
+// window duration. It returns nil when neither is known.
+func (w *UsageWindow) Start() *time.Time {
+	if w.StartsAt != nil {
+		return w.StartsAt
+	}
+	if w.ResetsAt == nil || w.Duration <= 0 {
+		return nil
+	}
+	start := w.ResetsAt.Add(-time.Duration(w.Duration))
+	return &start
+}
+
+// MarshalJSON encodes the stats with the schema version
+func (s UsageStats) MarshalJSON() ([]byte, error) {
+	type stats UsageStats
+	return json.Marshal(struct {
+		SchemaVersion int `json:"schema_version"`
+		stats
+	}{SchemaVersion, stats(s)})
+}
@@ -0,0 +1,214 @@
+// Package quota decides whether an account has quota left and waits until it has.
+package quota
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// ResetGrace is added to a window's reset time before polling again, since
+// providers may take a moment to report the new window
+const ResetGrace = 30 * time.Second
+
+// ErrUnknownWindow is returned when a condition names a window the usage doesn't have
+var ErrUnknownWindow = errors.New("unknown window")
+
+// Condition is a utilization threshold on one window, or on every window when
+// Window is empty
+type Condition struct {
+	Window string  // window label (case-insensitive), empty for all windows
+	Below  float64 // utilization percentage that must not be reached
+}
+
+// Status is the outcome of evaluating a Condition
+type Status struct {
+	Met         bool
+	Window      string     // label of the most utilized matched window
+	Utilization float64    // its utilization
+	ResetsAt    *time.Time // when all blocking windows reset, nil if unknown or met
+}
+
+// Evaluate checks the condition against an account's usage
+func (c Condition) Evaluate(u provider.Usage) (Status, error) {
+	var (
+		status   = Status{Met: true}
+		matched  bool
+		known    = true
+		resetsAt time.Time
+	)
+	for _, w := range u.Windows {
+		if c.Window != "" && !strings.EqualFold(w.Label, c.Window) {
+			continue
+		}
+		if !matched || w.Utilization > status.Utilization {
+			status.Window = w.Label
+			status.Utilization = w.Utilization
+		}
+		matched = true
+
+		if w.Utilization < c.Below {
+			continue
+		}
+		// Every blocking window has to reset before the condition holds
+		status.Met = false
+		if w.ResetsAt == nil {
+			known = false
+		} else if w.ResetsAt.After(resetsAt) {
+			resetsAt = *w.ResetsAt
+		}
+	}
+
+	if !matched {
+		if c.Window == "" {
+			return Status{}, fmt.Errorf("%s reports no usage windows", u.Provider)
+		}
+		labels := make([]string, len(u.Windows))
+		for i, w := range u.Windows {
+			labels[i] = w.Label
+		}
+		return Status{}, fmt.Errorf("%w %q for %s (available: %s)", ErrUnknownWindow, c.Window, u.Provider, strings.Join(labels, ", "))
+	}
+	if !status.Met && known {
+		status.ResetsAt = &resetsAt
+	}
+	return status, nil
+}
+
+// String describes the condition, e.g. "5-Hour below 80%"
+func (c Condition) String() string {
+	window := c.Window
+	if window == "" {
+		window = "all windows"
+	}
+	return fmt.Sprintf("%s below %.0f%%", window, c.Below)
+}
+
+// Backoff doubles the delay between polls from Min up to Max
+type Backoff struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// Delay returns how long to wait before the next poll, sleeping until the
+// blocking windows reset when that is known
+func (b Backoff) Delay(s Status, attempt int, now time.Time) time.Duration {
+	if s.ResetsAt != nil && s.ResetsAt.After(now) {
+		return s.ResetsAt.Sub(now) + ResetGrace
+	}
+	d := b.Min
+	for i := 0; i < attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	return min(d, b.Max)
+}
+
+// FetchFunc fetches the usage of the account being waited on
+type FetchFunc func(ctx context.Context) (provider.Usage, error)
+
+// ProgressFunc is called after every poll with its result and the delay before the next one
+type ProgressFunc func(s Status, err error, next time.Duration)
+
+// Wait polls until the condition holds or ctx is done. Fetch errors are
+// retried with backoff; an unknown window ends the wait immediately.
+func Wait(ctx context.Context, fetch FetchFunc, c Condition, b Backoff, progress ProgressFunc) (Status, error) {
+	var last Status
+	for attempt := 0; ; attempt++ {
+		s, err := poll(ctx, fetch, c)
+		if errors.Is(err, ErrUnknownWindow) {
+			return s, err
+		}
+		if err == nil {
+			if s.Met {
+				return s, nil
+			}
+			last = s
+		}
+
+		delay := b.Delay(s, attempt, time.Now())
+		if progress != nil {
+			progress(s, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return last, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func poll(ctx context.Context, fetch FetchFunc, c Condition) (Status, error) {
+	u, err := fetch(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+	if u.Error != nil {
+		return Status{}, u.Error
+	}
+	return c.Evaluate(u)
+}
+
+// ErrNoAccount is returned by Pick when no account could be evaluated
+var ErrNoAccount = errors.New("no usable account")
+
+// Candidate is an account considered by Pick
+type Candidate struct {
+	Account string
+	Usage   provider.Usage
+	Status  Status
+}
+
+// Pick chooses the account to run under. With a chain it returns the first
+// listed account meeting the condition; otherwise the account with the lowest
+// utilization. ok is false when no account meets the condition, in which case
+// the least utilized account is returned. Failed fetches are skipped.
+func Pick(usages []provider.Usage, c Condition, chain []string) (Candidate, bool, error) {
+	var (
+		candidates []Candidate
+		errs       []error
+	)
+	for _, u := range usages {
+		account, _ := u.Extra["account"].(string)
+		if u.Error != nil {
+			errs = append(errs, u.Error)
+			continue
+		}
+		if len(chain) > 0 && !slices.Contains(chain, account) {
+			continue
+		}
+		s, err := c.Evaluate(u)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		candidates = append(candidates, Candidate{Account: account, Usage: u, Status: s})
+	}
+	if len(candidates) == 0 {
+		return Candidate{}, false, errors.Join(append([]error{ErrNoAccount}, errs...)...)
+	}
+
+	// Least utilized first; the chain order wins where given
+	slices.SortStableFunc(candidates, func(a, b Candidate) int {
+		if len(chain) > 0 {
+			return slices.Index(chain, a.Account) - slices.Index(chain, b.Account)
+		}
+		return cmp.Compare(a.Status.Utilization, b.Status.Utilization)
+	})
+	for _, cand := range candidates {
+		if cand.Status.Met {
+			return cand, true, nil
+		}
+	}
+	return slices.MinFunc(candidates, func(a, b Candidate) int {
+		return cmp.Compare(a.Status.Utilization, b.Status.Utilization)
+	}), false, nil
+}
@@ -0,0 +1,156 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	soon, later := now.Add(time.Hour), now.Add(48*time.Hour)
+	u := provider.Usage{Provider: "claude", Windows: []provider.UsageWindow{
+		{Label: "5-Hour", Utilization: 92, ResetsAt: &soon},
+		{Label: "7-Day", Utilization: 81, ResetsAt: &later},
+		{Label: "Opus", Utilization: 95},
+	}}
+
+	tests := []struct {
+		name     string
+		cond     Condition
+		met      bool
+		window   string
+		resetsAt *time.Time
+	}{
+		{"window met", Condition{Window: "7-day", Below: 90}, true, "7-Day", nil},
+		{"window blocked", Condition{Window: "5-Hour", Below: 80}, false, "5-Hour", &soon},
+		{"latest reset", Condition{Window: "7-Day", Below: 80}, false, "7-Day", &later},
+		{"unknown reset", Condition{Below: 80}, false, "Opus", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.cond.Evaluate(u)
+			if err != nil {
+				t.Fatalf("Evaluate() failed: %v", err)
+			}
+			if s.Met != tt.met || s.Window != tt.window {
+				t.Errorf("Evaluate() = met %v window %q, want met %v window %q", s.Met, s.Window, tt.met, tt.window)
+			}
+			if (s.ResetsAt == nil) != (tt.resetsAt == nil) || (s.ResetsAt != nil && !s.ResetsAt.Equal(*tt.resetsAt)) {
+				t.Errorf("Evaluate().ResetsAt = %v, want %v", s.ResetsAt, tt.resetsAt)
+			}
+		})
+	}
+
+	if _, err := (Condition{Window: "Daily", Below: 50}).Evaluate(u); !errors.Is(err, ErrUnknownWindow) {
+		t.Errorf("Evaluate(Daily) error = %v, want ErrUnknownWindow", err)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := Backoff{Min: time.Minute, Max: 10 * time.Minute}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{3, 8 * time.Minute},
+		{10, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := b.Delay(Status{}, tt.attempt, now); got != tt.want {
+			t.Errorf("Delay(attempt %d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+
+	reset := now.Add(3 * time.Hour)
+	if got, want := b.Delay(Status{ResetsAt: &reset}, 0, now), 3*time.Hour+ResetGrace; got != want {
+		t.Errorf("Delay(reset) = %v, want %v", got, want)
+	}
+	past := now.Add(-time.Minute)
+	if got := b.Delay(Status{ResetsAt: &past}, 0, now); got != time.Minute {
+		t.Errorf("Delay(past reset) = %v, want %v", got, time.Minute)
+	}
+}
+
+func TestWait(t *testing.T) {
+	utils := []float64{95, 0, 85, 40}
+	calls := 0
+	fetch := func(context.Context) (provider.Usage, error) {
+		calls++
+		if utils[calls-1] == 0 {
+			return provider.Usage{}, errors.New("timeout")
+		}
+		return provider.Usage{Provider: "kimi", Windows: []provider.UsageWindow{{Label: "Weekly", Utilization: utils[calls-1]}}}, nil
+	}
+
+	var polls int
+	s, err := Wait(context.Background(), fetch, Condition{Below: 50}, Backoff{Min: time.Millisecond, Max: time.Millisecond},
+		func(Status, error, time.Duration) { polls++ })
+	if err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+	if !s.Met || s.Utilization != 40 || calls != 4 || polls != 3 {
+		t.Errorf("Wait() = %+v after %d calls and %d progress reports", s, calls, polls)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	calls = 0
+	utils = []float64{95, 95, 95, 95, 95, 95, 95, 95}
+	s, err = Wait(ctx, fetch, Condition{Below: 50}, Backoff{Min: 5 * time.Millisecond, Max: time.Second}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() error = %v, want deadline exceeded", err)
+	}
+	if s.Utilization != 95 {
+		t.Errorf("Wait() last status = %+v, want 95%% utilization", s)
+	}
+}
+
+func TestPick(t *testing.T) {
+	account := func(name string, util float64) provider.Usage {
+		return provider.Usage{Provider: "claude", Extra: map[string]any{"account": name},
+			Windows: []provider.UsageWindow{{Label: "5-Hour", Utilization: util}}}
+	}
+	usages := []provider.Usage{
+		account("work", 70),
+		account("home", 20),
+		account("spare", 95),
+		{Provider: "claude", Extra: map[string]any{"account": "broken"}, Error: errors.New("unauthorized")},
+	}
+
+	tests := []struct {
+		name    string
+		below   float64
+		chain   []string
+		account string
+		ok      bool
+	}{
+		{"least utilized", 80, nil, "home", true},
+		{"chain order", 80, []string{"spare", "work", "home"}, "work", true},
+		{"chain skips missing", 80, []string{"broken", "home"}, "home", true},
+		{"none below", 10, nil, "home", false},
+		{"chain none below", 50, []string{"spare", "work"}, "work", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := Pick(usages, Condition{Below: tt.below}, tt.chain)
+			if err != nil {
+				t.Fatalf("Pick() failed: %v", err)
+			}
+			if got.Account != tt.account || ok != tt.ok {
+				t.Errorf("Pick() = %q, %v, want %q, %v", got.Account, ok, tt.account, tt.ok)
+			}
+		})
+	}
+
+	if _, _, err := Pick(usages[3:], Condition{Below: 80}, nil); !errors.Is(err, ErrNoAccount) {
+		t.Errorf("Pick(failed only) error = %v, want ErrNoAccount", err)
+	}
+}
@@ -0,0 +1,208 @@
+// Package report implements signed usage reports pushed to a llm-usage server
+package report
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/version"
+)
+
+// Path is the server endpoint accepting reports
+const Path = "/api/v1/reports"
+
+// Headers carrying the report signature
+const (
+	HeaderReporter  = "X-LLM-Usage-Reporter"
+	HeaderTimestamp = "X-LLM-Usage-Timestamp"
+	HeaderNonce     = "X-LLM-Usage-Nonce"
+	HeaderSignature = "X-LLM-Usage-Signature"
+)
+
+// MaxSkew is how far a report's timestamp may be from the server's clock
+const MaxSkew = 5 * time.Minute
+
+// Verification errors
+var (
+	ErrUnknownReporter  = errors.New("unknown reporter")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrStale            = errors.New("report timestamp outside allowed window")
+	ErrReplayed         = errors.New("report nonce already used")
+)
+
+var httpClient = &http.Client{
+	Timeout: 60 * time.Second,
+}
+
+// Sign computes the hex HMAC-SHA256 signature of a report body
+func Sign(key, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Push sends a signed usage report to a llm-usage server
+func Push(ctx context.Context, baseURL, reporter, key string, stats *provider.UsageStats) error {
+	body, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(baseURL, "/")+Path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "llm-usage/"+version.Version)
+	req.Header.Set(HeaderReporter, reporter)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderSignature, Sign(key, timestamp, nonce, body))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach server: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("server returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// newNonce returns a random hex nonce
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Verifier checks report signatures and rejects stale or replayed reports
+type Verifier struct {
+	keys map[string]string
+
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	now    func() time.Time
+}
+
+// NewVerifier creates a verifier for the given reporter keys
+func NewVerifier(keys map[string]string) *Verifier {
+	return &Verifier{
+		keys:   keys,
+		nonces: make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+// Verify checks the request's signature headers against body and returns
+// the reporter name and report timestamp
+func (v *Verifier) Verify(header http.Header, body []byte) (string, time.Time, error) {
+	reporter := header.Get(HeaderReporter)
+	key, ok := v.keys[reporter]
+	if !ok || reporter == "" {
+		return "", time.Time{}, ErrUnknownReporter
+	}
+
+	timestamp := header.Get(HeaderTimestamp)
+	nonce := header.Get(HeaderNonce)
+	signature := header.Get(HeaderSignature)
+
+	expected := Sign(key, timestamp, nonce, body)
+	if nonce == "" || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "", time.Time{}, ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", time.Time{}, ErrStale
+	}
+	sentAt := time.Unix(unix, 0)
+
+	now := v.now()
+	if sentAt.Before(now.Add(-MaxSkew)) || sentAt.After(now.Add(MaxSkew)) {
+		return "", time.Time{}, ErrStale
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Nonces only need remembering while their timestamp is still accepted
+	for n, seen := range v.nonces {
+		if now.Sub(seen) > 2*MaxSkew {
+			delete(v.nonces, n)
+		}
+	}
+
+	nonceKey := reporter + "\n" + nonce
+	if _, seen := v.nonces[nonceKey]; seen {
+		return "", time.Time{}, ErrReplayed
+	}
+	v.nonces[nonceKey] = now
+
+	return reporter, sentAt, nil
+}
+
+// KeyEnv prefixes the environment variables holding reporter keys, e.g.
+// LLM_USAGE_REPORTER_KEY_ALICE for reporter alice
+const KeyEnv = "LLM_USAGE_REPORTER_KEY"
+
+// ParseKeys parses --reporter-key name=key specifications. A key may be read
+// from a file as name=@path, or from $LLM_USAGE_REPORTER_KEY_<NAME> when only
+// the name is given.
+func ParseKeys(specs []string) (map[string]string, error) {
+	keys := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		name, key, ok := strings.Cut(spec, "=")
+		if !ok {
+			key = os.Getenv(credentials.SecretEnv(KeyEnv, name))
+		}
+		if name == "" || key == "" {
+			return nil, fmt.Errorf("invalid reporter key %q, expected name=key, name=@path or name with $%s", spec, credentials.SecretEnv(KeyEnv, "<NAME>"))
+		}
+		key, err := credentials.ReadSecret(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reporter key for %q: %w", name, err)
+		}
+		if key == "" {
+			return nil, fmt.Errorf("empty reporter key for %q", name)
+		}
+		if _, dup := keys[name]; dup {
+			return nil, fmt.Errorf("duplicate reporter %q", name)
+		}
+		keys[name] = key
+	}
+	return keys, nil
+}
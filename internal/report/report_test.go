@@ -0,0 +1,77 @@
+package report
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signedHeader(reporter, key, nonce string, sentAt time.Time, body []byte) http.Header {
+	timestamp := strconv.FormatInt(sentAt.Unix(), 10)
+	h := http.Header{}
+	h.Set(HeaderReporter, reporter)
+	h.Set(HeaderTimestamp, timestamp)
+	h.Set(HeaderNonce, nonce)
+	h.Set(HeaderSignature, Sign(key, timestamp, nonce, body))
+	return h
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"providers":[]}`)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		want   error
+	}{
+		{"valid", signedHeader("alice", "k1", "n1", now, body), body, nil},
+		{"unknown reporter", signedHeader("mallory", "k1", "n2", now, body), body, ErrUnknownReporter},
+		{"wrong key", signedHeader("alice", "other", "n3", now, body), body, ErrInvalidSignature},
+		{"tampered body", signedHeader("alice", "k1", "n4", now, body), []byte(`{"providers":null}`), ErrInvalidSignature},
+		{"stale", signedHeader("alice", "k1", "n5", now.Add(-10*time.Minute), body), body, ErrStale},
+		{"future", signedHeader("alice", "k1", "n6", now.Add(10*time.Minute), body), body, ErrStale},
+		{"replayed", signedHeader("alice", "k1", "n1", now, body), body, ErrReplayed},
+	}
+
+	v := NewVerifier(map[string]string{"alice": "k1"})
+	v.now = func() time.Time { return now }
+
+	for _, tc := range tests {
+		reporter, _, err := v.Verify(tc.header, tc.body)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: Verify error = %v, want %v", tc.name, err, tc.want)
+		}
+		if tc.want == nil && reporter != "alice" {
+			t.Errorf("%s: reporter = %q, want alice", tc.name, reporter)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "carol.key")
+	if err := os.WriteFile(keyFile, []byte("k3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LLM_USAGE_REPORTER_KEY_DAVE", "k4")
+	t.Setenv("LLM_USAGE_REPORTER_KEY_ERIN", "")
+
+	keys, err := ParseKeys([]string{"alice=k1", "bob=k=2", "carol=@" + keyFile, "dave"})
+	if err != nil {
+		t.Fatalf("ParseKeys failed: %v", err)
+	}
+	if keys["alice"] != "k1" || keys["bob"] != "k=2" || keys["carol"] != "k3" || keys["dave"] != "k4" {
+		t.Errorf("keys = %v", keys)
+	}
+
+	for _, spec := range []string{"erin", "=k1", "alice=", "alice=@" + keyFile + ".missing"} {
+		if _, err := ParseKeys([]string{spec}); err == nil {
+			t.Errorf("ParseKeys(%q) succeeded, want error", spec)
+		}
+	}
+}
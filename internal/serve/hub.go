@@ -0,0 +1,235 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+const defaultPeerInterval = time.Minute
+
+// Peer is another llm-usage server polled in hub mode
+type Peer struct {
+	Name  string
+	URL   string
+	Token string
+}
+
+// PeerStatus is the latest known state of a peer
+type PeerStatus struct {
+	Name      string               `json:"name"`
+	URL       string               `json:"url"`
+	Reachable bool                 `json:"reachable"`
+	Error     string               `json:"error,omitempty"`
+	CheckedAt *time.Time           `json:"checked_at,omitempty"`
+	UpdatedAt *time.Time           `json:"updated_at,omitempty"` // last successful poll
+	Usage     *provider.UsageStats `json:"usage,omitempty"`      // last successful result, kept while unreachable
+}
+
+// TeamView is the team-wide usage served by a hub
+type TeamView struct {
+	Peers     []PeerStatus     `json:"peers"`
+	Reporters []ReporterStatus `json:"reporters"`
+
+	// Providers merges all peers' and reporters' usage, labelled with
+	// Extra["peer"] or Extra["reporter"]
+	Providers []provider.Usage `json:"providers"`
+}
+
+// PeerTokenEnv prefixes the environment variables holding peer tokens, e.g.
+// LLM_USAGE_PEER_TOKEN_ALICE for peer alice
+const PeerTokenEnv = "LLM_USAGE_PEER_TOKEN"
+
+// ParsePeers parses --peer name=url and --peer-token name=token specifications.
+// A token may be read from a file as name=@path; peers without a --peer-token
+// use $LLM_USAGE_PEER_TOKEN_<NAME>.
+func ParsePeers(specs, tokens []string) ([]Peer, error) {
+	tokenByName := make(map[string]string, len(tokens))
+	for _, spec := range tokens {
+		name, token, ok := strings.Cut(spec, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid peer token %q, expected name=token or name=@path", spec)
+		}
+		token, err := credentials.ReadSecret(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer token for %q: %w", name, err)
+		}
+		tokenByName[name] = token
+	}
+
+	peers := make([]Peer, 0, len(specs))
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		name, peerURL, ok := strings.Cut(spec, "=")
+		if !ok || name == "" || peerURL == "" {
+			return nil, fmt.Errorf("invalid peer %q, expected name=url", spec)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate peer %q", name)
+		}
+		seen[name] = true
+		token, ok := tokenByName[name]
+		if !ok {
+			token = os.Getenv(credentials.SecretEnv(PeerTokenEnv, name))
+		}
+		peers = append(peers, Peer{Name: name, URL: peerURL, Token: token})
+		delete(tokenByName, name)
+	}
+
+	if len(tokenByName) > 0 {
+		return nil, fmt.Errorf("token given for unknown peer %q", sortedKeys(tokenByName)[0])
+	}
+
+	return peers, nil
+}
+
+// hub polls peer servers and keeps their latest usage
+type hub struct {
+	peers    []Peer
+	interval time.Duration
+
+	mu     sync.RWMutex
+	status map[string]*PeerStatus
+}
+
+// newHub creates a hub for the given peers
+func newHub(peers []Peer, interval time.Duration) *hub {
+	if interval <= 0 {
+		interval = defaultPeerInterval
+	}
+	h := &hub{
+		peers:    peers,
+		interval: interval,
+		status:   make(map[string]*PeerStatus, len(peers)),
+	}
+	for _, p := range peers {
+		h.status[p.Name] = &PeerStatus{Name: p.Name, URL: p.URL}
+	}
+	return h
+}
+
+// run polls all peers until the context is cancelled
+func (h *hub) run(ctx context.Context) {
+	log.Printf("Hub mode: polling %d peers every %s", len(h.peers), h.interval)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.pollAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollAll polls every peer concurrently
+func (h *hub) pollAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, p := range h.peers {
+		wg.Add(1)
+		go func(p Peer) {
+			defer wg.Done()
+			h.poll(ctx, p)
+		}(p)
+	}
+	wg.Wait()
+}
+
+// poll fetches a single peer's usage and records the result
+func (h *hub) poll(ctx context.Context, p Peer) {
+	pollCtx, cancel := context.WithTimeout(ctx, h.interval)
+	defer cancel()
+
+	stats, err := usage.FetchRemote(pollCtx, p.URL, p.Token, "", "", usage.Selection{})
+	now := time.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st := h.status[p.Name]
+	st.CheckedAt = &now
+	if err != nil {
+		if st.Reachable {
+			log.Printf("Peer %s unreachable: %v", p.Name, err)
+		}
+		st.Reachable = false
+		st.Error = err.Error()
+		return
+	}
+
+	st.Reachable = true
+	st.Error = ""
+	st.UpdatedAt = &now
+	st.Usage = stats
+}
+
+// view returns a snapshot of the team's usage
+func (h *hub) view() TeamView {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	view := TeamView{
+		Peers:     make([]PeerStatus, 0, len(h.peers)),
+		Reporters: []ReporterStatus{},
+		Providers: make([]provider.Usage, 0),
+	}
+
+	for _, p := range h.peers {
+		st := *h.status[p.Name]
+		view.Peers = append(view.Peers, st)
+
+		if st.Usage == nil {
+			continue
+		}
+		for _, u := range st.Usage.Providers {
+			extra := make(map[string]any, len(u.Extra)+1)
+			for k, v := range u.Extra {
+				extra[k] = v
+			}
+			extra["peer"] = p.Name
+			if !st.Reachable {
+				extra["stale"] = true
+			}
+			u.Extra = extra
+			view.Providers = append(view.Providers, u)
+		}
+	}
+
+	return view
+}
+
+// handleTeam returns the team-wide usage collected from peers and reporters
+func (s *Server) handleTeam(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	view := TeamView{Peers: []PeerStatus{}, Reporters: []ReporterStatus{}, Providers: []provider.Usage{}}
+	if s.hub != nil {
+		view = s.hub.view()
+	}
+	if s.reports != nil {
+		view.Reporters = s.reports.view()
+		for _, r := range view.Reporters {
+			view.Providers = append(view.Providers, r.Providers...)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(view); err != nil {
+		http.Error(w, "Error encoding JSON: "+err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,96 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func TestParsePeers(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "carol.token")
+	if err := os.WriteFile(tokenFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LLM_USAGE_PEER_TOKEN_DAVE_2", "from-env")
+
+	peers, err := ParsePeers(
+		[]string{"alice=http://alice:8080", "bob=http://bob:8080", "carol=http://carol:8080", "dave-2=http://dave:8080"},
+		[]string{"bob=secret", "carol=@" + tokenFile},
+	)
+	if err != nil {
+		t.Fatalf("ParsePeers failed: %v", err)
+	}
+	if len(peers) != 4 {
+		t.Fatalf("got %d peers, want 4", len(peers))
+	}
+	for i, want := range []string{"", "secret", "from-file", "from-env"} {
+		if peers[i].Token != want {
+			t.Errorf("%s token = %q, want %q", peers[i].Name, peers[i].Token, want)
+		}
+	}
+
+	invalid := []struct {
+		specs  []string
+		tokens []string
+	}{
+		{[]string{"http://alice:8080"}, nil},
+		{[]string{"alice=http://a", "alice=http://b"}, nil},
+		{[]string{"alice=http://a"}, []string{"carol=secret"}},
+		{[]string{"alice=http://a"}, []string{"alice=@" + tokenFile + ".missing"}},
+	}
+	for _, tc := range invalid {
+		if _, err := ParsePeers(tc.specs, tc.tokens); err == nil {
+			t.Errorf("ParsePeers(%v, %v) succeeded, want error", tc.specs, tc.tokens)
+		}
+	}
+}
+
+func TestHub_Poll(t *testing.T) {
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(provider.UsageStats{Providers: []provider.Usage{{
+			Provider: "claude",
+			Windows:  []provider.UsageWindow{{Label: "5-Hour", Utilization: 40}},
+			Extra:    map[string]any{"account": "work"},
+		}}})
+	}))
+	defer srv.Close()
+
+	peer := Peer{Name: "alice", URL: srv.URL, Token: "secret"}
+	h := newHub([]Peer{peer}, time.Second)
+
+	h.poll(context.Background(), peer)
+	view := h.view()
+	if !view.Peers[0].Reachable {
+		t.Fatalf("peer unreachable: %s", view.Peers[0].Error)
+	}
+	if len(view.Providers) != 1 || view.Providers[0].Extra["peer"] != "alice" {
+		t.Fatalf("Providers = %+v, want one provider labelled alice", view.Providers)
+	}
+
+	down.Store(true)
+	h.poll(context.Background(), peer)
+	view = h.view()
+	if view.Peers[0].Reachable || view.Peers[0].Error == "" {
+		t.Errorf("peer should be marked unreachable with an error")
+	}
+	if len(view.Providers) != 1 || view.Providers[0].Extra["stale"] != true {
+		t.Errorf("last known usage should be kept and marked stale, got %+v", view.Providers)
+	}
+}
@@ -0,0 +1,156 @@
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/report"
+)
+
+// maxReportSize caps the size of a pushed report body
+const maxReportSize = 1 << 20
+
+// ReporterStatus is the latest usage pushed by a reporter
+type ReporterStatus struct {
+	Name       string           `json:"name"`
+	ReportedAt time.Time        `json:"reported_at"`
+	ReceivedAt time.Time        `json:"received_at"`
+	Providers  []provider.Usage `json:"providers"`
+}
+
+// storedReport is the latest report for a single reporter account
+type storedReport struct {
+	reportedAt time.Time
+	receivedAt time.Time
+	usage      provider.Usage
+}
+
+// reportStore keeps the latest report per reporter and account
+type reportStore struct {
+	verifier *report.Verifier
+
+	mu      sync.RWMutex
+	latest  map[string]time.Time
+	reports map[string]map[string]*storedReport
+}
+
+// newReportStore creates a store accepting reports signed with the given keys
+func newReportStore(keys map[string]string) *reportStore {
+	return &reportStore{
+		verifier: report.NewVerifier(keys),
+		latest:   make(map[string]time.Time),
+		reports:  make(map[string]map[string]*storedReport),
+	}
+}
+
+// add stores a verified report, rejecting reports older than the latest one
+func (rs *reportStore) add(reporter string, reportedAt time.Time, stats *provider.UsageStats) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if last, ok := rs.latest[reporter]; ok && reportedAt.Before(last) {
+		return report.ErrStale
+	}
+	rs.latest[reporter] = reportedAt
+
+	accounts := rs.reports[reporter]
+	if accounts == nil {
+		accounts = make(map[string]*storedReport)
+		rs.reports[reporter] = accounts
+	}
+
+	now := time.Now()
+	for _, u := range stats.Providers {
+		account, _ := u.Extra["account"].(string)
+		accounts[u.Provider+"/"+account] = &storedReport{
+			reportedAt: reportedAt,
+			receivedAt: now,
+			usage:      u,
+		}
+	}
+
+	return nil
+}
+
+// view returns the latest reports, sorted by reporter and account
+func (rs *reportStore) view() []ReporterStatus {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	statuses := make([]ReporterStatus, 0, len(rs.reports))
+	for _, name := range sortedKeys(rs.reports) {
+		accounts := rs.reports[name]
+		st := ReporterStatus{Name: name, Providers: make([]provider.Usage, 0, len(accounts))}
+		for _, key := range sortedKeys(accounts) {
+			r := accounts[key]
+			if r.reportedAt.After(st.ReportedAt) {
+				st.ReportedAt = r.reportedAt
+				st.ReceivedAt = r.receivedAt
+			}
+
+			u := r.usage
+			extra := make(map[string]any, len(u.Extra)+2)
+			for k, v := range u.Extra {
+				extra[k] = v
+			}
+			extra["reporter"] = name
+			extra["reported_at"] = r.reportedAt
+			u.Extra = extra
+			st.Providers = append(st.Providers, u)
+		}
+		statuses = append(statuses, st)
+	}
+
+	return statuses
+}
+
+// sortedKeys returns the map's keys in a stable order
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// handleReport accepts a signed usage report pushed by llm-usage report
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	if s.reports == nil {
+		http.Error(w, "reports are not enabled on this server", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxReportSize))
+	if err != nil {
+		http.Error(w, "failed to read report: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reporter, reportedAt, err := s.reports.verifier.Verify(r.Header, body)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, report.ErrStale) || errors.Is(err, report.ErrReplayed) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var stats provider.UsageStats
+	if err := json.Unmarshal(body, &stats); err != nil {
+		http.Error(w, "invalid report: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.reports.add(reporter, reportedAt, &stats); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	log.Printf("Received report from %s (%d accounts)", reporter, len(stats.Providers))
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,48 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/report"
+)
+
+func TestServer_Reports(t *testing.T) {
+	s := NewServer(&Config{ReporterKeys: map[string]string{"alice": "k1"}})
+	srv := httptest.NewServer(s.server.Handler)
+	defer srv.Close()
+
+	stats := &provider.UsageStats{Providers: []provider.Usage{{
+		Provider: "claude",
+		Windows:  []provider.UsageWindow{{Label: "5-Hour", Utilization: 55}},
+		Extra:    map[string]any{"account": "work"},
+	}}}
+
+	if err := report.Push(context.Background(), srv.URL, "alice", "k1", stats); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if err := report.Push(context.Background(), srv.URL, "alice", "wrong", stats); err == nil {
+		t.Error("Push with wrong key succeeded, want error")
+	}
+
+	resp, err := http.Get(srv.URL + "/api/v1/team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var view TeamView
+	if err := json.NewDecoder(resp.Body).Decode(&view); err != nil {
+		t.Fatal(err)
+	}
+	if len(view.Reporters) != 1 || view.Reporters[0].Name != "alice" {
+		t.Fatalf("Reporters = %+v, want alice", view.Reporters)
+	}
+	if len(view.Providers) != 1 || view.Providers[0].Extra["reporter"] != "alice" {
+		t.Errorf("Providers = %+v, want one provider reported by alice", view.Providers)
+	}
+}
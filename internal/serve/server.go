@@ -3,16 +3,20 @@ package serve
 
 import (
 	"context"
+	"crypto/subtle"
 	"embed"
 	"encoding/json"
 	"io/fs"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/report"
 	"github.com/denysvitali/llm-usage/internal/usage"
 )
 
@@ -30,6 +34,26 @@ type Config struct {
 	Host   string
 	Port   int
 	WebDir string
+
+	// Token, when set, is required as a bearer token on all API requests
+	Token string
+
+	// CredentialsFile, when set, is a combined credentials file read instead
+	// of the per-provider files
+	CredentialsFile string
+
+	// Peers are other llm-usage servers polled in hub mode
+	Peers []Peer
+
+	// PeerInterval is how often peers are polled
+	PeerInterval time.Duration
+
+	// ReporterKeys maps reporter names to the keys signing their pushed reports
+	ReporterKeys map[string]string
+
+	// PollInterval, when set, fetches usage periodically so fetch hooks
+	// (webhook notifications, OTLP metrics) run without anyone viewing the dashboard
+	PollInterval time.Duration
 }
 
 // Server represents the HTTP server
@@ -38,15 +62,22 @@ type Server struct {
 	credsMgr  *credentials.Manager
 	server    *http.Server
 	providers []usage.ProviderInstance
+	hub       *hub
+	reports   *reportStore
 }
 
 // NewServer creates a new HTTP server
 func NewServer(cfg *Config) *Server {
 	mux := http.NewServeMux()
 
+	credsMgr := credentials.NewManager()
+	if cfg.CredentialsFile != "" {
+		credsMgr = credentials.NewManagerFromFile(cfg.CredentialsFile)
+	}
+
 	s := &Server{
 		config:   cfg,
-		credsMgr: credentials.NewManager(),
+		credsMgr: credsMgr,
 		server: &http.Server{
 			Addr:              cfg.Host + ":" + itoa(cfg.Port),
 			Handler:           mux,
@@ -56,8 +87,19 @@ func NewServer(cfg *Config) *Server {
 
 	// Register routes
 	mux.HandleFunc("GET /", s.handleIndex)
-	mux.HandleFunc("GET /api/v1/usage", s.handleUsage)
-	mux.HandleFunc("GET /api/v1/providers", s.handleProviders)
+	mux.HandleFunc("GET /api/v1/usage", s.requireToken(s.handleUsage))
+	mux.HandleFunc("GET /api/v1/providers", s.requireToken(s.handleProviders))
+	mux.HandleFunc("GET /api/v1/team", s.requireToken(s.handleTeam))
+
+	// Reports authenticate with their own per-reporter signature
+	mux.HandleFunc("POST "+report.Path, s.handleReport)
+
+	if len(cfg.Peers) > 0 {
+		s.hub = newHub(cfg.Peers, cfg.PeerInterval)
+	}
+	if len(cfg.ReporterKeys) > 0 {
+		s.reports = newReportStore(cfg.ReporterKeys)
+	}
 
 	return s
 }
@@ -92,6 +134,13 @@ func (s *Server) Start(ctx context.Context) error {
 
 	log.Printf("Starting server on http://%s:%d", s.config.Host, s.config.Port)
 
+	if s.hub != nil {
+		go s.hub.run(ctx)
+	}
+	if s.config.PollInterval > 0 {
+		go s.pollUsage(ctx, s.config.PollInterval)
+	}
+
 	// Shutdown on context cancellation
 	go func() {
 		<-ctx.Done()
@@ -106,7 +155,38 @@ func (s *Server) Start(ctx context.Context) error {
 
 // loadProviders loads all configured providers
 func (s *Server) loadProviders() {
-	s.providers = usage.GetProviders("", "", true, s.credsMgr)
+	s.providers = usage.GetProviders("", "", true, s.credsMgr, usage.Selection{})
+}
+
+// pollUsage fetches usage from all providers until the context is cancelled
+func (s *Server) pollUsage(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		usage.FetchAllUsage(usage.GetProviders("", "", true, s.credsMgr, usage.Selection{}))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// requireToken wraps an API handler with bearer token authentication when a token is configured
+func (s *Server) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	if s.config.Token == "" {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="llm-usage"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
 }
 
 // handleIndex serves the frontend HTML
@@ -136,13 +216,20 @@ func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache")
 
 	// Parse query parameters
-	providerFilter := r.URL.Query().Get("provider")
-	accountFilter := r.URL.Query().Get("account")
+	query := r.URL.Query()
+	providerFilter := query.Get("provider")
+	accountFilter := query.Get("account")
+	sel := usage.Selection{
+		Tags:        queryList(query, "tag"),
+		Groups:      queryList(query, "group"),
+		ExcludeTags: queryList(query, "exclude_tag"),
+	}
 
 	// Always fetch fresh providers on each request
-	providers := usage.GetProviders(providerFilter, accountFilter, accountFilter == "", s.credsMgr)
+	providers := usage.GetProviders(providerFilter, accountFilter, accountFilter == "", s.credsMgr, sel)
 
 	stats := usage.FetchAllUsage(providers)
+	stats.Groups = stats.Rollup()
 
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
@@ -151,6 +238,19 @@ func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// queryList returns a query parameter's values, which may be repeated or comma-separated
+func queryList(query url.Values, key string) []string {
+	var out []string
+	for _, v := range query[key] {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
 // handleProviders returns list of available providers
 func (s *Server) handleProviders(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
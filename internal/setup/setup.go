@@ -230,9 +230,10 @@ func saveMiniMaxCredentials(mgr *credentials.Manager, accountName, cookie, group
 	if creds.Accounts == nil {
 		creds.Accounts = make(map[string]*credentials.MiniMaxAccount)
 		if creds.Cookie != "" {
-			creds.Accounts["default"] = &credentials.MiniMaxAccount{Cookie: creds.Cookie, GroupID: creds.GroupID}
+			creds.Accounts["default"] = &credentials.MiniMaxAccount{Cookie: creds.Cookie, GroupID: creds.GroupID, APIKey: creds.APIKey}
 			creds.Cookie = ""
 			creds.GroupID = ""
+			creds.APIKey = ""
 		}
 	}
 
@@ -0,0 +1,201 @@
+// Package snapshot keeps the last fetched usage of every account on disk, so
+// latency-sensitive consumers (statuslines, shell prompts) never hit the network.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// Entry is the last fetched usage of a provider account
+type Entry struct {
+	Usage     provider.Usage `json:"usage"`
+	FetchedAt time.Time      `json:"fetched_at"`
+}
+
+// Account returns the entry's account name
+func (e *Entry) Account() string {
+	account, _ := e.Usage.Extra["account"].(string)
+	return account
+}
+
+// Age returns how long ago the entry was fetched
+func (e *Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.FetchedAt)
+}
+
+// Snapshot holds the last usage of every account fetched by any llm-usage run
+type Snapshot struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Find returns the entry of a provider account, or nil. An empty account
+// matches the provider's first entry.
+func (s *Snapshot) Find(providerID, account string) *Entry {
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		if e.Usage.Provider == providerID && (account == "" || e.Account() == account) {
+			return e
+		}
+	}
+	return nil
+}
+
+// Stats returns the snapshot as usage stats
+func (s *Snapshot) Stats() *provider.UsageStats {
+	stats := &provider.UsageStats{Providers: make([]provider.Usage, 0, len(s.Entries))}
+	for _, e := range s.Entries {
+		stats.Providers = append(stats.Providers, e.Usage)
+	}
+	return stats
+}
+
+// Oldest returns the fetch time of the oldest entry, or zero for an empty snapshot
+func (s *Snapshot) Oldest() time.Time {
+	var oldest time.Time
+	for _, e := range s.Entries {
+		if oldest.IsZero() || e.FetchedAt.Before(oldest) {
+			oldest = e.FetchedAt
+		}
+	}
+	return oldest
+}
+
+// merge replaces the entries of the fetched accounts. A failed fetch does
+// not replace the last successful usage of an account.
+func (s *Snapshot) merge(stats *provider.UsageStats, now time.Time) {
+	for _, u := range stats.Providers {
+		entry := Entry{Usage: u, FetchedAt: now}
+		idx := slices.IndexFunc(s.Entries, func(e Entry) bool {
+			return e.Usage.Provider == u.Provider && e.Account() == entry.Account()
+		})
+		switch {
+		case idx < 0:
+			s.Entries = append(s.Entries, entry)
+		case u.Error == nil || s.Entries[idx].Usage.Error != nil:
+			s.Entries[idx] = entry
+		}
+	}
+}
+
+// Store reads and writes the snapshot file
+type Store struct {
+	path string
+}
+
+// NewStore creates a store at $XDG_CACHE_HOME/llm-usage/snapshot.json
+func NewStore() *Store {
+	return &Store{path: filepath.Join(xdg.CacheHome, "llm-usage", "snapshot.json")}
+}
+
+// NewStoreFromPath creates a store at path
+func NewStoreFromPath(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the snapshot file path
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load reads the snapshot, returning an empty one if none was written yet
+func (s *Store) Load() (*Snapshot, error) {
+	snap := &Snapshot{}
+	data, err := os.ReadFile(s.path) //nolint:gosec
+	if errors.Is(err, os.ErrNotExist) {
+		return snap, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if err := json.Unmarshal(data, snap); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// Merge records freshly fetched usage in the snapshot. Concurrent merges,
+// e.g. from a server and a background refresh, are serialized by a file lock
+// so neither loses the other's accounts.
+func (s *Store) Merge(stats *provider.UsageStats, now time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	lock, err := os.OpenFile(s.path+".merge.lock", os.O_CREATE|os.O_WRONLY, 0600) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot lock: %w", err)
+	}
+	defer func() { _ = lock.Close() }()
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("failed to lock snapshot: %w", err)
+	}
+	defer func() { _ = unlockFile(lock) }()
+
+	snap, err := s.Load()
+	if err != nil {
+		// A corrupt snapshot is replaced
+		snap = &Snapshot{}
+	}
+	snap.merge(stats, now)
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".snapshot.*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// lockPath returns the refresh lock file path
+func (s *Store) lockPath() string {
+	return s.path + ".lock"
+}
+
+// Refreshing reports whether a refresh started less than ttl ago is still running
+func (s *Store) Refreshing(ttl time.Duration) bool {
+	info, err := os.Stat(s.lockPath())
+	return err == nil && time.Since(info.ModTime()) < ttl
+}
+
+// TryLock takes the refresh lock, so that concurrent callers don't all
+// refresh at once. A lock older than ttl is considered abandoned.
+// It returns false if another refresh holds the lock.
+func (s *Store) TryLock(ttl time.Duration) (unlock func(), ok bool) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return nil, false
+	}
+	if s.Refreshing(ttl) {
+		return nil, false
+	}
+	_ = os.Remove(s.lockPath()) // abandoned
+
+	f, err := os.OpenFile(s.lockPath(), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600) //nolint:gosec
+	if err != nil {
+		return nil, false
+	}
+	_ = f.Close()
+	return func() { _ = os.Remove(s.lockPath()) }, true
+}
@@ -0,0 +1,134 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func usageFor(providerID, account string, util float64, err error) provider.Usage {
+	return provider.Usage{
+		Provider: providerID,
+		Windows:  []provider.UsageWindow{{Label: "5-Hour", Utilization: util}},
+		Extra:    map[string]any{"account": account},
+		Error:    err,
+	}
+}
+
+func TestStore_Merge(t *testing.T) {
+	store := NewStoreFromPath(filepath.Join(t.TempDir(), "llm-usage", "snapshot.json"))
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	snap, err := store.Load()
+	if err != nil || len(snap.Entries) != 0 {
+		t.Fatalf("Load() = %v, %v, want an empty snapshot", snap, err)
+	}
+
+	steps := []*provider.UsageStats{
+		{Providers: []provider.Usage{usageFor("claude", "work", 10, nil), usageFor("kimi", "default", 20, nil)}},
+		// A partial fetch only replaces its own accounts
+		{Providers: []provider.Usage{usageFor("claude", "work", 30, nil), usageFor("claude", "home", 5, nil)}},
+		// A failed fetch keeps the last good usage
+		{Providers: []provider.Usage{usageFor("kimi", "default", 0, errors.New("timeout"))}},
+	}
+	for i, stats := range steps {
+		if err := store.Merge(stats, t0.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snap, err = store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Entries) != 3 {
+		t.Fatalf("len(Entries) = %d, want 3", len(snap.Entries))
+	}
+
+	tests := []struct {
+		provider, account string
+		util              float64
+		fetchedAt         time.Time
+	}{
+		{"claude", "work", 30, t0.Add(time.Minute)},
+		{"claude", "home", 5, t0.Add(time.Minute)},
+		{"kimi", "default", 20, t0},
+		{"claude", "", 30, t0.Add(time.Minute)}, // first claude account
+	}
+	for _, tt := range tests {
+		e := snap.Find(tt.provider, tt.account)
+		if e == nil {
+			t.Errorf("Find(%s, %s) = nil", tt.provider, tt.account)
+			continue
+		}
+		if e.Usage.Windows[0].Utilization != tt.util || !e.FetchedAt.Equal(tt.fetchedAt) {
+			t.Errorf("Find(%s, %s) = %v at %v, want %v at %v", tt.provider, tt.account,
+				e.Usage.Windows[0].Utilization, e.FetchedAt, tt.util, tt.fetchedAt)
+		}
+	}
+
+	if got := snap.Oldest(); !got.Equal(t0) {
+		t.Errorf("Oldest() = %v, want %v", got, t0)
+	}
+	if got := len(snap.Stats().Providers); got != 3 {
+		t.Errorf("len(Stats().Providers) = %d, want 3", got)
+	}
+}
+
+func TestStore_MergeConcurrent(t *testing.T) {
+	store := NewStoreFromPath(filepath.Join(t.TempDir(), "snapshot.json"))
+	now := time.Now()
+
+	const accounts = 20
+	var wg sync.WaitGroup
+	for i := range accounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats := &provider.UsageStats{Providers: []provider.Usage{usageFor("claude", fmt.Sprint(i), 1, nil)}}
+			if err := store.Merge(stats, now); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	snap, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Entries) != accounts {
+		t.Errorf("len(Entries) = %d, want %d", len(snap.Entries), accounts)
+	}
+}
+
+func TestStore_TryLock(t *testing.T) {
+	store := NewStoreFromPath(filepath.Join(t.TempDir(), "snapshot.json"))
+
+	unlock, ok := store.TryLock(time.Minute)
+	if !ok {
+		t.Fatal("TryLock() should succeed on a free lock")
+	}
+	if !store.Refreshing(time.Minute) {
+		t.Error("Refreshing() = false while locked")
+	}
+	if _, ok := store.TryLock(time.Minute); ok {
+		t.Error("TryLock() should fail while locked")
+	}
+	// A lock older than the ttl is abandoned
+	if unlock2, ok := store.TryLock(0); !ok {
+		t.Error("TryLock() should take over an abandoned lock")
+	} else {
+		unlock2()
+	}
+
+	unlock()
+	if store.Refreshing(time.Minute) {
+		t.Error("Refreshing() = true after unlock")
+	}
+}
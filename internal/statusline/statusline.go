@@ -0,0 +1,413 @@
+// Package statusline renders the Claude Code statusLine from cached usage.
+package statusline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// Input is the session JSON Claude Code passes to statusLine commands on stdin
+type Input struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	CWD            string `json:"cwd"`
+	Model          struct {
+		ID          string `json:"id"`
+		DisplayName string `json:"display_name"`
+	} `json:"model"`
+	Workspace struct {
+		CurrentDir string `json:"current_dir"`
+		ProjectDir string `json:"project_dir"`
+	} `json:"workspace"`
+}
+
+// ParseInput reads the session JSON. Empty input yields an empty session.
+func ParseInput(r io.Reader) (*Input, error) {
+	var in Input
+	data, err := io.ReadAll(io.LimitReader(r, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session input: %w", err)
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return &in, nil
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("failed to parse session input: %w", err)
+	}
+	return &in, nil
+}
+
+// Tokens are the tokens used by a session
+type Tokens struct {
+	Input         int64
+	Output        int64
+	CacheCreation int64
+	CacheRead     int64
+}
+
+// Total returns all tokens processed by the session
+func (t Tokens) Total() int64 {
+	return t.Input + t.Output + t.CacheCreation + t.CacheRead
+}
+
+// transcriptLine is the part of a transcript entry carrying token usage
+type transcriptLine struct {
+	RequestID string `json:"requestId"`
+	Message   struct {
+		ID    string `json:"id"`
+		Usage *struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// add returns the sum of two token counts
+func (t Tokens) add(o Tokens) Tokens {
+	return Tokens{
+		Input:         t.Input + o.Input,
+		Output:        t.Output + o.Output,
+		CacheCreation: t.CacheCreation + o.CacheCreation,
+		CacheRead:     t.CacheRead + o.CacheRead,
+	}
+}
+
+// parseLine returns the usage of a transcript line and the key identifying
+// its message, which is empty for lines without message or request IDs
+func parseLine(line []byte) (key string, tokens Tokens, ok bool) {
+	if !bytes.Contains(line, []byte(`"usage"`)) {
+		return "", tokens, false
+	}
+	var entry transcriptLine
+	if err := json.Unmarshal(line, &entry); err != nil || entry.Message.Usage == nil {
+		return "", tokens, false
+	}
+	if entry.Message.ID != "" || entry.RequestID != "" {
+		key = entry.Message.ID + "/" + entry.RequestID
+	}
+	u := entry.Message.Usage
+	return key, Tokens{
+		Input:         u.InputTokens,
+		Output:        u.OutputTokens,
+		CacheCreation: u.CacheCreationInputTokens,
+		CacheRead:     u.CacheReadInputTokens,
+	}, true
+}
+
+// recentKeys bounds the message keys kept for deduplication. The lines of a
+// streamed message are written back to back, so only the last few matter.
+const recentKeys = 16
+
+// tokenState is the progress of summing a transcript: the totals of the
+// complete lines before Offset
+type tokenState struct {
+	Path   string   `json:"path"`
+	Offset int64    `json:"offset"`
+	Tokens Tokens   `json:"tokens"`
+	Recent []string `json:"recent"` // keys of the last counted messages
+}
+
+// counted reports whether the message of a line was already counted
+func (s *tokenState) counted(key string) bool {
+	return key != "" && slices.Contains(s.Recent, key)
+}
+
+// read sums the lines of r, which starts at the state's offset. A trailing
+// line without newline may still be being written, so it is counted in the
+// result but not consumed.
+func (s *tokenState) read(r io.Reader) (Tokens, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			total := s.Tokens
+			if key, tokens, ok := parseLine(line); ok && !s.counted(key) {
+				total = total.add(tokens)
+			}
+			return total, nil
+		}
+		if err != nil {
+			return s.Tokens, fmt.Errorf("failed to read transcript: %w", err)
+		}
+		s.Offset += int64(len(line))
+
+		key, tokens, ok := parseLine(line)
+		if !ok || s.counted(key) {
+			continue
+		}
+		if key != "" {
+			s.Recent = append(s.Recent, key)
+			if n := len(s.Recent); n > recentKeys {
+				s.Recent = slices.Clone(s.Recent[n-recentKeys:])
+			}
+		}
+		s.Tokens = s.Tokens.add(tokens)
+	}
+}
+
+// SessionTokens sums the token usage recorded in a session transcript (JSONL).
+// A streamed message is written once per content block with the same usage,
+// so each message is only counted once.
+func SessionTokens(path string) (Tokens, error) {
+	return CachedSessionTokens(path, "")
+}
+
+// TokenCachePath returns where the token totals of a session are cached,
+// or "" without a session ID
+func TokenCachePath(sessionID string) string {
+	if sessionID == "" || sessionID != filepath.Base(sessionID) {
+		return ""
+	}
+	return filepath.Join(xdg.CacheHome, "llm-usage", "sessions", sessionID+".json")
+}
+
+// CachedSessionTokens is SessionTokens, resuming from the totals cached at
+// cachePath so each call only parses the lines appended since the last one.
+// An empty cachePath reads the whole transcript.
+func CachedSessionTokens(path, cachePath string) (Tokens, error) {
+	if path == "" {
+		return Tokens{}, nil
+	}
+
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return Tokens{}, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	state := loadTokenState(cachePath)
+	if info, err := f.Stat(); err != nil || state.Path != path || info.Size() < state.Offset {
+		// Another or a rewritten transcript
+		state = &tokenState{Path: path}
+	}
+	if _, err := f.Seek(state.Offset, io.SeekStart); err != nil {
+		return Tokens{}, fmt.Errorf("failed to read transcript: %w", err)
+	}
+
+	total, err := state.read(f)
+	if err != nil {
+		return total, err
+	}
+	if cachePath != "" {
+		if err := saveTokenState(cachePath, state); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// loadTokenState reads a cached token state, returning an empty one if
+// there is none
+func loadTokenState(cachePath string) *tokenState {
+	state := &tokenState{}
+	if cachePath == "" {
+		return state
+	}
+	data, err := os.ReadFile(cachePath) //nolint:gosec
+	if err != nil || json.Unmarshal(data, state) != nil {
+		return &tokenState{}
+	}
+	return state
+}
+
+// saveTokenState atomically writes a token state
+func saveTokenState(cachePath string, state *tokenState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), ".tokens.*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write token cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), cachePath); err != nil {
+		return fmt.Errorf("failed to write token cache: %w", err)
+	}
+	return nil
+}
+
+// ConfigDir returns the Claude Code config dir of the session: $CLAUDE_CONFIG_DIR or ~/.claude
+func ConfigDir() string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return credentials.ExpandHome(dir)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".claude")
+}
+
+// DetectAccount returns the configured Claude account used by a Claude Code
+// config dir. Accounts match by their configDir, or by the OAuth tokens stored
+// in the config dir. The default ~/.claude dir maps to the "default" account.
+// It returns "" when no account matches.
+func DetectAccount(creds *credentials.ClaudeCredentials, configDir string) string {
+	if creds != nil {
+		names := make([]string, 0, len(creds.Accounts))
+		for name := range creds.Accounts {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			if dir := creds.Accounts[name].ConfigDir; dir != "" && samePath(credentials.ExpandHome(dir), configDir) {
+				return name
+			}
+		}
+
+		if session, err := credentials.LoadFromPath(filepath.Join(configDir, ".credentials.json")); err == nil {
+			oauth := session.ClaudeAiOauth
+			for _, name := range names {
+				acc := creds.Accounts[name]
+				if acc.AccessToken == oauth.AccessToken || (acc.RefreshToken != "" && acc.RefreshToken == oauth.RefreshToken) {
+					return name
+				}
+			}
+		}
+	}
+
+	if home, err := os.UserHomeDir(); err == nil && samePath(configDir, filepath.Join(home, ".claude")) {
+		return "default"
+	}
+	return ""
+}
+
+// samePath reports whether two paths refer to the same directory
+func samePath(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	ia, errA := os.Stat(a)
+	ib, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(ia, ib)
+}
+
+// ANSI colors by severity
+var severityANSI = map[string]string{
+	"normal":   "\033[32m",
+	"warning":  "\033[33m",
+	"critical": "\033[31m",
+}
+
+const (
+	ansiDim   = "\033[2m"
+	ansiReset = "\033[0m"
+)
+
+// Line is the content of a statusline
+type Line struct {
+	Model      string
+	Account    string
+	Undetected bool            // the session's account is unknown, so no usage is shown
+	Usage      *provider.Usage // nil until the first snapshot is written
+	Tokens     Tokens
+	Color      bool
+	Now        time.Time
+}
+
+// Render renders the statusline, e.g. "Opus · work · 5h 42% (2h 15m) · 7d 18% · 1.2M tokens"
+func Render(l Line) string {
+	var parts []string
+	if l.Model != "" {
+		parts = append(parts, l.Model)
+	}
+	if l.Account != "" {
+		parts = append(parts, l.Account)
+	}
+
+	switch {
+	case l.Undetected:
+		parts = append(parts, l.dim("unknown account"))
+	case l.Usage == nil:
+		parts = append(parts, l.dim("usage loading…"))
+	case l.Usage.Error != nil:
+		parts = append(parts, l.color("critical", "usage error"))
+	default:
+		if w := findWindow(l.Usage, "5-Hour"); w != nil {
+			part := l.color(provider.ClassFor("claude", l.Account, w.Label, w.Utilization), fmt.Sprintf("5h %.0f%%", w.Utilization))
+			if w.ResetsAt != nil {
+				part += " " + l.dim("("+usage.FormatDuration(w.ResetsAt.Sub(l.Now))+")")
+			}
+			parts = append(parts, part)
+		}
+		if w := findWindow(l.Usage, "7-Day"); w != nil {
+			parts = append(parts, l.color(provider.ClassFor("claude", l.Account, w.Label, w.Utilization), fmt.Sprintf("7d %.0f%%", w.Utilization)))
+		}
+	}
+
+	if total := l.Tokens.Total(); total > 0 {
+		parts = append(parts, formatTokens(total)+" tokens")
+	}
+
+	sep := " · "
+	if l.Color {
+		sep = l.dim(sep)
+	}
+	return strings.Join(parts, sep)
+}
+
+// color wraps text in the severity's ANSI color when colors are enabled
+func (l Line) color(severity, text string) string {
+	if !l.Color {
+		return text
+	}
+	return severityANSI[severity] + text + ansiReset
+}
+
+// dim dims text when colors are enabled
+func (l Line) dim(text string) string {
+	if !l.Color {
+		return text
+	}
+	return ansiDim + text + ansiReset
+}
+
+// findWindow returns a usage window by label
+func findWindow(u *provider.Usage, label string) *provider.UsageWindow {
+	for i := range u.Windows {
+		if u.Windows[i].Label == label {
+			return &u.Windows[i]
+		}
+	}
+	return nil
+}
+
+// formatTokens formats a token count, e.g. 1.2M
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
@@ -0,0 +1,233 @@
+package statusline
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func TestParseInput(t *testing.T) {
+	in, err := ParseInput(strings.NewReader(`{"session_id":"s1","transcript_path":"/tmp/t.jsonl","model":{"id":"claude-opus-4-1","display_name":"Opus"},"workspace":{"current_dir":"/src"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.SessionID != "s1" || in.TranscriptPath != "/tmp/t.jsonl" || in.Model.DisplayName != "Opus" || in.Workspace.CurrentDir != "/src" {
+		t.Errorf("ParseInput() = %+v", in)
+	}
+
+	if in, err := ParseInput(strings.NewReader("")); err != nil || in.SessionID != "" {
+		t.Errorf("ParseInput(empty) = %+v, %v, want an empty session", in, err)
+	}
+	if _, err := ParseInput(strings.NewReader("{")); err == nil {
+		t.Error("ParseInput(invalid) should fail")
+	}
+}
+
+func TestSessionTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	transcript := strings.Join([]string{
+		`{"type":"user","message":{"role":"user","content":"hi"}}`,
+		`{"type":"assistant","requestId":"r1","message":{"id":"m1","usage":{"input_tokens":10,"output_tokens":5,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000}}}`,
+		// Same message, second content block
+		`{"type":"assistant","requestId":"r1","message":{"id":"m1","usage":{"input_tokens":10,"output_tokens":5,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000}}}`,
+		`{"type":"assistant","requestId":"r2","message":{"id":"m2","usage":{"input_tokens":1,"output_tokens":2,"cache_read_input_tokens":3}}}`,
+		`not json "usage"`,
+	}, "\n")
+	if err := os.WriteFile(path, []byte(transcript), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := SessionTokens(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Tokens{Input: 11, Output: 7, CacheCreation: 100, CacheRead: 1003}
+	if got != want {
+		t.Errorf("SessionTokens() = %+v, want %+v", got, want)
+	}
+	if got.Total() != 1121 {
+		t.Errorf("Total() = %d, want 1121", got.Total())
+	}
+
+	if _, err := SessionTokens(filepath.Join(t.TempDir(), "missing.jsonl")); err == nil {
+		t.Error("SessionTokens(missing) should fail")
+	}
+}
+
+func TestCachedSessionTokens(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "transcript.jsonl")
+	cachePath := filepath.Join(dir, "cache", "session.json")
+	m1 := `{"type":"assistant","requestId":"r1","message":{"id":"m1","usage":{"input_tokens":10,"output_tokens":5}}}`
+	m2 := `{"type":"assistant","requestId":"r2","message":{"id":"m2","usage":{"input_tokens":1,"output_tokens":2}}}`
+
+	appendLine := func(line string) {
+		t.Helper()
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = f.Close() }()
+		if _, err := f.WriteString(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(want Tokens) {
+		t.Helper()
+		got, err := CachedSessionTokens(path, cachePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("CachedSessionTokens() = %+v, want %+v", got, want)
+		}
+	}
+
+	appendLine(m1 + "\n")
+	check(Tokens{Input: 10, Output: 5})
+
+	// A line still being written is counted but parsed again once complete
+	appendLine(m1 + "\n" + m2)
+	check(Tokens{Input: 11, Output: 7})
+	appendLine("\n")
+	check(Tokens{Input: 11, Output: 7})
+
+	// Only the new lines are read: tampering with consumed lines goes unnoticed
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Replace(string(data), `"input_tokens":10`, `"input_tokens":90`, 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	check(Tokens{Input: 11, Output: 7})
+
+	// A shorter transcript was rewritten and is read from the start
+	if err := os.WriteFile(path, []byte(m2+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	check(Tokens{Input: 1, Output: 2})
+}
+
+func TestCachedSessionTokens_BoundedState(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "transcript.jsonl")
+	cachePath := filepath.Join(dir, "session.json")
+
+	var b strings.Builder
+	for i := range 100 {
+		line := fmt.Sprintf(`{"requestId":"r%d","message":{"id":"m%d","usage":{"output_tokens":1}}}`, i, i)
+		b.WriteString(line + "\n" + line + "\n")
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := CachedSessionTokens(path, cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Output != 100 {
+		t.Errorf("Output = %d, want 100", got.Output)
+	}
+	if state := loadTokenState(cachePath); len(state.Recent) > recentKeys {
+		t.Errorf("cached %d message keys, want at most %d", len(state.Recent), recentKeys)
+	}
+}
+
+func TestDetectAccount(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	workDir := filepath.Join(home, ".claude-work")
+	personalDir := filepath.Join(home, ".claude-personal")
+	for _, dir := range []string{workDir, personalDir, filepath.Join(home, ".claude")} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session := `{"claudeAiOauth":{"accessToken":"tok-personal","refreshToken":"ref-personal"}}`
+	if err := os.WriteFile(filepath.Join(personalDir, ".credentials.json"), []byte(session), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	creds := &credentials.ClaudeCredentials{Accounts: map[string]*credentials.ClaudeAccount{
+		"work":     {AccessToken: "tok-work", ConfigDir: "~/.claude-work"},
+		"personal": {AccessToken: "tok-old", RefreshToken: "ref-personal"},
+	}}
+
+	tests := []struct {
+		configDir string
+		want      string
+	}{
+		{workDir, "work"},
+		{personalDir, "personal"},
+		{filepath.Join(home, ".claude"), "default"},
+		{filepath.Join(home, "other"), ""},
+	}
+	for _, tt := range tests {
+		if got := DetectAccount(creds, tt.configDir); got != tt.want {
+			t.Errorf("DetectAccount(%s) = %q, want %q", tt.configDir, got, tt.want)
+		}
+	}
+
+	if got := DetectAccount(nil, filepath.Join(home, ".claude")); got != "default" {
+		t.Errorf("DetectAccount(nil) = %q, want default", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	resetsAt := now.Add(2*time.Hour + 15*time.Minute)
+	u := &provider.Usage{Provider: "claude", Windows: []provider.UsageWindow{
+		{Label: "5-Hour", Utilization: 42.4, ResetsAt: &resetsAt},
+		{Label: "7-Day", Utilization: 18},
+		{Label: "7-Day Opus", Utilization: 90},
+	}}
+
+	tests := []struct {
+		name string
+		line Line
+		want string
+	}{
+		{
+			name: "usage",
+			line: Line{Model: "Opus", Account: "work", Usage: u, Tokens: Tokens{Input: 1_200_000}, Now: now},
+			want: "Opus · work · 5h 42% (2h 15m) · 7d 18% · 1.2M tokens",
+		},
+		{
+			name: "no snapshot",
+			line: Line{Model: "Sonnet", Now: now},
+			want: "Sonnet · usage loading…",
+		},
+		{
+			name: "undetected account",
+			line: Line{Model: "Opus", Undetected: true, Usage: u, Now: now},
+			want: "Opus · unknown account",
+		},
+		{
+			name: "error",
+			line: Line{Usage: &provider.Usage{Provider: "claude", Error: errors.New("unauthorized")}, Tokens: Tokens{Output: 950}, Now: now},
+			want: "usage error · 950 tokens",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.line); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	colored := Render(Line{Usage: u, Color: true, Now: now})
+	if !strings.Contains(colored, "\033[32m5h 42%\033[0m") {
+		t.Errorf("Render(color) = %q, want a green 5h segment", colored)
+	}
+}
@@ -0,0 +1,216 @@
+// Package telemetry exports usage metrics via OpenTelemetry OTLP
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/version"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+)
+
+// meterName identifies the instrumentation scope
+const meterName = "github.com/denysvitali/llm-usage"
+
+// Protocols supported by OTEL_EXPORTER_OTLP_PROTOCOL
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+)
+
+// Enabled reports whether an OTLP endpoint is configured in the environment
+func Enabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT") != ""
+}
+
+// Protocol returns the OTLP protocol selected by the environment, defaulting to http/protobuf
+func Protocol() string {
+	for _, key := range []string{"OTEL_EXPORTER_OTLP_METRICS_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"} {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			return v
+		}
+	}
+	return ProtocolHTTPProtobuf
+}
+
+// Exporter records usage and fetch metrics and exports them via OTLP.
+// Endpoint, headers, TLS, timeout and export interval follow the standard
+// OTEL_EXPORTER_OTLP_* and OTEL_METRIC_EXPORT_* environment variables.
+type Exporter struct {
+	provider *sdkmetric.MeterProvider
+
+	fetchDuration metric.Float64Histogram
+	fetchErrors   metric.Int64Counter
+
+	mu    sync.RWMutex
+	usage map[string]provider.Usage // by provider/account
+}
+
+// New creates an exporter using the given OTLP protocol (grpc or http/protobuf)
+func New(ctx context.Context, protocol string) (*Exporter, error) {
+	var exporter sdkmetric.Exporter
+	var err error
+	switch protocol {
+	case ProtocolGRPC:
+		exporter, err = otlpmetricgrpc.New(ctx)
+	case ProtocolHTTPProtobuf, "http":
+		exporter, err = otlpmetrichttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q (supported: grpc, http/protobuf)", protocol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithHost(),
+		resource.WithOS(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(
+			semconv.ServiceName("llm-usage"),
+			semconv.ServiceVersion(version.Version),
+		),
+	)
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+		return nil, fmt.Errorf("failed to detect resource: %w", err)
+	}
+
+	e := &Exporter{
+		usage: make(map[string]provider.Usage),
+		provider: sdkmetric.NewMeterProvider(
+			sdkmetric.WithResource(res),
+			sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		),
+	}
+
+	if err := e.registerInstruments(); err != nil {
+		_ = e.provider.Shutdown(ctx)
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// registerInstruments creates the fetch instruments and usage gauges
+func (e *Exporter) registerInstruments() error {
+	meter := e.provider.Meter(meterName, metric.WithInstrumentationVersion(version.Version))
+
+	var err error
+	e.fetchDuration, err = meter.Float64Histogram("llm_usage.fetch.duration",
+		metric.WithDescription("Duration of provider usage fetches"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return fmt.Errorf("failed to create fetch duration histogram: %w", err)
+	}
+
+	e.fetchErrors, err = meter.Int64Counter("llm_usage.fetch.errors",
+		metric.WithDescription("Number of failed provider usage fetches"),
+		metric.WithUnit("{error}"))
+	if err != nil {
+		return fmt.Errorf("failed to create fetch error counter: %w", err)
+	}
+
+	utilization, err := meter.Float64ObservableGauge("llm_usage.utilization",
+		metric.WithDescription("Usage window utilization"),
+		metric.WithUnit("%"))
+	if err != nil {
+		return fmt.Errorf("failed to create utilization gauge: %w", err)
+	}
+
+	resetsIn, err := meter.Float64ObservableGauge("llm_usage.resets_in",
+		metric.WithDescription("Time until the usage window resets"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return fmt.Errorf("failed to create reset gauge: %w", err)
+	}
+
+	remaining, err := meter.Float64ObservableGauge("llm_usage.remaining",
+		metric.WithDescription("Remaining quota in the usage window, in provider units"),
+		metric.WithUnit("1"))
+	if err != nil {
+		return fmt.Errorf("failed to create remaining gauge: %w", err)
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+		now := time.Now()
+		for _, u := range e.usage {
+			account, _ := u.Extra["account"].(string)
+			for _, w := range u.Windows {
+				attrs := metric.WithAttributes(
+					attribute.String("provider", u.Provider),
+					attribute.String("account", account),
+					attribute.String("window", w.Label),
+				)
+				o.ObserveFloat64(utilization, w.Utilization, attrs)
+				if w.ResetsAt != nil {
+					o.ObserveFloat64(resetsIn, w.ResetsAt.Sub(now).Seconds(), attrs)
+				}
+				if w.Remaining != nil {
+					o.ObserveFloat64(remaining, *w.Remaining, attrs)
+				}
+			}
+		}
+		return nil
+	}, utilization, resetsIn, remaining)
+	if err != nil {
+		return fmt.Errorf("failed to register gauge callback: %w", err)
+	}
+
+	return nil
+}
+
+// RecordUsage updates the usage reported by the gauges. Accounts missing
+// from stats, e.g. because of a filtered fetch, keep their last values;
+// failed accounts stop being reported until they are fetched again.
+func (e *Exporter) RecordUsage(stats *provider.UsageStats) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, u := range stats.Providers {
+		account, _ := u.Extra["account"].(string)
+		key := u.Provider + "/" + account
+		if u.Error != nil {
+			delete(e.usage, key)
+			continue
+		}
+		e.usage[key] = u
+	}
+}
+
+// RecordFetch records the latency and outcome of a provider account fetch
+func (e *Exporter) RecordFetch(ctx context.Context, providerID, account string, d time.Duration, err error) {
+	attrs := metric.WithAttributes(
+		attribute.String("provider", providerID),
+		attribute.String("account", account),
+		attribute.Bool("error", err != nil),
+	)
+	e.fetchDuration.Record(ctx, d.Seconds(), attrs)
+	if err != nil {
+		e.fetchErrors.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("provider", providerID),
+			attribute.String("account", account),
+		))
+	}
+}
+
+// Shutdown exports any pending metrics and stops the exporter
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	if err := e.provider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to export metrics: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector records exported metric requests
+type collector struct {
+	colmetricpb.UnimplementedMetricsServiceServer
+
+	mu       sync.Mutex
+	requests []*colmetricpb.ExportMetricsServiceRequest
+}
+
+func (c *collector) Export(_ context.Context, req *colmetricpb.ExportMetricsServiceRequest) (*colmetricpb.ExportMetricsServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	return &colmetricpb.ExportMetricsServiceResponse{}, nil
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || r.URL.Path != "/v1/metrics" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	var req colmetricpb.ExportMetricsServiceRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, _ = c.Export(r.Context(), &req)
+
+	out, _ := proto.Marshal(&colmetricpb.ExportMetricsServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(out)
+}
+
+// metrics returns all exported metrics by name
+func (c *collector) metrics() map[string]*metricpb.Metric {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make(map[string]*metricpb.Metric)
+	for _, req := range c.requests {
+		for _, rm := range req.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					out[m.Name] = m
+				}
+			}
+		}
+	}
+	return out
+}
+
+// resourceAttr returns a resource attribute of the first exported request
+func (c *collector) resourceAttr(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, req := range c.requests {
+		for _, rm := range req.ResourceMetrics {
+			for _, kv := range rm.Resource.Attributes {
+				if kv.Key == key {
+					return kv.Value.GetStringValue()
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func exportSample(t *testing.T, protocol string) {
+	t.Helper()
+
+	exp, err := New(context.Background(), protocol)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	resetsAt := time.Now().Add(time.Hour)
+	remaining := 1200.0
+	exp.RecordUsage(&provider.UsageStats{Providers: []provider.Usage{{
+		Provider: "claude",
+		Windows:  []provider.UsageWindow{{Label: "5-Hour", Utilization: 42, ResetsAt: &resetsAt, Remaining: &remaining}},
+		Extra:    map[string]any{"account": "work"},
+	}}})
+	exp.RecordFetch(context.Background(), "claude", "work", 250*time.Millisecond, nil)
+	exp.RecordFetch(context.Background(), "kimi", "", time.Second, errors.New("unauthorized"))
+
+	if err := exp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+}
+
+func checkMetrics(t *testing.T, c *collector) {
+	t.Helper()
+
+	metrics := c.metrics()
+	for _, name := range []string{"llm_usage.utilization", "llm_usage.resets_in", "llm_usage.remaining", "llm_usage.fetch.duration", "llm_usage.fetch.errors"} {
+		if metrics[name] == nil {
+			t.Errorf("metric %s not exported", name)
+		}
+	}
+
+	if m := metrics["llm_usage.utilization"]; m != nil {
+		points := m.GetGauge().GetDataPoints()
+		if len(points) != 1 || points[0].GetAsDouble() != 42 {
+			t.Fatalf("utilization points = %v, want one point at 42", points)
+		}
+		attrs := make(map[string]string)
+		for _, kv := range points[0].Attributes {
+			attrs[kv.Key] = kv.Value.GetStringValue()
+		}
+		if attrs["provider"] != "claude" || attrs["account"] != "work" || attrs["window"] != "5-Hour" {
+			t.Errorf("utilization attributes = %v", attrs)
+		}
+	}
+
+	if c.resourceAttr("service.name") != "llm-usage" || c.resourceAttr("host.name") == "" {
+		t.Errorf("resource missing service.name or host.name")
+	}
+}
+
+func TestExporter_HTTP(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", srv.URL)
+	exportSample(t, Protocol())
+	checkMetrics(t, c)
+}
+
+func TestExporter_GRPC(t *testing.T) {
+	c := &collector{}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	colmetricpb.RegisterMetricsServiceServer(srv, c)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://"+lis.Addr().String())
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc")
+	exportSample(t, Protocol())
+	checkMetrics(t, c)
+}
+
+func TestExporter_RecordUsageMerges(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	e := &Exporter{
+		usage:    make(map[string]provider.Usage),
+		provider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	}
+	if err := e.registerInstruments(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two filtered fetches, e.g. ?provider=claude then ?provider=kimi
+	e.RecordUsage(&provider.UsageStats{Providers: []provider.Usage{{
+		Provider: "claude",
+		Windows:  []provider.UsageWindow{{Label: "5-Hour", Utilization: 42}},
+		Extra:    map[string]any{"account": "work"},
+	}}})
+	e.RecordUsage(&provider.UsageStats{Providers: []provider.Usage{{
+		Provider: "kimi",
+		Windows:  []provider.UsageWindow{{Label: "Weekly", Utilization: 10}},
+	}}})
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	series := make(map[string]float64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "llm_usage.utilization" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Gauge[float64]).DataPoints {
+				p, _ := dp.Attributes.Value("provider")
+				series[p.AsString()] = dp.Value
+			}
+		}
+	}
+	if len(series) != 2 || series["claude"] != 42 || series["kimi"] != 10 {
+		t.Errorf("utilization series = %v, want claude 42 and kimi 10", series)
+	}
+}
+
+func TestNew_UnsupportedProtocol(t *testing.T) {
+	if _, err := New(context.Background(), "http/json"); err == nil {
+		t.Error("New succeeded for http/json, want error")
+	}
+}
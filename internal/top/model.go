@@ -0,0 +1,308 @@
+// Package top provides the live full-screen usage dashboard.
+package top
+
+import (
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// sortMode is the account ordering
+type sortMode int
+
+const (
+	sortUtilization sortMode = iota
+	sortReset
+	sortName
+)
+
+// String returns the sort mode's display name
+func (s sortMode) String() string {
+	switch s {
+	case sortReset:
+		return "reset"
+	case sortName:
+		return "name"
+	default:
+		return "utilization"
+	}
+}
+
+// account is a provider account and its latest usage
+type account struct {
+	instance  usage.ProviderInstance
+	usage     *provider.Usage
+	fetchedAt time.Time
+	loading   bool
+	hidden    bool // every window is hidden by apply
+}
+
+// tickMsg re-renders countdowns every second
+type tickMsg time.Time
+
+// refreshMsg triggers a refresh of all accounts
+type refreshMsg struct{}
+
+// usageMsg carries a fetched account's usage
+type usageMsg struct {
+	idx   int
+	usage *provider.Usage
+	at    time.Time
+}
+
+// Model is the dashboard state
+type Model struct {
+	accounts []*account
+	interval time.Duration
+	apply    func(*provider.UsageStats) *provider.UsageStats
+	now      time.Time
+
+	sort     sortMode
+	filter   string // provider ID, empty for all
+	cursor   int
+	detail   bool
+	showHelp bool
+
+	width, height int
+	nextRefresh   time.Time
+}
+
+// NewModel creates a dashboard for the given accounts, refreshed every
+// interval. apply, if not nil, rewrites each fetched usage before display,
+// e.g. to hide and relabel windows; accounts it drops are not shown.
+func NewModel(instances []usage.ProviderInstance, interval time.Duration, apply func(*provider.UsageStats) *provider.UsageStats) Model {
+	accounts := make([]*account, len(instances))
+	for i, inst := range instances {
+		accounts[i] = &account{instance: inst}
+	}
+	return Model{
+		accounts: accounts,
+		interval: interval,
+		apply:    apply,
+		now:      time.Now(),
+	}
+}
+
+// Init starts the first fetch and the countdown ticker
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(refreshCmd(), tick())
+}
+
+// tick schedules the next countdown update
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// refreshCmd requests a refresh of all accounts
+func refreshCmd() tea.Cmd {
+	return func() tea.Msg { return refreshMsg{} }
+}
+
+// fetch returns a command fetching a single account
+func fetch(idx int, inst usage.ProviderInstance) tea.Cmd {
+	return func() tea.Msg {
+		return usageMsg{idx: idx, usage: usage.FetchUsage(inst), at: time.Now()}
+	}
+}
+
+// Update handles messages and updates the model state
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case tickMsg:
+		m.now = time.Time(msg)
+		if m.interval > 0 && !m.nextRefresh.IsZero() && !m.now.Before(m.nextRefresh) {
+			return m, tea.Batch(m.refreshAll(), tick())
+		}
+		return m, tick()
+
+	case refreshMsg:
+		return m, m.refreshAll()
+
+	case usageMsg:
+		a := m.accounts[msg.idx]
+		a.usage, a.hidden = msg.usage, false
+		if m.apply != nil {
+			stats := m.apply(&provider.UsageStats{Providers: []provider.Usage{*msg.usage}})
+			if len(stats.Providers) == 0 {
+				a.hidden = true
+			} else {
+				a.usage = &stats.Providers[0]
+			}
+		}
+		a.fetchedAt = msg.at
+		a.loading = false
+		return m, nil
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+// refreshAll fetches every account concurrently and schedules the next refresh
+func (m *Model) refreshAll() tea.Cmd {
+	m.nextRefresh = time.Now().Add(m.interval)
+	cmds := make([]tea.Cmd, 0, len(m.accounts))
+	for i, a := range m.accounts {
+		if a.loading {
+			continue
+		}
+		a.loading = true
+		cmds = append(cmds, fetch(i, a.instance))
+	}
+	return tea.Batch(cmds...)
+}
+
+// handleKey processes key presses
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	visible := m.visible()
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		if m.detail {
+			m.detail = false
+			return m, nil
+		}
+		if m.showHelp {
+			m.showHelp = false
+			return m, nil
+		}
+		return m, tea.Quit
+	case "?":
+		m.showHelp = !m.showHelp
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(visible)-1 {
+			m.cursor++
+		}
+	case "enter", " ":
+		if len(visible) > 0 {
+			m.detail = !m.detail
+		}
+	case "s":
+		m.sort = (m.sort + 1) % 3
+	case "f":
+		m.filter = m.nextFilter()
+		m.cursor = 0
+		m.detail = false
+	case "r":
+		if m.cursor < len(visible) {
+			idx := visible[m.cursor]
+			a := m.accounts[idx]
+			if !a.loading {
+				a.loading = true
+				return m, fetch(idx, a.instance)
+			}
+		}
+	case "R":
+		return m, m.refreshAll()
+	}
+
+	return m, nil
+}
+
+// nextFilter cycles through all configured provider IDs
+func (m Model) nextFilter() string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, a := range m.accounts {
+		if id := a.instance.ID(); !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	if m.filter == "" {
+		if len(ids) == 0 {
+			return ""
+		}
+		return ids[0]
+	}
+	for i, id := range ids {
+		if id == m.filter && i+1 < len(ids) {
+			return ids[i+1]
+		}
+	}
+	return ""
+}
+
+// visible returns the indexes of accounts passing the filter, in display order
+func (m Model) visible() []int {
+	var idx []int
+	for i, a := range m.accounts {
+		if !a.hidden && (m.filter == "" || a.instance.ID() == m.filter) {
+			idx = append(idx, i)
+		}
+	}
+
+	sort.SliceStable(idx, func(i, j int) bool {
+		a, b := m.accounts[idx[i]], m.accounts[idx[j]]
+		switch m.sort {
+		case sortReset:
+			ra, rb := nextReset(a.usage), nextReset(b.usage)
+			if ra.IsZero() != rb.IsZero() {
+				return !ra.IsZero()
+			}
+			return ra.Before(rb)
+		case sortName:
+			return displayName(a) < displayName(b)
+		default:
+			return maxUtilization(a.usage) > maxUtilization(b.usage)
+		}
+	})
+
+	return idx
+}
+
+// maxUtilization returns the highest window utilization of a usage
+func maxUtilization(u *provider.Usage) float64 {
+	if u == nil || u.Error != nil {
+		return -1
+	}
+	var maxUtil float64
+	for _, w := range u.Windows {
+		if w.Utilization > maxUtil {
+			maxUtil = w.Utilization
+		}
+	}
+	return maxUtil
+}
+
+// nextReset returns the earliest window reset of a usage, or zero
+func nextReset(u *provider.Usage) time.Time {
+	var next time.Time
+	if u == nil {
+		return next
+	}
+	for _, w := range u.Windows {
+		if w.ResetsAt != nil && (next.IsZero() || w.ResetsAt.Before(next)) {
+			next = *w.ResetsAt
+		}
+	}
+	return next
+}
+
+// displayName returns "Provider (account)"
+func displayName(a *account) string {
+	name := usage.ProviderName(a.instance.ID())
+	if a.instance.AccountName != "" {
+		name += " (" + a.instance.AccountName + ")"
+	}
+	return name
+}
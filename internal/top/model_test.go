@@ -0,0 +1,159 @@
+package top
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// fakeProvider returns fixed usage
+type fakeProvider struct {
+	id    string
+	usage *provider.Usage
+	err   error
+}
+
+func (f *fakeProvider) Name() string                       { return f.id }
+func (f *fakeProvider) ID() string                         { return f.id }
+func (f *fakeProvider) GetUsage() (*provider.Usage, error) { return f.usage, f.err }
+
+func window(label string, util float64, resetIn time.Duration) provider.UsageWindow {
+	resetsAt := time.Now().Add(resetIn)
+	return provider.UsageWindow{Label: label, Utilization: util, ResetsAt: &resetsAt}
+}
+
+func newTestModel() Model {
+	instances := []usage.ProviderInstance{
+		{Provider: &fakeProvider{id: "claude", usage: &provider.Usage{Provider: "claude", Windows: []provider.UsageWindow{window("5-Hour", 20, 3*time.Hour)}}}, AccountName: "a"},
+		{Provider: &fakeProvider{id: "claude", usage: &provider.Usage{Provider: "claude", Windows: []provider.UsageWindow{window("5-Hour", 95, time.Hour)}}}, AccountName: "b"},
+		{Provider: &fakeProvider{id: "kimi", usage: &provider.Usage{
+			Provider: "kimi",
+			Windows:  []provider.UsageWindow{window("Weekly", 50, 30*time.Minute)},
+			Extra:    map[string]any{"subscription": map[string]any{"features": []any{map[string]any{"feature": "search", "left": 3.0}}}},
+		}}},
+		{Provider: &fakeProvider{id: "zai", err: errors.New("unauthorized")}},
+	}
+
+	m := NewModel(instances, time.Minute, nil)
+	for i, a := range m.accounts {
+		msg := fetch(i, a.instance)().(usageMsg)
+		next, _ := m.Update(msg)
+		m = next.(Model)
+	}
+	return m
+}
+
+func press(m Model, key string) Model {
+	var msg tea.KeyMsg
+	if key == "enter" {
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	} else {
+		msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+	next, _ := m.Update(msg)
+	return next.(Model)
+}
+
+func order(m Model) string {
+	var names []string
+	for _, idx := range m.visible() {
+		names = append(names, m.accounts[idx].instance.ID()+"/"+m.accounts[idx].instance.AccountName)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestModel_Sort(t *testing.T) {
+	m := newTestModel()
+
+	if got, want := order(m), "claude/b,kimi/,claude/a,zai/"; got != want {
+		t.Errorf("utilization order = %s, want %s", got, want)
+	}
+
+	m = press(m, "s")
+	if got, want := order(m), "kimi/,claude/b,claude/a,zai/"; got != want {
+		t.Errorf("reset order = %s, want %s", got, want)
+	}
+}
+
+func TestModel_Filter(t *testing.T) {
+	m := newTestModel()
+
+	m = press(m, "f")
+	if got, want := order(m), "claude/b,claude/a"; got != want {
+		t.Errorf("filtered order = %s, want %s", got, want)
+	}
+
+	m = press(m, "f")
+	m = press(m, "f")
+	m = press(m, "f")
+	if m.filter != "" {
+		t.Errorf("filter = %q after cycling, want all", m.filter)
+	}
+}
+
+func TestModel_Detail(t *testing.T) {
+	m := newTestModel()
+
+	m = press(m, "j")
+	m = press(m, "enter")
+	view := m.View()
+	if !strings.Contains(view, "subscription") || !strings.Contains(view, "search") {
+		t.Errorf("detail view missing Extra details:\n%s", view)
+	}
+}
+
+func TestModel_RefreshAccount(t *testing.T) {
+	m := newTestModel()
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = next.(Model)
+	if cmd == nil {
+		t.Fatal("refresh returned no command")
+	}
+	if msg, ok := cmd().(usageMsg); !ok || msg.idx != 1 {
+		t.Errorf("refresh fetched %+v, want selected account 1", msg)
+	}
+}
+
+func TestModel_Apply(t *testing.T) {
+	// Hide Kimi's only window and rename the rest
+	apply := func(stats *provider.UsageStats) *provider.UsageStats {
+		out := &provider.UsageStats{}
+		for _, u := range stats.Providers {
+			if u.Provider == "kimi" {
+				continue
+			}
+			windows := make([]provider.UsageWindow, len(u.Windows))
+			for i, w := range u.Windows {
+				w.Label = "Session"
+				windows[i] = w
+			}
+			u.Windows = windows
+			out.Providers = append(out.Providers, u)
+		}
+		return out
+	}
+
+	base := newTestModel()
+	instances := make([]usage.ProviderInstance, len(base.accounts))
+	for i, a := range base.accounts {
+		instances[i] = a.instance
+	}
+	m := NewModel(instances, time.Minute, apply)
+	for i, a := range m.accounts {
+		next, _ := m.Update(fetch(i, a.instance)().(usageMsg))
+		m = next.(Model)
+	}
+
+	if got, want := order(m), "claude/b,claude/a,zai/"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+	if view := m.View(); !strings.Contains(view, "Session") || strings.Contains(view, "5-Hour") {
+		t.Errorf("view doesn't use the applied labels:\n%s", view)
+	}
+}
@@ -0,0 +1,329 @@
+package top
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// Color palette, shared with the setup wizard
+var (
+	titleColor    = lipgloss.Color("86")  // Cyan
+	cursorColor   = lipgloss.Color("213") // Pink
+	dimColor      = lipgloss.Color("241") // Dim gray
+	mutedColor    = lipgloss.Color("245") // Muted gray
+	normalColor   = lipgloss.Color("70")  // Green
+	warningColor  = lipgloss.Color("214") // Orange
+	criticalColor = lipgloss.Color("203") // Red
+)
+
+// Style definitions
+var (
+	titleStyle    = lipgloss.NewStyle().Foreground(titleColor).Bold(true)
+	cursorStyle   = lipgloss.NewStyle().Foreground(cursorColor).Bold(true)
+	accountStyle  = lipgloss.NewStyle().Bold(true)
+	dimStyle      = lipgloss.NewStyle().Foreground(dimColor)
+	mutedStyle    = lipgloss.NewStyle().Foreground(mutedColor)
+	errorStyle    = lipgloss.NewStyle().Foreground(criticalColor)
+	severityStyle = map[string]lipgloss.Style{
+		"normal":   lipgloss.NewStyle().Foreground(normalColor),
+		"warning":  lipgloss.NewStyle().Foreground(warningColor).Bold(true),
+		"critical": lipgloss.NewStyle().Foreground(criticalColor).Bold(true),
+	}
+)
+
+// barWidth is the width of utilization bars
+const barWidth = 24
+
+// View renders the dashboard
+func (m Model) View() string {
+	var b strings.Builder
+
+	filter := "all"
+	if m.filter != "" {
+		filter = usage.ProviderName(m.filter)
+	}
+	header := titleStyle.Render("llm-usage top")
+	status := fmt.Sprintf("  sort: %s  filter: %s", m.sort, filter)
+	if m.interval > 0 && !m.nextRefresh.IsZero() {
+		status += "  next refresh: " + formatCountdown(m.nextRefresh.Sub(m.now))
+	}
+	b.WriteString(header + mutedStyle.Render(status) + "\n\n")
+
+	visible := m.visible()
+	switch {
+	case m.showHelp:
+		b.WriteString(helpText())
+	case len(visible) == 0:
+		b.WriteString(dimStyle.Render("No accounts to show.") + "\n")
+	case m.detail && m.cursor < len(visible):
+		b.WriteString(m.renderDetail(m.accounts[visible[m.cursor]]))
+	default:
+		blocks := make([]string, len(visible))
+		for i, idx := range visible {
+			blocks[i] = m.renderAccount(m.accounts[idx], i == m.cursor)
+		}
+		b.WriteString(strings.Join(m.scroll(blocks), ""))
+	}
+
+	b.WriteString("\n" + dimStyle.Render("↑/↓ select • enter details • r refresh • R refresh all • s sort • f filter • ? help • q quit"))
+	return b.String()
+}
+
+// scroll returns the account blocks that fit the terminal, keeping the selected one visible
+func (m Model) scroll(blocks []string) []string {
+	available := m.height - 4 // header and footer
+	if m.height == 0 || available <= 0 {
+		return blocks
+	}
+
+	lines := func(from, to int) int {
+		n := 0
+		for _, blk := range blocks[from:to] {
+			n += strings.Count(blk, "\n")
+		}
+		return n
+	}
+
+	start := 0
+	for start < m.cursor && lines(start, m.cursor+1) > available {
+		start++
+	}
+	end := start
+	for end < len(blocks) && lines(start, end+1) <= available {
+		end++
+	}
+	if end == start {
+		end = start + 1
+	}
+	return blocks[start:end]
+}
+
+// renderAccount renders an account's summary block
+func (m Model) renderAccount(a *account, selected bool) string {
+	var b strings.Builder
+
+	cursor := "  "
+	if selected {
+		cursor = cursorStyle.Render("▶ ")
+	}
+	line := cursor + accountStyle.Render(displayName(a))
+	if a.loading {
+		line += dimStyle.Render("  refreshing…")
+	} else if !a.fetchedAt.IsZero() {
+		line += dimStyle.Render("  updated " + formatAgo(m.now.Sub(a.fetchedAt)))
+	}
+	b.WriteString(line + "\n")
+
+	switch {
+	case a.usage == nil:
+		b.WriteString("    " + dimStyle.Render("loading…") + "\n")
+	case a.usage.Error != nil:
+		b.WriteString("    " + errorStyle.Render("Error: "+a.usage.Error.Error()) + "\n")
+	default:
+		for _, w := range a.usage.Windows {
+			b.WriteString("    " + m.renderWindow(a, w) + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	return b.String()
+}
+
+// renderWindow renders a usage window line with a colored bar and live countdown
+func (m Model) renderWindow(a *account, w provider.UsageWindow) string {
+	style := severityStyle[provider.ClassFor(a.instance.ID(), a.instance.AccountName, w.Label, w.Utilization)]
+
+	filled := int(w.Utilization / 100 * barWidth)
+	filled = max(0, min(barWidth, filled))
+	bar := style.Render(strings.Repeat("█", filled)) + dimStyle.Render(strings.Repeat("░", barWidth-filled))
+
+	line := fmt.Sprintf("%-14s %s %s", truncate(w.Label, 14), bar, style.Render(fmt.Sprintf("%5.1f%%", w.Utilization)))
+	if w.ResetsAt != nil {
+		line += mutedStyle.Render("  resets in " + formatCountdown(w.ResetsAt.Sub(m.now)))
+	}
+	return line
+}
+
+// renderDetail renders all windows and Extra details of an account
+func (m Model) renderDetail(a *account) string {
+	var b strings.Builder
+	b.WriteString(accountStyle.Render(displayName(a)) + dimStyle.Render("  (esc to go back)") + "\n\n")
+
+	if a.usage == nil {
+		return b.String() + dimStyle.Render("loading…") + "\n"
+	}
+	if a.usage.Error != nil {
+		return b.String() + errorStyle.Render("Error: "+a.usage.Error.Error()) + "\n"
+	}
+
+	for _, w := range a.usage.Windows {
+		b.WriteString(m.renderWindow(a, w) + "\n")
+		var amounts []string
+		if w.Used != nil {
+			amounts = append(amounts, "used "+formatNumber(*w.Used))
+		}
+		if w.Remaining != nil {
+			amounts = append(amounts, "remaining "+formatNumber(*w.Remaining))
+		}
+		if w.Limit != nil {
+			amounts = append(amounts, "limit "+formatNumber(*w.Limit))
+		}
+		if w.ResetsAt != nil {
+			amounts = append(amounts, "resets "+w.ResetsAt.Local().Format("Mon Jan 2 15:04"))
+		}
+		if len(amounts) > 0 {
+			b.WriteString("  " + dimStyle.Render(strings.Join(amounts, " • ")) + "\n")
+		}
+	}
+
+	if bal := a.usage.Balance; bal != nil {
+		b.WriteString("\n" + titleStyle.Render("Balance") + "\n")
+		b.WriteString("  " + usage.BalanceSummary(bal, time.Now()) + "\n")
+	}
+
+	if sub := a.usage.Subscription; sub != nil {
+		b.WriteString("\n" + titleStyle.Render("Subscription") + "\n")
+		b.WriteString("  " + usage.SubscriptionSummary(sub, time.Now()) + "\n")
+		for _, f := range sub.Features {
+			b.WriteString("  " + mutedStyle.Render(f.Name+": ") + fmt.Sprintf("%d/%d left", f.Left, f.Total) + "\n")
+		}
+	}
+
+	extra := make(map[string]any, len(a.usage.Extra))
+	for k, v := range a.usage.Extra {
+		if k != "account" {
+			extra[k] = v
+		}
+	}
+	if len(extra) > 0 {
+		b.WriteString("\n" + titleStyle.Render("Details") + "\n")
+		renderValue(&b, extra, 1)
+	}
+
+	return b.String()
+}
+
+// renderValue renders nested Extra values as an indented tree
+func renderValue(b *strings.Builder, v any, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch val := normalize(v).(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := normalize(val[k])
+			switch child.(type) {
+			case map[string]any, []any:
+				b.WriteString(indent + mutedStyle.Render(k+":") + "\n")
+				renderValue(b, child, depth+1)
+			default:
+				b.WriteString(indent + mutedStyle.Render(k+": ") + fmt.Sprint(child) + "\n")
+			}
+		}
+	case []any:
+		for i, item := range val {
+			b.WriteString(indent + dimStyle.Render(fmt.Sprintf("[%d]", i)) + "\n")
+			renderValue(b, item, depth+1)
+		}
+	default:
+		b.WriteString(indent + fmt.Sprint(val) + "\n")
+	}
+}
+
+// normalize converts provider-specific structs in Extra to generic maps and slices
+func normalize(v any) any {
+	switch v.(type) {
+	case nil, string, bool, float64, int, int64, map[string]any, []any:
+		return v
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return fmt.Sprint(v)
+	}
+	return out
+}
+
+// helpText returns the keybinding reference
+func helpText() string {
+	keys := [][2]string{
+		{"↑/k ↓/j", "select account"},
+		{"enter", "show windows and details of the selected account"},
+		{"esc", "back / quit"},
+		{"r", "refresh the selected account now"},
+		{"R", "refresh all accounts now"},
+		{"s", "cycle sort: utilization, reset time, name"},
+		{"f", "cycle provider filter"},
+		{"?", "toggle this help"},
+		{"q", "quit"},
+	}
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString("  " + cursorStyle.Render(fmt.Sprintf("%-10s", k[0])) + " " + k[1] + "\n")
+	}
+	return b.String()
+}
+
+// formatCountdown formats a duration as a live countdown
+func formatCountdown(d time.Duration) string {
+	if d <= 0 {
+		return "now"
+	}
+	d = d.Truncate(time.Second)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %02dh %02dm", days, hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh %02dm %02ds", hours, minutes, seconds)
+	default:
+		return fmt.Sprintf("%dm %02ds", minutes, seconds)
+	}
+}
+
+// formatAgo formats the time since an update
+func formatAgo(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	}
+	return fmt.Sprintf("%dm ago", int(d.Minutes()))
+}
+
+// formatNumber formats a quota amount
+func formatNumber(v float64) string {
+	switch {
+	case v >= 1_000_000:
+		return fmt.Sprintf("%.1fM", v/1_000_000)
+	case v >= 1_000:
+		return fmt.Sprintf("%.1fK", v/1_000)
+	default:
+		return fmt.Sprintf("%.0f", v)
+	}
+}
+
+// truncate shortens s to n runes
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,31 @@
+package usage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// FormatAmount formats a balance amount, e.g. "12.50 USD"
+func FormatAmount(amount float64, currency string) string {
+	return strings.TrimSpace(fmt.Sprintf("%.2f %s", amount, currency))
+}
+
+// BalanceSummary returns a one-line balance summary, e.g.
+// "12.50 USD left of 20.00, grant expires in 3d"
+func BalanceSummary(b *provider.Balance, now time.Time) string {
+	summary := FormatAmount(b.Amount, b.Currency) + " left"
+	if total := b.Total(); total > 0 {
+		summary += fmt.Sprintf(" of %.2f", total)
+	}
+	if b.ExpiresAt != nil {
+		if remaining := b.ExpiresAt.Sub(now); remaining > 0 {
+			summary += ", grant expires in " + FormatDuration(remaining)
+		} else {
+			summary += ", grant expired"
+		}
+	}
+	return summary
+}
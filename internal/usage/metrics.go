@@ -0,0 +1,207 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/version"
+)
+
+// metricsHTTPClient is used to write InfluxDB line protocol to an HTTP endpoint
+var metricsHTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+// usageAccount returns the account name recorded in a usage's Extra
+func usageAccount(u provider.Usage) string {
+	account, _ := u.Extra["account"].(string)
+	return account
+}
+
+// WritePrometheus writes usage stats in the Prometheus text exposition format
+func WritePrometheus(w io.Writer, stats *provider.UsageStats) error {
+	var buf bytes.Buffer
+
+	type family struct {
+		name, help string
+		value      func(provider.UsageWindow) (float64, bool)
+	}
+	families := []family{
+		{"llm_usage_utilization_percent", "Usage window utilization in percent", func(w provider.UsageWindow) (float64, bool) {
+			return w.Utilization, true
+		}},
+		{"llm_usage_resets_at_seconds", "Unix time when the usage window resets", func(w provider.UsageWindow) (float64, bool) {
+			if w.ResetsAt == nil {
+				return 0, false
+			}
+			return float64(w.ResetsAt.Unix()), true
+		}},
+		{"llm_usage_remaining", "Remaining quota in the usage window, in provider units", func(w provider.UsageWindow) (float64, bool) {
+			if w.Remaining == nil {
+				return 0, false
+			}
+			return *w.Remaining, true
+		}},
+		{"llm_usage_limit", "Quota limit of the usage window, in provider units", func(w provider.UsageWindow) (float64, bool) {
+			if w.Limit == nil {
+				return 0, false
+			}
+			return *w.Limit, true
+		}},
+	}
+
+	for _, f := range families {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s gauge\n", f.name, f.help, f.name)
+		for _, u := range stats.Providers {
+			if u.Error != nil {
+				continue
+			}
+			for _, win := range u.Windows {
+				if v, ok := f.value(win); ok {
+					fmt.Fprintf(&buf, "%s{provider=\"%s\",account=\"%s\",window=\"%s\"} %s\n",
+						f.name, promEscape(u.Provider), promEscape(usageAccount(u)), promEscape(win.Label), formatMetric(v))
+				}
+			}
+		}
+	}
+
+	buf.WriteString("# HELP llm_usage_up Whether the last usage fetch succeeded\n# TYPE llm_usage_up gauge\n")
+	for _, u := range stats.Providers {
+		up := 1
+		if u.Error != nil {
+			up = 0
+		}
+		fmt.Fprintf(&buf, "llm_usage_up{provider=\"%s\",account=\"%s\"} %d\n", promEscape(u.Provider), promEscape(usageAccount(u)), up)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WritePromTextfile atomically writes usage stats to a node_exporter textfile collector file
+func WritePromTextfile(path string, stats *provider.UsageStats) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := WritePrometheus(tmp, stats); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move metrics into place: %w", err)
+	}
+	return nil
+}
+
+// promEscape escapes a Prometheus label value
+func promEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// WriteInflux writes usage stats as InfluxDB line protocol
+func WriteInflux(w io.Writer, stats *provider.UsageStats, now time.Time) error {
+	var buf bytes.Buffer
+	ts := now.UnixNano()
+
+	for _, u := range stats.Providers {
+		tags := "provider=" + influxTag(u.Provider)
+		if account := usageAccount(u); account != "" {
+			tags += ",account=" + influxTag(account)
+		}
+
+		up := "1i"
+		if u.Error != nil {
+			up = "0i"
+		}
+		fmt.Fprintf(&buf, "llm_usage_status,%s up=%s %d\n", tags, up, ts)
+
+		if u.Error != nil {
+			continue
+		}
+		for _, win := range u.Windows {
+			fields := []string{"utilization=" + formatMetric(win.Utilization)}
+			if win.ResetsAt != nil {
+				fields = append(fields, "resets_at="+strconv.FormatInt(win.ResetsAt.Unix(), 10)+"i")
+			}
+			if win.Remaining != nil {
+				fields = append(fields, "remaining="+formatMetric(*win.Remaining))
+			}
+			if win.Limit != nil {
+				fields = append(fields, "limit="+formatMetric(*win.Limit))
+			}
+			if win.Used != nil {
+				fields = append(fields, "used="+formatMetric(*win.Used))
+			}
+			fmt.Fprintf(&buf, "llm_usage,%s,window=%s %s %d\n", tags, influxTag(win.Label), strings.Join(fields, ","), ts)
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// PostInflux writes usage stats as line protocol to an InfluxDB HTTP write
+// endpoint (e.g. /api/v2/write?org=...&bucket=... or /write?db=...).
+// $INFLUX_TOKEN, when set, is sent as the authorization token.
+func PostInflux(ctx context.Context, endpoint string, stats *provider.UsageStats) error {
+	var buf bytes.Buffer
+	if err := WriteInflux(&buf, stats, time.Now()); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", "llm-usage/"+version.Version)
+	if token := os.Getenv("INFLUX_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+
+	resp, err := metricsHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write to InfluxDB: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("InfluxDB returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// influxTag escapes an InfluxDB tag key or value
+func influxTag(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", "").Replace(s)
+}
+
+// formatMetric formats a float without exponent noise
+func formatMetric(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func sampleStats() *provider.UsageStats {
+	resetsAt := time.Unix(1_800_000_000, 0)
+	remaining := 250.0
+	return &provider.UsageStats{Providers: []provider.Usage{
+		{
+			Provider: "claude",
+			Windows: []provider.UsageWindow{
+				{Label: "5-Hour", Utilization: 42.5, ResetsAt: &resetsAt},
+				{Label: "Weekly \"Opus\"", Utilization: 10, Remaining: &remaining},
+			},
+			Extra: map[string]any{"account": "work team"},
+		},
+		{
+			Provider: "kimi",
+			Error:    errors.New("Kimi: unauthorized"),
+		},
+	}}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, sampleStats()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE llm_usage_utilization_percent gauge\n",
+		`llm_usage_utilization_percent{provider="claude",account="work team",window="5-Hour"} 42.5`,
+		`llm_usage_utilization_percent{provider="claude",account="work team",window="Weekly \"Opus\""} 10`,
+		`llm_usage_resets_at_seconds{provider="claude",account="work team",window="5-Hour"} 1800000000`,
+		`llm_usage_remaining{provider="claude",account="work team",window="Weekly \"Opus\""} 250`,
+		`llm_usage_up{provider="kimi",account=""} 0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWritePromTextfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "llm_usage.prom")
+
+	if err := WritePromTextfile(path, sampleStats()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "llm_usage_up") {
+		t.Errorf("textfile missing metrics:\n%s", data)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestWriteInflux(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteInflux(&buf, sampleStats(), time.Unix(1_700_000_000, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `llm_usage_status,provider=claude,account=work\ team up=1i 1700000000000000000
+llm_usage,provider=claude,account=work\ team,window=5-Hour utilization=42.5,resets_at=1800000000i 1700000000000000000
+llm_usage,provider=claude,account=work\ team,window=Weekly\ "Opus" utilization=10,remaining=250 1700000000000000000
+llm_usage_status,provider=kimi up=0i 1700000000000000000
+`
+	if buf.String() != want {
+		t.Errorf("WriteInflux =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
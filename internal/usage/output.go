@@ -3,8 +3,10 @@ package usage
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
@@ -37,78 +39,46 @@ type WaybarOutput struct {
 
 // OutputWaybar outputs usage stats in waybar JSON format
 func OutputWaybar(stats *provider.UsageStats) {
-	// Build compact text for the bar
-	var textParts []string
-	for _, p := range stats.Providers {
-		if p.Error != nil {
-			continue
-		}
-		providerLabel := providerShortName(p.Provider)
-		if len(p.Windows) > 0 {
-			// Use the first window's utilization for the compact display
-			textParts = append(textParts, fmt.Sprintf("%s:%.0f%%", providerLabel, p.Windows[0].Utilization))
-		}
-	}
-	text := strings.Join(textParts, " ")
-
-	// Build detailed tooltip
-	var tooltipLines []string
-	tooltipLines = append(tooltipLines, "LLM Usage")
-	tooltipLines = append(tooltipLines, "")
-
-	for _, p := range stats.Providers {
-		if p.Error != nil {
-			tooltipLines = append(tooltipLines, fmt.Sprintf("%s: Error", ProviderName(p.Provider)))
-			continue
-		}
-
-		// Get account name if available
-		accountSuffix := ""
-		if acc, ok := p.Extra["account"]; ok && acc != "" {
-			accountSuffix = fmt.Sprintf(" (%s)", acc)
-		}
-
-		for _, w := range p.Windows {
-			line := fmt.Sprintf("%s%s %s: %.1f%%", ProviderName(p.Provider), accountSuffix, w.Label, w.Utilization)
-			if d := w.TimeUntilReset(); d != nil {
-				line += fmt.Sprintf(" (resets in %s)", FormatDuration(*d))
-			}
-			tooltipLines = append(tooltipLines, line)
-		}
+	if err := WriteWaybar(os.Stdout, Summarize(stats, BarView{})); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
 	}
+}
 
-	output := WaybarOutput{
-		Text:       text,
-		Tooltip:    strings.Join(tooltipLines, "\n"),
-		Class:      stats.GetClass(),
-		Percentage: int(stats.MaxUtilization()),
-	}
+// WriteWaybar writes a status summary as a single waybar JSON line
+func WriteWaybar(w io.Writer, summary StatusSummary) error {
+	return json.NewEncoder(w).Encode(WaybarOutput{
+		Text:       summary.Text,
+		Tooltip:    summary.Tooltip(),
+		Class:      summary.Class,
+		Percentage: summary.Percentage,
+	})
+}
 
-	enc := json.NewEncoder(os.Stdout)
-	if err := enc.Encode(output); err != nil {
+// OutputWaybarError outputs an error in waybar JSON format
+func OutputWaybarError(msg string) {
+	if err := WriteWaybarError(os.Stdout, msg); err != nil {
 		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
 	}
 }
 
-// OutputWaybarError outputs an error in waybar JSON format
-func OutputWaybarError(msg string) {
-	output := WaybarOutput{
+// WriteWaybarError writes an error as a single waybar JSON line
+func WriteWaybarError(w io.Writer, msg string) error {
+	return json.NewEncoder(w).Encode(WaybarOutput{
 		Text:       "LLM: Error",
 		Tooltip:    msg,
 		Class:      "error",
 		Percentage: 0,
-	}
-	enc := json.NewEncoder(os.Stdout)
-	if err := enc.Encode(output); err != nil {
-		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
-	}
+	})
 }
 
-// OutputJSON outputs usage stats in JSON format
+// OutputJSON outputs usage stats in JSON format, with group rollups
 func OutputJSON(stats *provider.UsageStats) {
+	out := *stats
+	out.Groups = stats.Rollup()
+
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
-	if err := enc.Encode(stats); err != nil {
+	if err := enc.Encode(&out); err != nil {
 		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
 		os.Exit(1)
 	}
@@ -146,13 +116,45 @@ func OutputPretty(stats *provider.UsageStats) {
 			printExtraUsageFromMap(extra)
 		}
 
-		// Print subscription info if available (for Kimi)
-		if sub, ok := p.Extra["subscription"]; ok {
-			printKimiSubscription(sub)
+		if p.Balance != nil {
+			printBalance(p.Provider, p.Extra["account"], p.Balance)
+		}
+
+		if p.Subscription != nil {
+			printSubscription(p.Subscription)
 		}
 
 		fmt.Println()
 	}
+
+	printGroups(stats.Rollup())
+}
+
+// printGroups prints the per-group rollups
+func printGroups(groups []provider.GroupRollup) {
+	if len(groups) == 0 {
+		return
+	}
+
+	fmt.Println("Groups:")
+	fmt.Println("-------")
+	for _, g := range groups {
+		errSuffix := ""
+		if g.Errors > 0 {
+			errSuffix = fmt.Sprintf(", %d failed", g.Errors)
+		}
+		noun := "accounts"
+		if len(g.Accounts) == 1 {
+			noun = "account"
+		}
+		fmt.Printf("  %s (%d %s%s):\n", g.Group, len(g.Accounts), noun, errSuffix)
+		for _, w := range g.Windows {
+			bar := RenderProgressBar(w.MaxUtilization)
+			fmt.Printf("    %-10s %s  %.1f%% max, %.1f%% avg\n", w.Label+":", bar, w.MaxUtilization, w.AvgUtilization)
+		}
+		fmt.Printf("    Accounts:  %s\n", strings.Join(g.Accounts, ", "))
+	}
+	fmt.Println()
 }
 
 func printExtraUsageFromMap(extra any) {
@@ -224,6 +226,19 @@ func FormatDuration(d time.Duration) string {
 	return strings.Join(parts, " ")
 }
 
+// registeredNames holds display names for providers that aren't built in
+var (
+	registeredNamesMu sync.RWMutex
+	registeredNames   = map[string]string{}
+)
+
+// RegisterProviderName sets the display name for a provider that isn't built in
+func RegisterProviderName(id, name string) {
+	registeredNamesMu.Lock()
+	defer registeredNamesMu.Unlock()
+	registeredNames[id] = name
+}
+
 // ProviderName returns the display name for a provider
 func ProviderName(id string) string {
 	switch id {
@@ -234,6 +249,12 @@ func ProviderName(id string) string {
 	case "zai":
 		return "Z.AI"
 	default:
+		registeredNamesMu.RLock()
+		name, ok := registeredNames[id]
+		registeredNamesMu.RUnlock()
+		if ok {
+			return name
+		}
 		return strings.ToUpper(id)
 	}
 }
@@ -251,91 +272,89 @@ func providerShortName(id string) string {
 	}
 }
 
-// printKimiSubscription prints Kimi subscription info with colors
-func printKimiSubscription(sub any) {
-	subMap, ok := sub.(map[string]any)
-	if !ok {
-		return
+// printBalance prints a prepaid balance, colored by its class
+func printBalance(providerID string, account any, b *provider.Balance) {
+	acc, _ := account.(string)
+	amount := FormatAmount(b.Amount, b.Currency)
+	switch b.Class(providerID, acc) {
+	case "critical":
+		amount = statusExpiredStyle.Render(amount)
+	case "warning":
+		amount = statusCancelledStyle.Render(amount)
 	}
 
-	fmt.Println(subscriptionTitleStyle.Render("Subscription:"))
-
-	// Print plan info
-	if plan, ok := subMap["plan"].(map[string]any); ok {
-		title := getStringValue(plan, "title")
-		level := getStringValue(plan, "level")
-		status := getStringValue(plan, "status")
-
-		// Style the status based on its value
-		var styledStatus string
-		switch status {
-		case "Active":
-			styledStatus = statusActiveStyle.Render(status)
-		case "Cancelled":
-			styledStatus = statusCancelledStyle.Render(status)
-		case "Expired":
-			styledStatus = statusExpiredStyle.Render(status)
-		default:
-			styledStatus = status
-		}
-
-		fmt.Printf("  Plan:     %s %s %s\n", title, dimStyle.Render("("+level+")"), styledStatus)
+	fmt.Println("Balance:")
+	fmt.Printf("  Amount:   %s\n", amount)
+	var parts []string
+	if b.Granted != nil {
+		parts = append(parts, fmt.Sprintf("granted %.2f", *b.Granted))
 	}
-
-	// Print expiry info
-	if expiresAt, ok := subMap["expires_at"].(string); ok && expiresAt != "" {
-		if t, err := time.Parse(time.RFC3339, expiresAt); err == nil {
-			remaining := time.Until(t)
-			var expiryStr string
-			if remaining > 0 {
-				expiryStr = fmt.Sprintf("%s %s", t.Format("2006-01-02"), dimStyle.Render("("+FormatDuration(remaining)+" remaining)"))
-			} else {
-				expiryStr = statusExpiredStyle.Render(t.Format("2006-01-02") + " (expired)")
-			}
-			fmt.Printf("  Expires:  %s\n", expiryStr)
+	if b.ToppedUp != nil {
+		parts = append(parts, fmt.Sprintf("topped up %.2f", *b.ToppedUp))
+	}
+	if b.Used != nil {
+		parts = append(parts, fmt.Sprintf("used %.2f", *b.Used))
+	}
+	if len(parts) > 0 {
+		fmt.Printf("  Credits:  %s\n", strings.Join(parts, ", "))
+	}
+	if b.ExpiresAt != nil {
+		if remaining := time.Until(*b.ExpiresAt); remaining > 0 {
+			fmt.Printf("  Expires:  %s %s\n", b.ExpiresAt.Format("2006-01-02"), dimStyle.Render("("+FormatDuration(remaining)+" remaining)"))
+		} else {
+			fmt.Printf("  Expires:  %s\n", statusExpiredStyle.Render(b.ExpiresAt.Format("2006-01-02")+" (expired)"))
 		}
 	}
+}
 
-	// Print features/quotas
-	if features, ok := subMap["features"].([]any); ok && len(features) > 0 {
-		fmt.Println("  Features:")
-		for _, f := range features {
-			if feature, ok := f.(map[string]any); ok {
-				name := getStringValue(feature, "feature")
-				left := getIntValue(feature, "left")
-				total := getIntValue(feature, "total")
-
-				// Calculate percentage for progress bar
-				var percentage float64
-				if total > 0 {
-					percentage = float64(total-left) / float64(total) * 100
-				}
-				bar := RenderProgressBar(percentage)
+// printSubscription prints subscription info with colors
+func printSubscription(sub *provider.Subscription) {
+	fmt.Println(subscriptionTitleStyle.Render("Subscription:"))
 
-				fmt.Printf("    %s: %s %s\n",
-					featureNameStyle.Render(name),
-					bar,
-					dimStyle.Render(fmt.Sprintf("%d/%d left", left, total)))
-			}
-		}
+	var styledStatus string
+	switch sub.Status {
+	case provider.SubscriptionActive:
+		styledStatus = statusActiveStyle.Render(sub.StatusTitle())
+	case provider.SubscriptionCancelled:
+		styledStatus = statusCancelledStyle.Render(sub.StatusTitle())
+	case provider.SubscriptionExpired:
+		styledStatus = statusExpiredStyle.Render(sub.StatusTitle())
+	default:
+		styledStatus = sub.StatusTitle()
+	}
+	plan := sub.Plan
+	if sub.Tier != "" {
+		plan += " " + dimStyle.Render("("+sub.Tier+")")
+	}
+	if line := strings.TrimSpace(plan + " " + styledStatus); line != "" {
+		fmt.Printf("  Plan:     %s\n", line)
 	}
-}
 
-// getStringValue safely extracts a string value from a map
-func getStringValue(m map[string]any, key string) string {
-	if v, ok := m[key].(string); ok {
-		return v
+	if price := FormatPrice(sub); price != "" {
+		fmt.Printf("  Price:    %s\n", price)
 	}
-	return ""
-}
 
-// getIntValue safely extracts an int value from a map (handles float64 from JSON)
-func getIntValue(m map[string]any, key string) int {
-	if v, ok := m[key].(float64); ok {
-		return int(v)
+	if sub.ExpiresAt != nil {
+		label := "Expires:"
+		if sub.Renews() {
+			label = "Renews:"
+		}
+		var expiryStr string
+		if remaining := time.Until(*sub.ExpiresAt); remaining > 0 {
+			expiryStr = fmt.Sprintf("%s %s", sub.ExpiresAt.Format("2006-01-02"), dimStyle.Render("("+FormatDuration(remaining)+" remaining)"))
+		} else {
+			expiryStr = statusExpiredStyle.Render(sub.ExpiresAt.Format("2006-01-02") + " (expired)")
+		}
+		fmt.Printf("  %-9s %s\n", label, expiryStr)
 	}
-	if v, ok := m[key].(int); ok {
-		return v
+
+	if len(sub.Features) > 0 {
+		fmt.Println("  Features:")
+		for _, f := range sub.Features {
+			fmt.Printf("    %s: %s %s\n",
+				featureNameStyle.Render(f.Name),
+				RenderProgressBar(f.Utilization()),
+				dimStyle.Render(fmt.Sprintf("%d/%d left", f.Left, f.Total)))
+		}
 	}
-	return 0
 }
@@ -3,15 +3,21 @@ package usage
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/denysvitali/llm-usage/internal/credentials"
 	"github.com/denysvitali/llm-usage/internal/provider"
 	"github.com/denysvitali/llm-usage/internal/provider/claude"
+	"github.com/denysvitali/llm-usage/internal/provider/declarative"
 	"github.com/denysvitali/llm-usage/internal/provider/kimi"
 	"github.com/denysvitali/llm-usage/internal/provider/minimax"
+	"github.com/denysvitali/llm-usage/internal/provider/wasm"
 	"github.com/denysvitali/llm-usage/internal/provider/zai"
 )
 
@@ -26,6 +32,7 @@ const (
 type ProviderInstance struct {
 	provider.Provider
 	AccountName string
+	credentials.AccountMeta
 }
 
 // LoadClaudeFromKeychain tries to load Claude credentials from the CLI keychain location
@@ -55,8 +62,9 @@ func LoadClaudeFromKeychain() (*credentials.OAuthCredentials, string, error) {
 	return result.ClaudeAiOauth, "default", nil
 }
 
-// GetProviders returns the list of providers to query based on the flags
-func GetProviders(providerFlag, accountFlag string, allAccounts bool, credsMgr *credentials.Manager) []ProviderInstance {
+// GetProviders returns the list of providers to query based on the flags,
+// narrowed down to the accounts matching the tag/group selection
+func GetProviders(providerFlag, accountFlag string, allAccounts bool, credsMgr *credentials.Manager, sel Selection) []ProviderInstance {
 	var providerIDs []string
 
 	if providerFlag == "all" || providerFlag == "" {
@@ -70,6 +78,9 @@ func GetProviders(providerFlag, accountFlag string, allAccounts bool, credsMgr *
 		providerIDs = strings.Split(providerFlag, ",")
 	}
 
+	definitions := loadDefinitions(credsMgr)
+	plugins := loadPlugins(credsMgr)
+
 	var providers []ProviderInstance
 	for _, pid := range providerIDs {
 		pid = strings.TrimSpace(pid)
@@ -82,9 +93,100 @@ func GetProviders(providerFlag, accountFlag string, allAccounts bool, credsMgr *
 			providers = append(providers, getZaiProviders(accountFlag, allAccounts, credsMgr)...)
 		case providerMiniMax:
 			providers = append(providers, getMiniMaxProviders(accountFlag, allAccounts, credsMgr)...)
+		default:
+			if def, ok := definitions[pid]; ok {
+				providers = append(providers, getGenericProviders(pid, accountFlag, allAccounts, credsMgr, func(acc credentials.GenericAccount) provider.Provider {
+					return declarative.NewProvider(def, acc)
+				})...)
+			} else if plugin, ok := plugins[pid]; ok {
+				providers = append(providers, getGenericProviders(pid, accountFlag, allAccounts, credsMgr, func(acc credentials.GenericAccount) provider.Provider {
+					return wasm.NewProvider(plugin, acc)
+				})...)
+			}
 		}
 	}
 
+	if !sel.IsZero() {
+		providers = slices.DeleteFunc(providers, func(p ProviderInstance) bool {
+			return !sel.MatchMeta(p.AccountMeta)
+		})
+	}
+	return providers
+}
+
+// loadDefinitions loads the config-defined providers and registers their display names.
+// Definitions that shadow a built-in provider are ignored.
+func loadDefinitions(credsMgr *credentials.Manager) map[string]*declarative.Definition {
+	defs, errs := declarative.LoadDefinitions(filepath.Join(credsMgr.ConfigDir(), declarative.DefinitionsDir))
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	definitions := make(map[string]*declarative.Definition, len(defs))
+	for _, def := range defs {
+		switch def.ID {
+		case providerClaude, providerKimi, providerZAi, providerMiniMax:
+			fmt.Fprintf(os.Stderr, "Warning: provider definition %q conflicts with a built-in provider\n", def.ID)
+			continue
+		}
+		definitions[def.ID] = def
+		RegisterProviderName(def.ID, def.Name)
+	}
+	return definitions
+}
+
+// loadPlugins loads the WebAssembly provider plugins and registers their display names.
+// Plugins that shadow a built-in provider are ignored.
+func loadPlugins(credsMgr *credentials.Manager) map[string]*wasm.Plugin {
+	loaded, errs := wasm.LoadPlugins(filepath.Join(credsMgr.ConfigDir(), wasm.PluginsDir))
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	plugins := make(map[string]*wasm.Plugin, len(loaded))
+	for _, plugin := range loaded {
+		switch plugin.ID {
+		case providerClaude, providerKimi, providerZAi, providerMiniMax:
+			fmt.Fprintf(os.Stderr, "Warning: plugin %q conflicts with a built-in provider\n", plugin.ID)
+			continue
+		}
+		plugins[plugin.ID] = plugin
+		RegisterProviderName(plugin.ID, plugin.Manifest.Name)
+	}
+	return plugins
+}
+
+// getGenericProviders returns instances of a config-defined or plugin provider,
+// one per account in its generic credentials file
+func getGenericProviders(providerID, accountFlag string, allAccounts bool, credsMgr *credentials.Manager, newProvider func(credentials.GenericAccount) provider.Provider) []ProviderInstance {
+	var providers []ProviderInstance
+
+	creds, err := credsMgr.LoadGeneric(providerID)
+	if err != nil {
+		return providers
+	}
+
+	if allAccounts || accountFlag == "" {
+		for _, accName := range creds.ListAccounts() {
+			acc := creds.GetAccount(accName)
+			providers = append(providers, ProviderInstance{
+				Provider:    newProvider(acc),
+				AccountName: accName,
+				AccountMeta: acc.Meta(),
+			})
+		}
+	} else {
+		acc := creds.GetAccount(accountFlag)
+		if acc == nil {
+			return providers
+		}
+		providers = append(providers, ProviderInstance{
+			Provider:    newProvider(acc),
+			AccountName: accountFlag,
+			AccountMeta: acc.Meta(),
+		})
+	}
+
 	return providers
 }
 
@@ -116,6 +218,7 @@ func getClaudeProviders(accountFlag string, credsMgr *credentials.Manager) []Pro
 		providers = append(providers, ProviderInstance{
 			Provider:    claude.NewProvider(oauth.AccessToken),
 			AccountName: accountFlag,
+			AccountMeta: multiCreds.Meta(accountFlag),
 		})
 		return providers
 	}
@@ -123,10 +226,14 @@ func getClaudeProviders(accountFlag string, credsMgr *credentials.Manager) []Pro
 	// No specific account requested - show all available
 	// Add from keychain if available
 	if keychainErr == nil && !claude.IsExpired(keychainCreds.ExpiresAt) {
-		providers = append(providers, ProviderInstance{
+		inst := ProviderInstance{
 			Provider:    claude.NewProvider(keychainCreds.AccessToken),
 			AccountName: keychainAccount,
-		})
+		}
+		if multiErr == nil {
+			inst.AccountMeta = multiCreds.Meta(keychainAccount)
+		}
+		providers = append(providers, inst)
 	}
 	// Add from multi-account location if available
 	if multiErr == nil {
@@ -142,6 +249,7 @@ func getClaudeProviders(accountFlag string, credsMgr *credentials.Manager) []Pro
 			providers = append(providers, ProviderInstance{
 				Provider:    claude.NewProvider(oauth.AccessToken),
 				AccountName: accName,
+				AccountMeta: multiCreds.Meta(accName),
 			})
 		}
 	}
@@ -168,6 +276,7 @@ func getKimiProviders(accountFlag string, allAccounts bool, credsMgr *credential
 			providers = append(providers, ProviderInstance{
 				Provider:    kimi.NewProvider(acc.APIKey),
 				AccountName: accName,
+				AccountMeta: acc.AccountMeta,
 			})
 		}
 	} else {
@@ -179,6 +288,7 @@ func getKimiProviders(accountFlag string, allAccounts bool, credsMgr *credential
 		providers = append(providers, ProviderInstance{
 			Provider:    kimi.NewProvider(acc.APIKey),
 			AccountName: accountFlag,
+			AccountMeta: acc.AccountMeta,
 		})
 	}
 
@@ -204,6 +314,7 @@ func getZaiProviders(accountFlag string, allAccounts bool, credsMgr *credentials
 			providers = append(providers, ProviderInstance{
 				Provider:    zai.NewProvider(acc.APIKey),
 				AccountName: accName,
+				AccountMeta: acc.AccountMeta,
 			})
 		}
 	} else {
@@ -215,6 +326,7 @@ func getZaiProviders(accountFlag string, allAccounts bool, credsMgr *credentials
 		providers = append(providers, ProviderInstance{
 			Provider:    zai.NewProvider(acc.APIKey),
 			AccountName: accountFlag,
+			AccountMeta: acc.AccountMeta,
 		})
 	}
 
@@ -240,6 +352,7 @@ func getMiniMaxProviders(accountFlag string, allAccounts bool, credsMgr *credent
 			providers = append(providers, ProviderInstance{
 				Provider:    minimax.NewProvider(acc.Cookie, acc.GroupID),
 				AccountName: accName,
+				AccountMeta: acc.AccountMeta,
 			})
 		}
 	} else {
@@ -251,6 +364,7 @@ func getMiniMaxProviders(accountFlag string, allAccounts bool, credsMgr *credent
 		providers = append(providers, ProviderInstance{
 			Provider:    minimax.NewProvider(acc.Cookie, acc.GroupID),
 			AccountName: accountFlag,
+			AccountMeta: acc.AccountMeta,
 		})
 	}
 
@@ -260,7 +374,6 @@ func getMiniMaxProviders(accountFlag string, allAccounts bool, credsMgr *credent
 // FetchAllUsage fetches usage from all providers concurrently
 func FetchAllUsage(providers []ProviderInstance) *provider.UsageStats {
 	var wg sync.WaitGroup
-	var mu sync.Mutex
 
 	stats := &provider.UsageStats{
 		Providers: make([]provider.Usage, len(providers)),
@@ -270,26 +383,7 @@ func FetchAllUsage(providers []ProviderInstance) *provider.UsageStats {
 		wg.Add(1)
 		go func(idx int, prov ProviderInstance) {
 			defer wg.Done()
-
-			usage, err := prov.GetUsage()
-			if err != nil {
-				mu.Lock()
-				stats.Providers[idx] = *provider.NewUsageError(prov.ID(), prov.Name(), err)
-				mu.Unlock()
-				return
-			}
-
-			// Add account name to usage if available
-			if prov.AccountName != "" {
-				if usage.Extra == nil {
-					usage.Extra = make(map[string]any)
-				}
-				usage.Extra["account"] = prov.AccountName
-			}
-
-			mu.Lock()
-			stats.Providers[idx] = *usage
-			mu.Unlock()
+			stats.Providers[idx] = *FetchUsage(prov)
 		}(i, p)
 	}
 
@@ -304,5 +398,91 @@ func FetchAllUsage(providers []ProviderInstance) *provider.UsageStats {
 	}
 	stats.Providers = filtered
 
+	runFetchHooks(stats)
+
 	return stats
 }
+
+// FetchUsage fetches usage from a single provider account. Errors are
+// returned in Usage.Error.
+func FetchUsage(prov ProviderInstance) *provider.Usage {
+	start := time.Now()
+	usage, err := prov.GetUsage()
+	runProviderHooks(ProviderFetch{
+		ProviderID: prov.ID(),
+		Account:    prov.AccountName,
+		Duration:   time.Since(start),
+		Err:        err,
+	})
+	if err != nil {
+		usage = provider.NewUsageError(prov.ID(), prov.Name(), err)
+	}
+
+	// Add account name to usage if available
+	if prov.AccountName != "" {
+		if usage.Extra == nil {
+			usage.Extra = make(map[string]any)
+		}
+		usage.Extra["account"] = prov.AccountName
+	}
+	if len(prov.Tags) > 0 || prov.Group != "" {
+		if usage.Extra == nil {
+			usage.Extra = make(map[string]any)
+		}
+		if len(prov.Tags) > 0 {
+			usage.Extra["tags"] = prov.Tags
+		}
+		if prov.Group != "" {
+			usage.Extra["group"] = prov.Group
+		}
+	}
+
+	return usage
+}
+
+var (
+	fetchHooksMu sync.RWMutex
+	fetchHooks   []func(*provider.UsageStats)
+)
+
+// AddFetchHook registers a function called with the results of every FetchAllUsage call
+func AddFetchHook(hook func(*provider.UsageStats)) {
+	fetchHooksMu.Lock()
+	defer fetchHooksMu.Unlock()
+	fetchHooks = append(fetchHooks, hook)
+}
+
+// ProviderFetch describes a single provider account fetch
+type ProviderFetch struct {
+	ProviderID string
+	Account    string
+	Duration   time.Duration
+	Err        error
+}
+
+var providerHooks []func(ProviderFetch)
+
+// AddProviderHook registers a function called after each provider account fetch
+func AddProviderHook(hook func(ProviderFetch)) {
+	fetchHooksMu.Lock()
+	defer fetchHooksMu.Unlock()
+	providerHooks = append(providerHooks, hook)
+}
+
+// runProviderHooks calls all registered provider hooks
+func runProviderHooks(f ProviderFetch) {
+	fetchHooksMu.RLock()
+	defer fetchHooksMu.RUnlock()
+	for _, hook := range providerHooks {
+		hook(f)
+	}
+}
+
+// runFetchHooks calls all registered fetch hooks
+func runFetchHooks(stats *provider.UsageStats) {
+	fetchHooksMu.RLock()
+	defer fetchHooksMu.RUnlock()
+	for _, hook := range fetchHooks {
+		hook(stats)
+	}
+}
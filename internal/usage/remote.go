@@ -0,0 +1,79 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+	"github.com/denysvitali/llm-usage/internal/version"
+)
+
+// remoteUsagePath is the server endpoint serving UsageStats
+const remoteUsagePath = "/api/v1/usage"
+
+var remoteHTTPClient = &http.Client{
+	Timeout: 60 * time.Second,
+}
+
+// FetchRemote fetches usage statistics from a llm-usage server instead of
+// querying providers directly. providerFilter and accountFilter are passed
+// through to the server and may be empty, as may the tag/group selection.
+func FetchRemote(ctx context.Context, baseURL, token, providerFilter, accountFilter string, sel Selection) (*provider.UsageStats, error) {
+	reqURL, err := url.Parse(strings.TrimSuffix(baseURL, "/") + remoteUsagePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote URL: %w", err)
+	}
+
+	query := reqURL.Query()
+	if providerFilter != "" && providerFilter != "all" {
+		query.Set("provider", providerFilter)
+	}
+	if accountFilter != "" {
+		query.Set("account", accountFilter)
+	}
+	for key, values := range map[string][]string{"tag": sel.Tags, "group": sel.Groups, "exclude_tag": sel.ExcludeTags} {
+		if len(values) > 0 {
+			query.Set(key, strings.Join(values, ","))
+		}
+	}
+	reqURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "llm-usage/"+version.Version)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := remoteHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach remote server: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote server returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var stats provider.UsageStats
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse remote response: %w", err)
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,55 @@
+package usage
+
+import (
+	"slices"
+
+	"github.com/denysvitali/llm-usage/internal/credentials"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// Selection narrows accounts down by their tags and group. Empty fields
+// select everything.
+type Selection struct {
+	Tags        []string // accounts with any of these tags
+	Groups      []string // accounts in any of these groups
+	ExcludeTags []string // accounts with none of these tags
+}
+
+// IsZero reports whether the selection selects every account
+func (s Selection) IsZero() bool {
+	return len(s.Tags) == 0 && len(s.Groups) == 0 && len(s.ExcludeTags) == 0
+}
+
+// Match reports whether an account with the given tags and group is selected
+func (s Selection) Match(tags []string, group string) bool {
+	hasAny := func(want []string) bool {
+		return slices.ContainsFunc(want, func(t string) bool { return slices.Contains(tags, t) })
+	}
+	if len(s.Tags) > 0 && !hasAny(s.Tags) {
+		return false
+	}
+	if len(s.Groups) > 0 && !slices.Contains(s.Groups, group) {
+		return false
+	}
+	return !hasAny(s.ExcludeTags)
+}
+
+// MatchMeta reports whether an account's credentials metadata is selected
+func (s Selection) MatchMeta(meta credentials.AccountMeta) bool {
+	return s.Match(meta.Tags, meta.Group)
+}
+
+// Filter returns the usage of the selected accounts
+func (s Selection) Filter(stats *provider.UsageStats) *provider.UsageStats {
+	if s.IsZero() {
+		return stats
+	}
+	out := &provider.UsageStats{}
+	for i := range stats.Providers {
+		u := &stats.Providers[i]
+		if s.Match(u.Tags(), u.Group()) {
+			out.Providers = append(out.Providers, *u)
+		}
+	}
+	return out
+}
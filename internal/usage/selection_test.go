@@ -0,0 +1,43 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func TestSelectionMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		sel   Selection
+		tags  []string
+		group string
+		want  bool
+	}{
+		{"empty", Selection{}, nil, "", true},
+		{"any tag", Selection{Tags: []string{"team-a", "client-x"}}, []string{"personal", "client-x"}, "", true},
+		{"missing tag", Selection{Tags: []string{"team-a"}}, []string{"personal"}, "", false},
+		{"group", Selection{Groups: []string{"work"}}, nil, "work", true},
+		{"other group", Selection{Groups: []string{"work"}}, nil, "home", false},
+		{"excluded", Selection{ExcludeTags: []string{"personal"}}, []string{"personal"}, "", false},
+		{"tag and excluded", Selection{Tags: []string{"team-a"}, ExcludeTags: []string{"personal"}}, []string{"team-a", "personal"}, "", false},
+	}
+	for _, tt := range tests {
+		if got := tt.sel.Match(tt.tags, tt.group); got != tt.want {
+			t.Errorf("%s: Match() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSelectionFilter(t *testing.T) {
+	stats := &provider.UsageStats{Providers: []provider.Usage{
+		{Provider: "claude", Extra: map[string]any{"tags": []string{"team-a"}}},
+		{Provider: "kimi", Extra: map[string]any{"tags": []any{"personal"}}},
+		{Provider: "zai"},
+	}}
+
+	got := Selection{ExcludeTags: []string{"personal"}}.Filter(stats)
+	if len(got.Providers) != 2 || got.Providers[0].Provider != "claude" || got.Providers[1].Provider != "zai" {
+		t.Errorf("Filter() = %+v, want claude and zai", got.Providers)
+	}
+}
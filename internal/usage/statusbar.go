@@ -0,0 +1,327 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// Status bar output formats
+const (
+	StatusI3bar    = "i3bar"
+	StatusI3blocks = "i3blocks"
+	StatusPolybar  = "polybar"
+	StatusTmux     = "tmux"
+	StatusXbar     = "xbar"
+	StatusConky    = "conky"
+)
+
+// statusBarAliases maps accepted format names to status bar formats
+var statusBarAliases = map[string]string{
+	StatusI3bar:    StatusI3bar,
+	StatusI3blocks: StatusI3blocks,
+	StatusPolybar:  StatusPolybar,
+	StatusTmux:     StatusTmux,
+	StatusXbar:     StatusXbar,
+	"swiftbar":     StatusXbar,
+	"argos":        StatusXbar,
+	StatusConky:    StatusConky,
+}
+
+// StatusBarFormat resolves a status bar format name, including the
+// swiftbar and argos aliases of xbar
+func StatusBarFormat(name string) (string, bool) {
+	format, ok := statusBarAliases[name]
+	return format, ok
+}
+
+// SeverityColors are the colors used by status bars for each class
+var SeverityColors = map[string]string{
+	"normal":   "#8ec07c",
+	"warning":  "#fabd2f",
+	"critical": "#fb4934",
+	"error":    "#fb4934",
+}
+
+// StatusSummary is the compact status shared by Waybar and the other status bar outputs
+type StatusSummary struct {
+	Text       string          // e.g. "C:42% K:10%"
+	Class      string          // normal, warning or critical
+	Percentage int             // highest utilization
+	Accounts   []StatusAccount // per-account details for tooltips and menus
+}
+
+// StatusAccount is a provider account's status
+type StatusAccount struct {
+	Name    string   // provider name with the account, e.g. "Kimi (work)"
+	Class   string   // normal, warning, critical or error
+	Error   string   // fetch error, if any
+	Windows []string // e.g. "5-Hour: 42.5% (resets in 2h 15m)"
+
+	Subscription string // e.g. "Moderato (Basic), Active, renews in 12d"
+	Balance      string // e.g. "12.50 USD left of 20.00"
+}
+
+// BarView selects what the compact status bar text shows
+type BarView struct {
+	Window  int  // window offset within each account, wrapping around
+	Account int  // 0 for all accounts, otherwise the focused account, wrapping around
+	Detail  bool // include window labels and reset countdowns
+}
+
+// Summarize builds the status bar summary of usage stats
+func Summarize(stats *provider.UsageStats, view BarView) StatusSummary {
+	summary := StatusSummary{
+		Class:      stats.GetClass(),
+		Percentage: int(stats.MaxUtilization()),
+	}
+
+	var shown []provider.Usage
+	for _, p := range stats.Providers {
+		name := ProviderName(p.Provider)
+		if acc, ok := p.Extra["account"]; ok && acc != "" {
+			name += fmt.Sprintf(" (%s)", acc)
+		}
+		account := StatusAccount{Name: name}
+
+		if p.Error != nil {
+			account.Class = "error"
+			account.Error = p.Error.Error()
+			summary.Accounts = append(summary.Accounts, account)
+			continue
+		}
+		if len(p.Windows) > 0 {
+			shown = append(shown, p)
+		}
+
+		for _, w := range p.Windows {
+			line := fmt.Sprintf("%s: %.1f%%", w.Label, w.Utilization)
+			if d := w.TimeUntilReset(); d != nil {
+				line += fmt.Sprintf(" (resets in %s)", FormatDuration(*d))
+			}
+			account.Windows = append(account.Windows, line)
+		}
+		if p.Subscription != nil {
+			account.Subscription = SubscriptionSummary(p.Subscription, time.Now())
+		}
+		if p.Balance != nil {
+			account.Balance = BalanceSummary(p.Balance, time.Now())
+		}
+		account.Class = p.Class()
+		summary.Accounts = append(summary.Accounts, account)
+	}
+
+	// Focus a single account; position 0 of the cycle shows all of them
+	focused := false
+	if focus := wrap(view.Account, len(shown)+1); focus > 0 {
+		shown = shown[focus-1 : focus]
+		focused = true
+	}
+
+	textParts := make([]string, 0, len(shown))
+	for _, p := range shown {
+		label := providerShortName(p.Provider)
+		if acc, _ := p.Extra["account"].(string); focused && acc != "" {
+			label += " " + acc
+		}
+
+		w := p.Windows[wrap(view.Window, len(p.Windows))]
+		if !view.Detail {
+			textParts = append(textParts, fmt.Sprintf("%s:%.0f%%", label, w.Utilization))
+			continue
+		}
+		part := fmt.Sprintf("%s %s:%.0f%%", label, w.Label, w.Utilization)
+		if d := w.TimeUntilReset(); d != nil {
+			part += " " + FormatDuration(*d)
+		}
+		textParts = append(textParts, part)
+	}
+	summary.Text = strings.Join(textParts, " ")
+
+	return summary
+}
+
+// wrap maps an offset into [0, n), also for negative offsets
+func wrap(i, n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return ((i % n) + n) % n
+}
+
+// Tooltip returns the multi-line tooltip text
+func (s StatusSummary) Tooltip() string {
+	lines := []string{"LLM Usage", ""}
+	for _, a := range s.Accounts {
+		if a.Error != "" {
+			lines = append(lines, fmt.Sprintf("%s: Error", a.Name))
+			continue
+		}
+		for _, w := range a.Windows {
+			lines = append(lines, a.Name+" "+w)
+		}
+		if a.Balance != "" {
+			lines = append(lines, a.Name+" Balance: "+a.Balance)
+		}
+		if a.Subscription != "" {
+			lines = append(lines, a.Name+" Plan: "+a.Subscription)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ShortText returns the abbreviated text for narrow bars
+func (s StatusSummary) ShortText() string {
+	return fmt.Sprintf("LLM %d%%", s.Percentage)
+}
+
+// I3barBlock is a block of the i3bar JSON protocol
+type I3barBlock struct {
+	Name      string `json:"name"`
+	FullText  string `json:"full_text"`
+	ShortText string `json:"short_text,omitempty"`
+	Color     string `json:"color,omitempty"`
+	Urgent    bool   `json:"urgent,omitempty"`
+}
+
+// I3barBlockFor returns the i3bar block for a status summary
+func I3barBlockFor(s StatusSummary) I3barBlock {
+	return I3barBlock{
+		Name:      "llm-usage",
+		FullText:  s.Text,
+		ShortText: s.ShortText(),
+		Color:     SeverityColors[s.Class],
+		Urgent:    s.Class == "critical",
+	}
+}
+
+// I3barErrorBlock returns the i3bar block for a failed fetch
+func I3barErrorBlock() I3barBlock {
+	return I3barBlock{
+		Name:     "llm-usage",
+		FullText: "LLM: Error",
+		Color:    SeverityColors["error"],
+		Urgent:   true,
+	}
+}
+
+// I3barHeader starts an i3bar protocol stream: the header and the opening of
+// the endless array of status lines
+const I3barHeader = "{\"version\":1}\n[\n"
+
+// writeI3barLine writes an i3bar protocol stream with a single status line.
+// i3bar doesn't accept a bare block, so even one-shot output needs the header.
+func writeI3barLine(w io.Writer, block I3barBlock) error {
+	line, err := json.Marshal([]I3barBlock{block})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", I3barHeader, line)
+	return err
+}
+
+// WriteStatusBar writes a status summary in a status bar format
+func WriteStatusBar(w io.Writer, format string, s StatusSummary) error {
+	if format == StatusI3bar {
+		return writeI3barLine(w, I3barBlockFor(s))
+	}
+
+	color := SeverityColors[s.Class]
+
+	var out string
+	switch format {
+	case StatusI3blocks:
+		out = s.Text + "\n" + s.ShortText() + "\n" + color + "\n"
+	case StatusPolybar:
+		out = polybarColor(s.Text, color) + "\n"
+	case StatusTmux:
+		out = tmuxColor(s.Text, color) + "\n"
+	case StatusConky:
+		out = conkyColor(s.Text, color) + "\n"
+	case StatusXbar:
+		out = xbarMenu(s)
+	default:
+		return fmt.Errorf("unknown status bar format %q", format)
+	}
+
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// WriteStatusBarError writes a fetch error in a status bar format
+func WriteStatusBarError(w io.Writer, format, msg string) error {
+	const text = "LLM: Error"
+	color := SeverityColors["error"]
+
+	var out string
+	switch format {
+	case StatusI3bar:
+		return writeI3barLine(w, I3barErrorBlock())
+	case StatusI3blocks:
+		out = text + "\n" + text + "\n" + color + "\n"
+	case StatusPolybar:
+		out = polybarColor(text, color) + "\n"
+	case StatusTmux:
+		out = tmuxColor(text, color) + "\n"
+	case StatusConky:
+		out = conkyColor(text, color) + "\n"
+	case StatusXbar:
+		out = text + " | color=" + color + "\n---\n" + xbarEscape(msg) + "\n"
+	default:
+		return fmt.Errorf("unknown status bar format %q", format)
+	}
+
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// polybarColor wraps text in polybar foreground format tags
+func polybarColor(text, color string) string {
+	return "%{F" + color + "}" + text + "%{F-}"
+}
+
+// tmuxColor wraps text in tmux style directives
+func tmuxColor(text, color string) string {
+	return "#[fg=" + color + "]" + strings.ReplaceAll(text, "#", "##") + "#[default]"
+}
+
+// conkyColor wraps text in conky color variables
+func conkyColor(text, color string) string {
+	return "${color " + color + "}" + strings.ReplaceAll(text, "$", "$$") + "${color}"
+}
+
+// xbarMenu renders the xbar/SwiftBar/argos menu, with a dropdown per account
+func xbarMenu(s StatusSummary) string {
+	var b strings.Builder
+	b.WriteString(xbarEscape(s.Text) + " | color=" + SeverityColors[s.Class] + "\n---\n")
+
+	for _, a := range s.Accounts {
+		b.WriteString(xbarEscape(a.Name) + " | color=" + SeverityColors[a.Class] + "\n")
+		if a.Error != "" {
+			b.WriteString("--Error: " + xbarEscape(a.Error) + "\n")
+			continue
+		}
+		for _, w := range a.Windows {
+			b.WriteString("--" + xbarEscape(w) + "\n")
+		}
+		if a.Balance != "" {
+			b.WriteString("--Balance: " + xbarEscape(a.Balance) + "\n")
+		}
+		if a.Subscription != "" {
+			b.WriteString("--Plan: " + xbarEscape(a.Subscription) + "\n")
+		}
+	}
+
+	b.WriteString("---\nRefresh | refresh=true\n")
+	return b.String()
+}
+
+// xbarEscape keeps menu text from being parsed as xbar parameters
+func xbarEscape(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	return strings.ReplaceAll(s, "|", "¦")
+}
@@ -0,0 +1,160 @@
+package usage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func TestSummarize(t *testing.T) {
+	s := Summarize(sampleStats(), BarView{})
+
+	if s.Text != "C:42%" {
+		t.Errorf("Text = %q, want %q", s.Text, "C:42%")
+	}
+	if s.Class != "normal" || s.Percentage != 42 {
+		t.Errorf("Class, Percentage = %q, %d, want normal, 42", s.Class, s.Percentage)
+	}
+	if len(s.Accounts) != 2 {
+		t.Fatalf("len(Accounts) = %d, want 2", len(s.Accounts))
+	}
+	if got := s.Accounts[0].Name; got != "Claude (Pro/Max Subscription) (work team)" {
+		t.Errorf("Accounts[0].Name = %q", got)
+	}
+	if got := s.Accounts[1]; got.Class != "error" || got.Error != "Kimi: unauthorized" {
+		t.Errorf("Accounts[1] = %+v, want an error account", got)
+	}
+
+	tooltip := s.Tooltip()
+	for _, want := range []string{
+		"LLM Usage\n\n",
+		"Claude (Pro/Max Subscription) (work team) 5-Hour: 42.5%",
+		"Claude (Pro/Max Subscription) (work team) Weekly \"Opus\": 10.0%",
+		"Kimi: Error",
+	} {
+		if !strings.Contains(tooltip, want) {
+			t.Errorf("tooltip missing %q:\n%s", want, tooltip)
+		}
+	}
+}
+
+func TestWriteStatusBar(t *testing.T) {
+	stats := &provider.UsageStats{Providers: []provider.Usage{
+		{Provider: "claude", Windows: []provider.UsageWindow{{Label: "5-Hour", Utilization: 95}}},
+		{Provider: "zai", Windows: []provider.UsageWindow{{Label: "Tokens", Utilization: 10}}},
+	}}
+	red := SeverityColors["critical"]
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{StatusI3blocks, "C:95% Z:10%\nLLM 95%\n" + red + "\n"},
+		{StatusPolybar, "%{F" + red + "}C:95% Z:10%%{F-}\n"},
+		{StatusTmux, "#[fg=" + red + "]C:95% Z:10%#[default]\n"},
+		{StatusConky, "${color " + red + "}C:95% Z:10%${color}\n"},
+		{StatusXbar, "C:95% Z:10% | color=" + red + "\n---\n" +
+			"Claude (Pro/Max Subscription) | color=" + red + "\n--5-Hour: 95.0%\n" +
+			"Z.AI | color=" + SeverityColors["normal"] + "\n--Tokens: 10.0%\n" +
+			"---\nRefresh | refresh=true\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteStatusBar(&buf, tt.format, Summarize(stats, BarView{})); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run(StatusI3bar, func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteStatusBar(&buf, StatusI3bar, Summarize(stats, BarView{})); err != nil {
+			t.Fatal(err)
+		}
+		line, ok := strings.CutPrefix(buf.String(), I3barHeader)
+		if !ok {
+			t.Fatalf("output doesn't start with the i3bar header:\n%s", buf.String())
+		}
+		var blocks []I3barBlock
+		if err := json.Unmarshal([]byte(line), &blocks); err != nil {
+			t.Fatal(err)
+		}
+		want := I3barBlock{Name: "llm-usage", FullText: "C:95% Z:10%", ShortText: "LLM 95%", Color: red, Urgent: true}
+		if len(blocks) != 1 || blocks[0] != want {
+			t.Errorf("blocks = %+v, want [%+v]", blocks, want)
+		}
+	})
+}
+
+func TestSummarizeView(t *testing.T) {
+	stats := &provider.UsageStats{Providers: []provider.Usage{
+		{Provider: "claude", Extra: map[string]any{"account": "work"}, Windows: []provider.UsageWindow{
+			{Label: "5-Hour", Utilization: 40},
+			{Label: "7-Day", Utilization: 70},
+		}},
+		{Provider: "kimi", Error: errors.New("unauthorized")},
+		{Provider: "zai", Windows: []provider.UsageWindow{{Label: "Tokens", Utilization: 10}}},
+	}}
+
+	tests := []struct {
+		view BarView
+		want string
+	}{
+		{BarView{}, "C:40% Z:10%"},
+		{BarView{Window: 1}, "C:70% Z:10%"},
+		{BarView{Window: -1}, "C:70% Z:10%"},
+		{BarView{Account: 1}, "C work:40%"},
+		{BarView{Account: 2}, "Z:10%"},
+		{BarView{Account: 3}, "C:40% Z:10%"},
+		{BarView{Account: -1}, "Z:10%"},
+		{BarView{Account: 1, Window: 1, Detail: true}, "C work 7-Day:70%"},
+	}
+	for _, tt := range tests {
+		if got := Summarize(stats, tt.view).Text; got != tt.want {
+			t.Errorf("Summarize(%+v).Text = %q, want %q", tt.view, got, tt.want)
+		}
+	}
+}
+
+func TestWriteStatusBarError(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteStatusBarError(&buf, StatusXbar, "no | providers"); err != nil {
+		t.Fatal(err)
+	}
+	want := "LLM: Error | color=" + SeverityColors["error"] + "\n---\nno ¦ providers\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+
+	if err := WriteStatusBarError(&buf, "lemonbar", "x"); err == nil {
+		t.Error("unknown format should fail")
+	}
+}
+
+func TestStatusBarFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"tmux", StatusTmux, true},
+		{"swiftbar", StatusXbar, true},
+		{"argos", StatusXbar, true},
+		{"lemonbar", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := StatusBarFormat(tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("StatusBarFormat(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
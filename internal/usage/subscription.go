@@ -0,0 +1,58 @@
+package usage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// FormatPrice formats a subscription price, e.g. "19.00 USD / 1 month"
+func FormatPrice(sub *provider.Subscription) string {
+	if sub.Price == nil {
+		return ""
+	}
+	price := fmt.Sprintf("%.2f", *sub.Price)
+	if sub.Currency != "" {
+		price += " " + sub.Currency
+	}
+	if sub.BillingCycle != "" {
+		price += " / " + sub.BillingCycle
+	}
+	return price
+}
+
+// FormatExpiry describes the end of the paid period relative to now,
+// e.g. "renews in 12d 3h", "expires in 2d" or "expired"
+func FormatExpiry(sub *provider.Subscription, now time.Time) string {
+	if sub.ExpiresAt == nil {
+		return ""
+	}
+	remaining := sub.ExpiresAt.Sub(now)
+	switch {
+	case remaining < 0:
+		return "expired"
+	case sub.Renews():
+		return "renews in " + FormatDuration(remaining)
+	default:
+		return "expires in " + FormatDuration(remaining)
+	}
+}
+
+// SubscriptionSummary returns a one-line subscription summary, e.g.
+// "Moderato (Basic), Active, 19.00 USD / 1 month, renews in 12d 3h"
+func SubscriptionSummary(sub *provider.Subscription, now time.Time) string {
+	plan := sub.Plan
+	if sub.Tier != "" {
+		plan = strings.TrimSpace(plan + " (" + sub.Tier + ")")
+	}
+
+	var parts []string
+	for _, part := range []string{plan, sub.StatusTitle(), FormatPrice(sub), FormatExpiry(sub, now)} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,189 @@
+package usage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// ANSI colors used by the color template function
+var severityANSI = map[string]string{
+	"normal":   "\033[32m",
+	"warning":  "\033[33m",
+	"critical": "\033[31m",
+}
+
+const ansiReset = "\033[0m"
+
+// TemplateFuncs are the helper functions available to --format templates
+var TemplateFuncs = template.FuncMap{
+	"bar":          templateBar,
+	"duration":     templateDuration,
+	"percent":      templatePercent,
+	"color":        templateColor,
+	"severity":     templateSeverity,
+	"providerName": ProviderName,
+	"shortName":    providerShortName,
+	"maxUtil":      templateMaxUtil,
+}
+
+// ParseFormat parses a --format template
+func ParseFormat(text string) (*template.Template, error) {
+	tmpl, err := template.New("format").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ParseFormatFile reads and parses a --format-file template
+func ParseFormatFile(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read format file: %w", err)
+	}
+	return ParseFormat(string(data))
+}
+
+// OutputTemplate renders usage stats with a template, ending with a newline
+func OutputTemplate(w io.Writer, tmpl *template.Template, stats *provider.UsageStats) error {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, stats); err != nil {
+		return fmt.Errorf("failed to render format template: %w", err)
+	}
+
+	out := b.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// toFloat converts template numbers to float64
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case *float64:
+		if n == nil {
+			return 0, nil
+		}
+		return *n, nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, fmt.Errorf("expected a number, got %T", v)
+	}
+}
+
+// templateBar renders a progress bar: {{ bar .Utilization }} or {{ bar .Utilization 10 }}
+func templateBar(value any, width ...int) (string, error) {
+	pct, err := toFloat(value)
+	if err != nil {
+		return "", err
+	}
+
+	w := barWidth
+	if len(width) > 0 && width[0] > 0 {
+		w = width[0]
+	}
+	filled := max(0, min(int(pct/100*float64(w)), w))
+	return strings.Repeat(barFull, filled) + strings.Repeat(barEmpty, w-filled), nil
+}
+
+// templateDuration formats the time until a reset or a duration: {{ duration .ResetsAt }}
+func templateDuration(v any) (string, error) {
+	switch t := v.(type) {
+	case *time.Time:
+		if t == nil {
+			return "N/A", nil
+		}
+		return FormatDuration(time.Until(*t)), nil
+	case time.Time:
+		return FormatDuration(time.Until(t)), nil
+	case time.Duration:
+		return FormatDuration(t), nil
+	case *time.Duration:
+		if t == nil {
+			return "N/A", nil
+		}
+		return FormatDuration(*t), nil
+	default:
+		return "", fmt.Errorf("duration: expected a time or duration, got %T", v)
+	}
+}
+
+// templatePercent formats a percentage: {{ percent .Utilization }} or {{ percent .Utilization 1 }}
+func templatePercent(value any, decimals ...int) (string, error) {
+	pct, err := toFloat(value)
+	if err != nil {
+		return "", err
+	}
+	d := 0
+	if len(decimals) > 0 {
+		d = decimals[0]
+	}
+	return strconv.FormatFloat(pct, 'f', d, 64) + "%", nil
+}
+
+// templateSeverity returns normal, warning or critical for a utilization
+func templateSeverity(value any) (string, error) {
+	pct, err := toFloat(value)
+	if err != nil {
+		return "", err
+	}
+	return provider.ClassForUtilization(pct), nil
+}
+
+// templateColor wraps text in an ANSI color by severity. The first argument
+// is a utilization or a severity name: {{ color .Utilization (percent .Utilization) }}
+func templateColor(level any, text string) (string, error) {
+	severity, ok := level.(string)
+	if _, known := severityANSI[severity]; !ok || !known {
+		pct, err := toFloat(level)
+		if err != nil {
+			return "", fmt.Errorf("color: expected a utilization or severity, got %v", level)
+		}
+		severity = provider.ClassForUtilization(pct)
+	}
+	return severityANSI[severity] + text + ansiReset, nil
+}
+
+// templateMaxUtil returns the highest utilization of UsageStats, a Usage or a window list
+func templateMaxUtil(v any) (float64, error) {
+	windowsMax := func(windows []provider.UsageWindow) float64 {
+		var m float64
+		for _, w := range windows {
+			m = max(m, w.Utilization)
+		}
+		return m
+	}
+
+	switch s := v.(type) {
+	case *provider.UsageStats:
+		return s.MaxUtilization(), nil
+	case provider.UsageStats:
+		return s.MaxUtilization(), nil
+	case provider.Usage:
+		return windowsMax(s.Windows), nil
+	case *provider.Usage:
+		return windowsMax(s.Windows), nil
+	case []provider.UsageWindow:
+		return windowsMax(s), nil
+	default:
+		return 0, fmt.Errorf("maxUtil: unsupported type %T", v)
+	}
+}
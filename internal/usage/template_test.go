@@ -0,0 +1,92 @@
+package usage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestOutputTemplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "providers",
+			format: `{{range .Providers}}{{shortName .Provider}}:{{if .Error}}err{{else}}{{percent (maxUtil .)}}{{end}} {{end}}`,
+			want:   "C:42% K:err \n",
+		},
+		{
+			name:   "overall",
+			format: `{{percent (maxUtil .) 1}} {{severity (maxUtil .)}}`,
+			want:   "42.5% normal\n",
+		},
+		{
+			name:   "bar",
+			format: `{{with index .Providers 0}}{{range .Windows}}[{{bar .Utilization 10}}]{{end}}{{end}}`,
+			want:   "[████░░░░░░][█░░░░░░░░░]\n",
+		},
+		{
+			name:   "color",
+			format: `{{color 95 "hot"}}{{color "warning" "warm"}}`,
+			want:   "\033[31mhot\033[0m\033[33mwarm\033[0m\n",
+		},
+		{
+			name:   "provider name",
+			format: "{{providerName \"kimi\"}}\n",
+			want:   "Kimi\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseFormat(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := OutputTemplate(&buf, tmpl, sampleStats()); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateDuration(t *testing.T) {
+	future := time.Now().Add(2*time.Hour + 30*time.Minute + 30*time.Second)
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{&future, "2h 30m"},
+		{(*time.Time)(nil), "N/A"},
+		{90 * time.Minute, "1h 30m"},
+		{-time.Minute, "expired"},
+	}
+	for _, tt := range tests {
+		got, err := templateDuration(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("duration(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := templateDuration("soon"); err == nil {
+		t.Error("duration(string) should fail")
+	}
+}
+
+func TestParseFormatError(t *testing.T) {
+	if _, err := ParseFormat("{{ bar "); err == nil {
+		t.Error("ParseFormat should fail on invalid templates")
+	}
+	if _, err := ParseFormat("{{ nope . }}"); err == nil {
+		t.Error("ParseFormat should fail on unknown functions")
+	}
+}
@@ -0,0 +1,210 @@
+package llmusage
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/denysvitali/llm-usage/internal/engine"
+	"github.com/denysvitali/llm-usage/internal/provider/claude"
+	"github.com/denysvitali/llm-usage/internal/provider/kimi"
+	"github.com/denysvitali/llm-usage/internal/provider/minimax"
+	"github.com/denysvitali/llm-usage/internal/provider/zai"
+	"github.com/denysvitali/llm-usage/internal/usage"
+)
+
+// ErrNoProviders is returned by Fetch when no provider accounts are configured
+var ErrNoProviders = engine.ErrNoProviders
+
+// Credentials holds explicit credentials for the built-in providers, keyed by
+// account name per provider. Config-defined HTTP providers and WebAssembly
+// plugins have no explicit credentials; use WithCredentialsDir or
+// WithCredentialsFile for them.
+type Credentials struct {
+	Claude  map[string]ClaudeAccount
+	Kimi    map[string]APIKeyAccount
+	ZAi     map[string]APIKeyAccount
+	MiniMax map[string]MiniMaxAccount
+}
+
+// ClaudeAccount holds an OAuth access token for a Claude Pro/Max account
+type ClaudeAccount struct {
+	AccessToken string
+}
+
+// APIKeyAccount holds an API key for key-based providers (Kimi, Z.AI)
+type APIKeyAccount struct {
+	APIKey string
+}
+
+// MiniMaxAccount holds cookie-based credentials for a MiniMax account
+type MiniMaxAccount struct {
+	Cookie  string
+	GroupID string
+}
+
+// Account identifies a single provider account resolved by a Client
+type Account struct {
+	Provider string
+	Name     string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithCredentialsDir reads per-provider credential files from dir instead of
+// $XDG_CONFIG_HOME/llm-usage
+func WithCredentialsDir(dir string) Option {
+	return func(c *Client) {
+		c.opts = append(c.opts, engine.WithCredentialsDir(dir))
+	}
+}
+
+// WithCredentialsFile reads credentials from a combined credentials file.
+// Values may reference environment variables using $VAR or ${VAR}.
+func WithCredentialsFile(path string) Option {
+	return func(c *Client) {
+		c.opts = append(c.opts, engine.WithCredentialsFile(path))
+	}
+}
+
+// WithCredentials uses the given credentials instead of any configuration on
+// disk, so only the built-in providers are queried
+func WithCredentials(creds Credentials) Option {
+	return func(c *Client) {
+		c.opts = append(c.opts, engine.WithExplicit(func() []usage.ProviderInstance {
+			return explicitInstances(creds)
+		}))
+	}
+}
+
+// WithProviders restricts queries to the given provider IDs (e.g. "claude", "kimi")
+func WithProviders(ids ...string) Option {
+	return func(c *Client) {
+		c.opts = append(c.opts, engine.WithProviders(ids...))
+	}
+}
+
+// WithAccount restricts queries to the named account
+func WithAccount(name string) Option {
+	return func(c *Client) {
+		c.opts = append(c.opts, engine.WithAccount(name))
+	}
+}
+
+// WithAllAccounts queries every account of each provider even when an account is set
+func WithAllAccounts() Option {
+	return func(c *Client) {
+		c.opts = append(c.opts, engine.WithAllAccounts())
+	}
+}
+
+// WithTags restricts queries to accounts tagged with any of the given tags
+// in the credential files
+func WithTags(tags ...string) Option {
+	return func(c *Client) {
+		c.opts = append(c.opts, engine.WithSelection(usage.Selection{Tags: tags}))
+	}
+}
+
+// WithGroups restricts queries to accounts in any of the given groups
+func WithGroups(groups ...string) Option {
+	return func(c *Client) {
+		c.opts = append(c.opts, engine.WithSelection(usage.Selection{Groups: groups}))
+	}
+}
+
+// WithExcludeTags skips accounts tagged with any of the given tags
+func WithExcludeTags(tags ...string) Option {
+	return func(c *Client) {
+		c.opts = append(c.opts, engine.WithSelection(usage.Selection{ExcludeTags: tags}))
+	}
+}
+
+// WithRemote fetches usage from a llm-usage server (llm-usage serve) instead of
+// querying providers directly. token is sent as a bearer token and may be empty.
+func WithRemote(baseURL, token string) Option {
+	return func(c *Client) {
+		c.opts = append(c.opts, engine.WithRemote(baseURL, token))
+	}
+}
+
+// WithPersistentProviders reuses provider clients across Fetch calls, keeping
+// their HTTP connections warm in long-running programs. Accounts are resolved
+// again after a fetch reports an error, picking up refreshed credentials.
+func WithPersistentProviders() Option {
+	return func(c *Client) {
+		c.opts = append(c.opts, engine.WithPersistentProviders())
+	}
+}
+
+// Client fetches usage statistics from configured provider accounts
+type Client struct {
+	opts   []engine.Option
+	engine *engine.Client
+}
+
+// New creates a Client. Without credential options it uses the same
+// configuration as the llm-usage CLI.
+func New(opts ...Option) (*Client, error) {
+	c := &Client{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	var err error
+	if c.engine, err = engine.New(c.opts...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Accounts returns the provider accounts that Fetch would query.
+// It returns nil for remote clients, whose accounts are resolved by the server.
+func (c *Client) Accounts() []Account {
+	resolved := c.engine.Accounts()
+	if resolved == nil {
+		return nil
+	}
+	accounts := make([]Account, 0, len(resolved))
+	for _, a := range resolved {
+		accounts = append(accounts, Account(a))
+	}
+	return accounts
+}
+
+// Fetch queries all resolved provider accounts concurrently.
+// Per-account failures are reported in Usage.Error rather than as an error.
+// When ctx is done first, the providers' requests finish in the background;
+// persistent clients wait for them before reusing the providers.
+func (c *Client) Fetch(ctx context.Context) (*UsageStats, error) {
+	stats, err := c.engine.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fromStats(stats), nil
+}
+
+// explicitInstances builds provider instances from explicit credentials
+func explicitInstances(creds Credentials) []usage.ProviderInstance {
+	var instances []usage.ProviderInstance
+	for _, name := range sortedKeys(creds.Claude) {
+		instances = append(instances, usage.ProviderInstance{Provider: claude.NewProvider(creds.Claude[name].AccessToken), AccountName: name})
+	}
+	for _, name := range sortedKeys(creds.Kimi) {
+		instances = append(instances, usage.ProviderInstance{Provider: kimi.NewProvider(creds.Kimi[name].APIKey), AccountName: name})
+	}
+	for _, name := range sortedKeys(creds.ZAi) {
+		instances = append(instances, usage.ProviderInstance{Provider: zai.NewProvider(creds.ZAi[name].APIKey), AccountName: name})
+	}
+	for _, name := range sortedKeys(creds.MiniMax) {
+		acc := creds.MiniMax[name]
+		instances = append(instances, usage.ProviderInstance{Provider: minimax.NewProvider(acc.Cookie, acc.GroupID), AccountName: name})
+	}
+	return instances
+}
+
+// sortedKeys returns the map's keys in a stable order
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
@@ -0,0 +1,161 @@
+package llmusage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/engine"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+func TestNew_ConflictingCredentials(t *testing.T) {
+	_, err := New(WithCredentials(Credentials{}), WithCredentialsDir(t.TempDir()))
+	if err == nil {
+		t.Error("expected error when combining WithCredentials and WithCredentialsDir")
+	}
+}
+
+func TestClient_ExplicitAccounts(t *testing.T) {
+	creds := Credentials{
+		Claude: map[string]ClaudeAccount{"work": {AccessToken: "a"}, "home": {AccessToken: "b"}},
+		Kimi:   map[string]APIKeyAccount{"default": {APIKey: "c"}},
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []Account
+	}{
+		{
+			name: "all accounts",
+			expected: []Account{
+				{Provider: "claude", Name: "home"},
+				{Provider: "claude", Name: "work"},
+				{Provider: "kimi", Name: "default"},
+			},
+		},
+		{
+			name:     "provider filter",
+			opts:     []Option{WithProviders("kimi")},
+			expected: []Account{{Provider: "kimi", Name: "default"}},
+		},
+		{
+			name:     "account filter",
+			opts:     []Option{WithAccount("work")},
+			expected: []Account{{Provider: "claude", Name: "work"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(append([]Option{WithCredentials(creds)}, tt.opts...)...)
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			got := client.Accounts()
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Accounts() = %v, want %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Accounts()[%d] = %v, want %v", i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClient_CredentialsDir(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte(`{"accounts": {"team": {"apiKey": "k"}}}`)
+	if err := os.WriteFile(filepath.Join(dir, "kimi.json"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(WithCredentialsDir(dir), WithProviders("kimi"))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	got := client.Accounts()
+	if len(got) != 1 || got[0] != (Account{Provider: "kimi", Name: "team"}) {
+		t.Errorf("Accounts() = %v, want [kimi/team]", got)
+	}
+}
+
+func TestClient_FetchNoProviders(t *testing.T) {
+	client, err := New(WithCredentials(Credentials{}))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := client.Fetch(context.Background()); !errors.Is(err, ErrNoProviders) {
+		t.Errorf("Fetch error = %v, want ErrNoProviders", err)
+	}
+}
+
+func TestClient_RemoteFetch(t *testing.T) {
+	const body = `{"schema_version":3,"providers":[{"provider":"claude","windows":[{"label":"5-Hour","utilization":42,"resets_at":null,"kind":"rolling","duration":"5h0m0s"}],"balance":{"amount":3.5,"currency":"USD"},"extra":{"account":"work"},"error":null}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	client, err := New(WithRemote(srv.URL, ""))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	stats, err := client.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	u := stats.Providers[0]
+	if u.Account != "work" || u.Balance == nil || u.Balance.Amount != 3.5 {
+		t.Errorf("Usage = %+v, want account work with a 3.5 balance", u)
+	}
+	if w := u.Windows[0]; w.Kind != KindRolling || w.Duration != 5*time.Hour {
+		t.Errorf("Windows[0] = %+v, want a rolling 5h window", w)
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != body {
+		t.Errorf("Marshal = %s, want %s", data, body)
+	}
+}
+
+func TestUnwrap_RoundTrip(t *testing.T) {
+	// The CLI renders the engine's results after a trip through the public types
+	resets := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	limit, price := 100.0, 20.0
+	stats := &provider.UsageStats{
+		Providers: []provider.Usage{
+			{
+				Provider: "claude",
+				Windows: []provider.UsageWindow{{
+					Label: "5-Hour", Utilization: 42, ResetsAt: &resets, Limit: &limit,
+					Kind: provider.KindRolling, Duration: provider.Duration(5 * time.Hour), Unit: provider.UnitTokens, Model: "opus",
+				}},
+				Subscription: &provider.Subscription{Plan: "Max", Price: &price, Features: []provider.Feature{{Name: "search", Left: 3, Total: 5}}},
+				Balance:      &provider.Balance{Amount: 1.5, Currency: "USD"},
+				Extra:        map[string]any{"account": "work"},
+			},
+			{Provider: "kimi", Extra: map[string]any{}, Error: errors.New("timeout")},
+		},
+		Groups: []provider.GroupRollup{{Group: "team", Accounts: []string{"claude/work"}, Windows: []provider.RollupWindow{{Label: "5-Hour", Accounts: 1}}}},
+	}
+
+	if got := engine.Unwrap(fromStats(stats)); !reflect.DeepEqual(got, stats) {
+		t.Errorf("Unwrap(fromStats()) = %+v, want %+v", got, stats)
+	}
+}
@@ -0,0 +1,31 @@
+// Package llmusage fetches LLM provider usage statistics for embedding in Go programs.
+//
+// The llm-usage CLI fetches usage through this package: a Client resolves the
+// configured provider accounts (Claude, Kimi, Z.AI, MiniMax, config-defined
+// HTTP providers and WebAssembly plugins) and queries them concurrently.
+//
+// Credentials can come from the default llm-usage configuration directory,
+// an explicit directory, a combined credentials file, or be passed in
+// directly:
+//
+//	client, err := llmusage.New(llmusage.WithCredentials(llmusage.Credentials{
+//		Claude: map[string]llmusage.ClaudeAccount{"work": {AccessToken: token}},
+//	}))
+//	if err != nil {
+//		return err
+//	}
+//	stats, err := client.Fetch(ctx)
+//
+// Explicit credentials only cover the built-in providers; config-defined
+// providers and plugins read theirs from disk.
+//
+// # Compatibility
+//
+// This package follows semantic versioning. Within a major version, exported
+// identifiers are not removed or changed incompatibly; new fields, options and
+// functions may be added. The result types (Usage, UsageWindow, UsageStats,
+// Subscription, Balance and GroupRollup) are defined here rather than shared
+// with the CLI's internals; they may gain fields in minor releases, so avoid
+// unkeyed struct literals. Their MarshalJSON output matches the CLI's --json
+// format (see SchemaVersion).
+package llmusage
@@ -0,0 +1,77 @@
+package llmusage_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/denysvitali/llm-usage/pkg/llmusage"
+)
+
+// Fetch usage using the same configuration as the llm-usage CLI.
+func Example() {
+	client, err := llmusage.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stats, err := client.Fetch(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, u := range stats.Providers {
+		if u.Error != nil {
+			fmt.Printf("%s: %v\n", u.Provider, u.Error)
+			continue
+		}
+		for _, w := range u.Windows {
+			fmt.Printf("%s %s: %.1f%%\n", u.Provider, w.Label, w.Utilization)
+		}
+	}
+}
+
+// Pass credentials explicitly instead of reading them from disk.
+func ExampleWithCredentials() {
+	client, err := llmusage.New(
+		llmusage.WithCredentials(llmusage.Credentials{
+			Claude: map[string]llmusage.ClaudeAccount{
+				"work": {AccessToken: os.Getenv("CLAUDE_TOKEN")},
+			},
+			Kimi: map[string]llmusage.APIKeyAccount{
+				"default": {APIKey: os.Getenv("KIMI_API_KEY")},
+			},
+		}),
+		llmusage.WithProviders("claude"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, acc := range client.Accounts() {
+		fmt.Printf("%s/%s\n", acc.Provider, acc.Name)
+	}
+	// Output: claude/work
+}
+
+// Read credentials from a dedicated directory, e.g. one mounted as a secret.
+func ExampleWithCredentialsDir() {
+	client, err := llmusage.New(
+		llmusage.WithCredentialsDir("/run/secrets/llm-usage"),
+		llmusage.WithAccount("work"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stats, err := client.Fetch(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("max utilization: %.1f%%\n", stats.MaxUtilization())
+}
@@ -0,0 +1,349 @@
+package llmusage
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/denysvitali/llm-usage/internal/engine"
+	"github.com/denysvitali/llm-usage/internal/provider"
+)
+
+// SchemaVersion is the version of the JSON format written by
+// UsageStats.MarshalJSON, the same as the llm-usage CLI's --json output
+const SchemaVersion = provider.SchemaVersion
+
+// WindowKind describes how a usage window resets
+type WindowKind string
+
+// Window kinds
+const (
+	KindRolling   WindowKind = "rolling"    // slides with time, e.g. the last 5 hours
+	KindFixed     WindowKind = "fixed"      // resets at a fixed time, e.g. a weekly quota
+	KindMonthly   WindowKind = "monthly"    // resets with the billing month
+	KindRateLimit WindowKind = "rate_limit" // short burst limit, e.g. per minute
+)
+
+// Unit is what a usage window counts
+type Unit string
+
+// Window units
+const (
+	UnitTokens   Unit = "tokens"
+	UnitRequests Unit = "requests"
+	UnitPrompts  Unit = "prompts"
+	UnitCredits  Unit = "credits"
+	UnitPercent  Unit = "percent" // only the utilization is known
+)
+
+// Subscription statuses
+const (
+	SubscriptionActive    = "active"
+	SubscriptionCancelled = "cancelled" // paid until ExpiresAt, won't renew
+	SubscriptionExpired   = "expired"
+)
+
+// UsageStats aggregates the usage of all queried provider accounts
+type UsageStats struct {
+	Providers []Usage
+
+	// Groups rolls up accounts by group; only set by remote clients
+	Groups []GroupRollup
+}
+
+// Usage is the usage reported by a single provider account
+type Usage struct {
+	Provider     string
+	Account      string // Account name; empty for a provider's only account
+	Windows      []UsageWindow
+	Subscription *Subscription // nil when the provider reports no plan
+	Balance      *Balance      // nil when the provider reports no prepaid balance
+	Extra        map[string]any
+
+	// Error if fetching failed (allows partial results)
+	Error error
+}
+
+// UsageWindow is a single usage window (e.g. "5-Hour") within a Usage
+type UsageWindow struct {
+	Label       string     // e.g., "5-Hour", "7-Day", "Daily"
+	Utilization float64    // 0-100 percentage
+	ResetsAt    *time.Time // When this window resets (can be nil)
+
+	Limit     *float64 // Usage limit (e.g., token count)
+	Used      *float64 // Amount used
+	Remaining *float64 // Amount remaining
+
+	// Structured metadata; empty when the provider doesn't report it
+	Kind     WindowKind
+	Duration time.Duration // Window length, e.g. 5h
+	StartsAt *time.Time    // When the current window started
+	Unit     Unit
+	Model    string // Model the window is scoped to, e.g. "opus"; empty for all models
+}
+
+// Subscription is the plan an account is subscribed to
+type Subscription struct {
+	Plan         string     // Plan name, e.g. "Moderato"
+	Tier         string     // Membership level, e.g. "Basic"
+	Status       string     // One of the Subscription* statuses, or the provider's own
+	BillingCycle string     // e.g. "1 month"
+	Price        *float64   // Price per billing cycle
+	Currency     string     // ISO 4217 code, e.g. "USD"
+	ExpiresAt    *time.Time // End of the paid period: the renewal date while active
+	Features     []Feature
+}
+
+// Feature is a per-feature quota included in a subscription
+type Feature struct {
+	Name     string
+	Left     int
+	Total    int
+	ResetsAt *time.Time
+}
+
+// Balance is a prepaid currency or credit balance
+type Balance struct {
+	Amount    float64    // Spendable amount left
+	Currency  string     // ISO 4217 code, e.g. "USD", or "credits"
+	Granted   *float64   // Free credits granted
+	ToppedUp  *float64   // Credits bought
+	Used      *float64   // Credits spent
+	ExpiresAt *time.Time // When the granted credits expire
+}
+
+// GroupRollup aggregates the usage of the accounts in a group
+type GroupRollup struct {
+	Group          string
+	Accounts       []string // "provider/account"
+	Errors         int      // accounts whose fetch failed
+	MaxUtilization float64
+	Class          string // "normal", "warning" or "critical"
+	Windows        []RollupWindow
+}
+
+// RollupWindow aggregates a window label across a group's accounts
+type RollupWindow struct {
+	Label          string
+	Accounts       int
+	MaxUtilization float64
+	AvgUtilization float64
+}
+
+// MaxUtilization returns the maximum utilization across all providers
+func (s *UsageStats) MaxUtilization() float64 {
+	var maxUtil float64
+	for _, p := range s.Providers {
+		if p.Error != nil {
+			continue
+		}
+		for _, w := range p.Windows {
+			maxUtil = max(maxUtil, w.Utilization)
+		}
+	}
+	return maxUtil
+}
+
+// ProviderByID returns the first usage of a provider from the stats
+func (s *UsageStats) ProviderByID(id string) *Usage {
+	for i := range s.Providers {
+		if s.Providers[i].Provider == id {
+			return &s.Providers[i]
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the stats in the llm-usage JSON format (see SchemaVersion)
+func (s UsageStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toStats(&s))
+}
+
+// MarshalJSON encodes the usage in the llm-usage JSON format
+func (u Usage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toUsage(u))
+}
+
+// TimeUntilReset returns the duration until the window resets, or nil when unknown
+func (w *UsageWindow) TimeUntilReset() *time.Duration {
+	if w == nil || w.ResetsAt == nil {
+		return nil
+	}
+	d := time.Until(*w.ResetsAt)
+	return &d
+}
+
+// Utilization returns the used share of the feature quota as a percentage
+func (f Feature) Utilization() float64 {
+	if f.Total <= 0 {
+		return 0
+	}
+	return float64(f.Total-f.Left) / float64(f.Total) * 100
+}
+
+// Renews reports whether the subscription renews at ExpiresAt instead of ending
+func (s *Subscription) Renews() bool {
+	return s.Status == SubscriptionActive
+}
+
+// Total returns the granted plus topped-up credits, or 0 when unknown
+func (b *Balance) Total() float64 {
+	var total float64
+	if b.Granted != nil {
+		total += *b.Granted
+	}
+	if b.ToppedUp != nil {
+		total += *b.ToppedUp
+	}
+	return total
+}
+
+func init() {
+	engine.Unwrap = func(stats any) *provider.UsageStats {
+		out := toStats(stats.(*UsageStats))
+		return &out
+	}
+}
+
+// fromStats converts engine results to the public types
+func fromStats(s *provider.UsageStats) *UsageStats {
+	out := &UsageStats{Providers: make([]Usage, 0, len(s.Providers))}
+	for _, u := range s.Providers {
+		account, _ := u.Extra["account"].(string)
+		out.Providers = append(out.Providers, Usage{
+			Provider:     u.Provider,
+			Account:      account,
+			Windows:      fromWindows(u.Windows),
+			Subscription: fromSubscription(u.Subscription),
+			Balance:      (*Balance)(u.Balance),
+			Extra:        u.Extra,
+			Error:        u.Error,
+		})
+	}
+	for _, g := range s.Groups {
+		rollup := GroupRollup{
+			Group:          g.Group,
+			Accounts:       g.Accounts,
+			Errors:         g.Errors,
+			MaxUtilization: g.MaxUtilization,
+			Class:          g.Class,
+		}
+		for _, w := range g.Windows {
+			rollup.Windows = append(rollup.Windows, RollupWindow(w))
+		}
+		out.Groups = append(out.Groups, rollup)
+	}
+	return out
+}
+
+func fromWindows(windows []provider.UsageWindow) []UsageWindow {
+	if windows == nil {
+		return nil
+	}
+	out := make([]UsageWindow, 0, len(windows))
+	for _, w := range windows {
+		out = append(out, UsageWindow{
+			Label:       w.Label,
+			Utilization: w.Utilization,
+			ResetsAt:    w.ResetsAt,
+			Limit:       w.Limit,
+			Used:        w.Used,
+			Remaining:   w.Remaining,
+			Kind:        WindowKind(w.Kind),
+			Duration:    time.Duration(w.Duration),
+			StartsAt:    w.StartsAt,
+			Unit:        Unit(w.Unit),
+			Model:       w.Model,
+		})
+	}
+	return out
+}
+
+func fromSubscription(s *provider.Subscription) *Subscription {
+	if s == nil {
+		return nil
+	}
+	out := &Subscription{
+		Plan:         s.Plan,
+		Tier:         s.Tier,
+		Status:       s.Status,
+		BillingCycle: s.BillingCycle,
+		Price:        s.Price,
+		Currency:     s.Currency,
+		ExpiresAt:    s.ExpiresAt,
+	}
+	for _, f := range s.Features {
+		out.Features = append(out.Features, Feature(f))
+	}
+	return out
+}
+
+// toStats converts public results back for JSON encoding
+func toStats(s *UsageStats) provider.UsageStats {
+	out := provider.UsageStats{Providers: make([]provider.Usage, 0, len(s.Providers))}
+	for _, u := range s.Providers {
+		out.Providers = append(out.Providers, toUsage(u))
+	}
+	for _, g := range s.Groups {
+		rollup := provider.GroupRollup{
+			Group:          g.Group,
+			Accounts:       g.Accounts,
+			Errors:         g.Errors,
+			MaxUtilization: g.MaxUtilization,
+			Class:          g.Class,
+		}
+		for _, w := range g.Windows {
+			rollup.Windows = append(rollup.Windows, provider.RollupWindow(w))
+		}
+		out.Groups = append(out.Groups, rollup)
+	}
+	return out
+}
+
+func toUsage(u Usage) provider.Usage {
+	out := provider.Usage{
+		Provider: u.Provider,
+		Balance:  (*provider.Balance)(u.Balance),
+		Extra:    u.Extra,
+		Error:    u.Error,
+	}
+	if u.Account != "" && u.Extra["account"] == nil {
+		out.Extra = make(map[string]any, len(u.Extra)+1)
+		for k, v := range u.Extra {
+			out.Extra[k] = v
+		}
+		out.Extra["account"] = u.Account
+	}
+	if u.Windows != nil {
+		out.Windows = make([]provider.UsageWindow, 0, len(u.Windows))
+	}
+	for _, w := range u.Windows {
+		out.Windows = append(out.Windows, provider.UsageWindow{
+			Label:       w.Label,
+			Utilization: w.Utilization,
+			ResetsAt:    w.ResetsAt,
+			Limit:       w.Limit,
+			Used:        w.Used,
+			Remaining:   w.Remaining,
+			Kind:        provider.WindowKind(w.Kind),
+			Duration:    provider.Duration(w.Duration),
+			StartsAt:    w.StartsAt,
+			Unit:        provider.Unit(w.Unit),
+			Model:       w.Model,
+		})
+	}
+	if s := u.Subscription; s != nil {
+		out.Subscription = &provider.Subscription{
+			Plan:         s.Plan,
+			Tier:         s.Tier,
+			Status:       s.Status,
+			BillingCycle: s.BillingCycle,
+			Price:        s.Price,
+			Currency:     s.Currency,
+			ExpiresAt:    s.ExpiresAt,
+		}
+		for _, f := range s.Features {
+			out.Subscription.Features = append(out.Subscription.Features, provider.Feature(f))
+		}
+	}
+	return out
+}